@@ -0,0 +1,69 @@
+package benchmarks
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mohae/firkin/queuetest"
+)
+
+// size is the capacity of the bounded queues, and the initial capacity of the
+// unbounded ones.
+const size = 1024
+
+func BenchmarkEnqueueDequeue(b *testing.B) {
+	for _, impl := range queuetest.Impls() {
+		for _, g := range []int{1, 4, 16} {
+			b.Run(fmt.Sprintf("%s/%d", impl.Name, g), func(b *testing.B) {
+				produceConsume(b, impl, g)
+			})
+		}
+	}
+}
+
+// produceConsume has g producers enqueue, and g consumers dequeue, b.N items.
+func produceConsume(b *testing.B, impl queuetest.Impl, g int) {
+	q := impl.New(size)
+	consumers := g
+	if impl.SingleConsumer {
+		consumers = 1
+	}
+	var received atomic.Int64
+	var wg sync.WaitGroup
+	b.ResetTimer()
+	// each producer enqueues its own range of ints, so that no item is
+	// enqueued twice; see queue.Unique.
+	for p, start := 0, 0; p < g; p++ {
+		n := b.N / g
+		if p < b.N%g {
+			n++
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				for q.Enqueue(i) != nil {
+					runtime.Gosched()
+				}
+			}
+		}(start, start+n)
+		start += n
+	}
+	for c := 0; c < consumers; c++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for received.Load() < int64(b.N) {
+				if _, ok := q.Dequeue(); ok {
+					received.Add(1)
+					continue
+				}
+				runtime.Gosched()
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,11 @@
+// Package benchmarks compares the throughput of the queue implementations,
+// to help with picking one. It has no code of its own; run its benchmarks
+// with:
+//
+//	go test -bench . github.com/mohae/firkin/benchmarks
+//
+// Each benchmark has g producers enqueue, and g consumers dequeue, b.N items
+// in total, for g of 1, 4, and 16; queues that only support one consumer have
+// one consumer. The implementations benchmarked are queuetest.Impls, so a new
+// queue added there is both conformance tested and benchmarked.
+package benchmarks
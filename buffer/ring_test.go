@@ -1,6 +1,8 @@
 package buffer
 
 import (
+	"context"
+	"runtime"
 	"testing"
 )
 
@@ -41,8 +43,8 @@ func TestRingBufferEnqueueDequeuePeek(t *testing.T) {
 		for _, v := range test.items {
 			_ = b.Enqueue(v)
 		}
-		if b.Tail != test.tailPos {
-			t.Errorf("%d: post initial enqueue, expected tail to be at pos %d, was at %d", i, test.tailPos, b.Tail)
+		if _, tail := b.Positions(); tail != test.tailPos {
+			t.Errorf("%d: post initial enqueue, expected tail to be at pos %d, was at %d", i, test.tailPos, tail)
 		}
 		for j, v := range test.dequeue {
 			val, _ := b.Dequeue()
@@ -50,69 +52,57 @@ func TestRingBufferEnqueueDequeuePeek(t *testing.T) {
 				t.Errorf("%d dequeue item %d: expected %v got %v", i, j, v, val)
 			}
 		}
-		if b.Head != test.dequeueHeadPos {
-			t.Errorf("%d: post dequeue, expected head pos to be %d, got %d", i, test.dequeueHeadPos, b.Head)
+		if head, _ := b.Positions(); head != test.dequeueHeadPos {
+			t.Errorf("%d: post dequeue, expected head pos to be %d, got %d", i, test.dequeueHeadPos, head)
 		}
-		if b.Tail != test.dequeueTailPos {
-			t.Errorf("%d: post dequeue, expected Tail pos to be %d, got %d", i, test.dequeueTailPos, b.Tail)
+		if _, tail := b.Positions(); tail != test.dequeueTailPos {
+			t.Errorf("%d: post dequeue, expected Tail pos to be %d, got %d", i, test.dequeueTailPos, tail)
 		}
 		if b.Len() != len(test.dequeueRemaining) {
 			t.Errorf("%d: after dequeue, expected %d items in buffer, got %d", i, len(test.dequeueRemaining), b.Len())
 			goto Enqueue1
 		}
 		for j, v := range test.dequeueRemaining {
-			k := b.Head + j
-			if k >= cap(b.Items) {
-				k -= cap(b.Items)
-			}
-			if v != b.Items[k] {
-				t.Errorf("%d dequeueRemainingItem %d: expected %v, got %v", i, j, v, b.Items[k])
+			if got, _ := b.At(j); v != got {
+				t.Errorf("%d dequeueRemainingItem %d: expected %v, got %v", i, j, v, got)
 			}
 		}
 	Enqueue1:
 		for _, v := range test.enqueue1 {
 			_ = b.Enqueue(v)
 		}
-		if b.Head != test.enqueue1HeadPos {
-			t.Errorf("%d: after enqueue1, expected head pos to be %d got %d", i, test.enqueue1HeadPos, b.Head)
+		if head, _ := b.Positions(); head != test.enqueue1HeadPos {
+			t.Errorf("%d: after enqueue1, expected head pos to be %d got %d", i, test.enqueue1HeadPos, head)
 		}
-		if b.Tail != test.enqueue1TailPos {
-			t.Errorf("%d: after enqueue1, expected head pos to be %d got %d", i, test.enqueue1TailPos, b.Tail)
+		if _, tail := b.Positions(); tail != test.enqueue1TailPos {
+			t.Errorf("%d: after enqueue1, expected head pos to be %d got %d", i, test.enqueue1TailPos, tail)
 		}
 		if b.Len() != len(test.enqueue1Remaining) {
 			t.Errorf("%d: after enqueue1, expected %d items in buffer, got %d", i, len(test.enqueue1Remaining), b.Len())
 			goto Enqueue2
 		}
 		for j, v := range test.enqueue1Remaining {
-			k := b.Head + j
-			if k >= cap(b.Items) {
-				k -= cap(b.Items)
-			}
-			if v != b.Items[k] {
-				t.Errorf("%d enqueue1RemainingItem %d: expected %v, got %v", i, j, v, b.Items[k])
+			if got, _ := b.At(j); v != got {
+				t.Errorf("%d enqueue1RemainingItem %d: expected %v, got %v", i, j, v, got)
 			}
 		}
 	Enqueue2:
 		for _, v := range test.enqueue2 {
 			_ = b.Enqueue(v)
 		}
-		if b.Head != test.enqueue2HeadPos {
-			t.Errorf("%d: after enqueue2, expected head pos to be %d got %d", i, test.enqueue2HeadPos, b.Head)
+		if head, _ := b.Positions(); head != test.enqueue2HeadPos {
+			t.Errorf("%d: after enqueue2, expected head pos to be %d got %d", i, test.enqueue2HeadPos, head)
 		}
-		if b.Tail != test.enqueue2TailPos {
-			t.Errorf("%d: after enqueue2, expected head pos to be %d got %d", i, test.enqueue2TailPos, b.Tail)
+		if _, tail := b.Positions(); tail != test.enqueue2TailPos {
+			t.Errorf("%d: after enqueue2, expected head pos to be %d got %d", i, test.enqueue2TailPos, tail)
 		}
 		if b.Len() != len(test.enqueue2Remaining) {
 			t.Errorf("%d: after enqueue2, expected %d items in buffer, got %d", i, len(test.enqueue2Remaining), b.Len())
 			goto Enqueue2
 		}
 		for j, v := range test.enqueue2Remaining {
-			k := b.Head + j
-			if k >= cap(b.Items) {
-				k -= cap(b.Items)
-			}
-			if v != b.Items[k] {
-				t.Errorf("%d enqueue2RemainingItem %d: expected %v, got %v", i, j, v, b.Items[k])
+			if got, _ := b.At(j); v != got {
+				t.Errorf("%d enqueue2RemainingItem %d: expected %v, got %v", i, j, v, got)
 			}
 		}
 	}
@@ -160,21 +150,51 @@ func TestBufferPos(t *testing.T) {
 				if v != test.val {
 					t.Errorf("%d: dequeue val expected to be %s, got %s", i, test.val, v)
 				}
-				if b.Head != test.expectedHead {
-					t.Errorf("%d: post dequeue expected head to be at pos %d, was at %d", i, test.expectedHead, b.Head)
+				if head, _ := b.Positions(); head != test.expectedHead {
+					t.Errorf("%d: post dequeue expected head to be at pos %d, was at %d", i, test.expectedHead, head)
 				}
-				if b.Tail != test.expectedTail {
-					t.Errorf("%d: post dequeue expected tail to be at pos %d, was at %d", i, test.expectedTail, b.Tail)
+				if _, tail := b.Positions(); tail != test.expectedTail {
+					t.Errorf("%d: post dequeue expected tail to be at pos %d, was at %d", i, test.expectedTail, tail)
 				}
 			}
 			continue
 		}
 		_ = b.Enqueue(test.val)
-		if b.Head != test.expectedHead {
-			t.Errorf("%d: post enqueue, expected head to be at pos %d, was at %d", i, test.expectedHead, b.Head)
+		if head, _ := b.Positions(); head != test.expectedHead {
+			t.Errorf("%d: post enqueue, expected head to be at pos %d, was at %d", i, test.expectedHead, head)
 		}
-		if b.Tail != test.expectedTail {
-			t.Errorf("%d: post enqueue, expected head to be at pos %d, was at %d", i, test.expectedTail, b.Tail)
+		if _, tail := b.Positions(); tail != test.expectedTail {
+			t.Errorf("%d: post enqueue, expected head to be at pos %d, was at %d", i, test.expectedTail, tail)
 		}
 	}
 }
+
+func BenchmarkGCRing(b *testing.B) {
+	var before, after runtime.MemStats
+	r := NewRing(1024)
+	item := &struct{ n int }{42}
+	b.ReportAllocs()
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = r.Enqueue(item)
+	}
+	b.StopTimer()
+	runtime.ReadMemStats(&after)
+	b.ReportMetric(float64(after.PauseTotalNs-before.PauseTotalNs)/float64(b.N), "gc-pause-ns/op")
+	b.ReportMetric(float64(after.NumGC-before.NumGC), "gcs")
+}
+
+func TestRingEnqueueCtx(t *testing.T) {
+	r := NewRing(1)
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if err := r.EnqueueCtx(ctx, i); err != nil {
+			t.Errorf("%d: unexpected error: %s", i, err)
+		}
+	}
+	if v, _ := r.Peek(); v != 2 {
+		t.Errorf("expected the full ring to evict, got %v", v)
+	}
+}
@@ -0,0 +1,161 @@
+// Package httpqueue protects an HTTP server from overload: requests are
+// admitted to the wrapped handler with a limit on how many are served at once,
+// the rest wait in a bounded queue. Requests that can't be queued, or that wait
+// too long, are rejected with a Retry-After header.
+package httpqueue
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mohae/firkin/queue"
+)
+
+// Config configures an admission Handler.
+type Config struct {
+	Concurrency int           // the number of requests served at once; the default is 1.
+	QueueSize   int           // the number of requests that can wait; 0 is none.
+	Timeout     time.Duration // how long a request can wait; 0 is until the client goes away.
+	RetryAfter  time.Duration // the Retry-After given rejected requests; the default is 1s.
+}
+
+// The reasons a request is rejected: requests that can't be queued get a 429,
+// requests that time out, a 503.
+var (
+	errQueueFull = errors.New("httpqueue: queue full")
+	errTimeout   = errors.New("httpqueue: timed out waiting to be served")
+)
+
+// waiter is a queued request. granted is protected by the Handler's lock.
+type waiter struct {
+	ready   chan struct{}
+	granted bool // the request was given a slot.
+}
+
+// Handler is an http.Handler that admits requests to another handler through
+// a bounded queue.
+type Handler struct {
+	next     http.Handler
+	cfg      Config
+	mu       sync.Mutex
+	inFlight int
+	waiting  *queue.Circular
+}
+
+// New returns a Handler that admits requests to next as configured by cfg.
+func New(next http.Handler, cfg Config) *Handler {
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+	if cfg.RetryAfter <= 0 {
+		cfg.RetryAfter = time.Second
+	}
+	h := &Handler{next: next, cfg: cfg}
+	if cfg.QueueSize > 0 {
+		h.waiting = queue.NewCircular(cfg.QueueSize)
+	}
+	return h
+}
+
+// Middleware returns a func that wraps handlers with New; it's for use with
+// routers that take middleware.
+func Middleware(cfg Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return New(next, cfg)
+	}
+}
+
+// ServeHTTP serves the request once it has been admitted.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := h.acquire(r.Context()); err != nil {
+		w.Header().Set("Retry-After", strconv.Itoa(int((h.cfg.RetryAfter+time.Second-1)/time.Second)))
+		code := http.StatusServiceUnavailable
+		if err == errQueueFull {
+			code = http.StatusTooManyRequests
+		}
+		http.Error(w, http.StatusText(code), code)
+		return
+	}
+	defer h.release()
+	h.next.ServeHTTP(w, r)
+}
+
+// acquire waits for a slot to serve the request in.
+func (h *Handler) acquire(ctx context.Context) error {
+	h.mu.Lock()
+	if h.inFlight < h.cfg.Concurrency {
+		h.inFlight++
+		h.mu.Unlock()
+		return nil
+	}
+	if h.waiting == nil {
+		h.mu.Unlock()
+		return errQueueFull
+	}
+	wt := &waiter{ready: make(chan struct{}, 1)}
+	if err := h.waiting.Enqueue(wt); err != nil {
+		h.mu.Unlock()
+		return errQueueFull
+	}
+	h.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if h.cfg.Timeout > 0 {
+		t := time.NewTimer(h.cfg.Timeout)
+		defer t.Stop()
+		timeout = t.C
+	}
+	select {
+	case <-wt.ready:
+		return nil
+	case <-timeout:
+	case <-ctx.Done():
+	}
+	h.mu.Lock()
+	if wt.granted {
+		// the slot was granted as the wait ended; pass it on.
+		h.mu.Unlock()
+		h.release()
+		return errTimeout
+	}
+	// the request stopped waiting; free its place in the queue.
+	h.waiting.RemoveWhere(func(v interface{}) bool { return v == wt })
+	h.mu.Unlock()
+	return errTimeout
+}
+
+// release gives the request's slot to the next waiting request, if there is
+// one.
+func (h *Handler) release() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.waiting != nil {
+		if v, ok := h.waiting.Dequeue(); ok {
+			wt := v.(*waiter)
+			wt.granted = true
+			wt.ready <- struct{}{}
+			return
+		}
+	}
+	h.inFlight--
+}
+
+// InFlight returns the number of requests being served.
+func (h *Handler) InFlight() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.inFlight
+}
+
+// Waiting returns the number of queued requests. Requests that stop waiting
+// are removed from the queue, so they aren't counted.
+func (h *Handler) Waiting() int {
+	if h.waiting == nil {
+		return 0
+	}
+	return h.waiting.Len()
+}
@@ -0,0 +1,152 @@
+package httpqueue
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blocker is a handler that blocks until it is released.
+type blocker struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlocker() *blocker {
+	return &blocker{started: make(chan struct{}, 10), release: make(chan struct{})}
+}
+
+func (b *blocker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.started <- struct{}{}
+	<-b.release
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func serve(h http.Handler) chan *httptest.ResponseRecorder {
+	done := make(chan *httptest.ResponseRecorder, 1)
+	go func() {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		done <- rec
+	}()
+	return done
+}
+
+func TestHandler(t *testing.T) {
+	b := newBlocker()
+	h := New(b, Config{Concurrency: 1, QueueSize: 1, RetryAfter: 1500 * time.Millisecond})
+	first := serve(h)
+	<-b.started
+	second := serve(h)
+	for h.Waiting() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	// the queue is full.
+	rec := <-serve(h)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected %d, got %d", http.StatusTooManyRequests, rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("expected Retry-After to be 2, got %q", got)
+	}
+	close(b.release)
+	for i, done := range []chan *httptest.ResponseRecorder{first, second} {
+		if rec := <-done; rec.Code != http.StatusNoContent {
+			t.Errorf("%d: expected %d, got %d", i, http.StatusNoContent, rec.Code)
+		}
+	}
+	if h.InFlight() != 0 {
+		t.Errorf("expected no requests in flight, got %d", h.InFlight())
+	}
+}
+
+func TestHandlerTimeout(t *testing.T) {
+	b := newBlocker()
+	h := New(b, Config{QueueSize: 4, Timeout: 10 * time.Millisecond})
+	first := serve(h)
+	<-b.started
+	rec := <-serve(h)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected %d, got %d", http.StatusServiceUnavailable, rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("expected Retry-After to be 1, got %q", got)
+	}
+	// the abandoned request must not be given the slot.
+	third := serve(h)
+	close(b.release)
+	<-first
+	if rec := <-third; rec.Code != http.StatusNoContent {
+		t.Errorf("expected %d, got %d", http.StatusNoContent, rec.Code)
+	}
+	if h.InFlight() != 0 || h.Waiting() != 0 {
+		t.Errorf("expected nothing in flight or waiting, got %d %d", h.InFlight(), h.Waiting())
+	}
+}
+
+func TestHandlerAbandoned(t *testing.T) {
+	const n = 20
+	b := newBlocker()
+	h := New(b, Config{QueueSize: 4, Timeout: 10 * time.Millisecond})
+	first := serve(h)
+	<-b.started
+	// many clients time out while the only slot is busy.
+	for i := 0; i < n; i++ {
+		if rec := <-serve(h); rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("%d: expected %d, got %d", i, http.StatusServiceUnavailable, rec.Code)
+		}
+	}
+	if h.Waiting() != 0 {
+		t.Errorf("expected the timed out requests not to be waiting, got %d", h.Waiting())
+	}
+	// a fresh request must still be queued, and served.
+	h.cfg.Timeout = 0
+	fresh := serve(h)
+	for h.Waiting() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	close(b.release)
+	<-first
+	if rec := <-fresh; rec.Code != http.StatusNoContent {
+		t.Errorf("expected %d, got %d", http.StatusNoContent, rec.Code)
+	}
+}
+
+func TestHandlerConcurrency(t *testing.T) {
+	const max, n = 3, 30
+	var (
+		mu        sync.Mutex
+		cur, peak int
+	)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		cur++
+		if cur > peak {
+			peak = cur
+		}
+		mu.Unlock()
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		cur--
+		mu.Unlock()
+	})
+	h := Middleware(Config{Concurrency: max, QueueSize: n})(next)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+			if rec.Code != http.StatusOK {
+				t.Errorf("expected %d, got %d", http.StatusOK, rec.Code)
+			}
+		}()
+	}
+	wg.Wait()
+	if peak > max {
+		t.Errorf("expected at most %d concurrent requests, got %d", max, peak)
+	}
+}
@@ -0,0 +1,162 @@
+package loadgen
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Format is the output format of an Exporter.
+type Format int
+
+// The supported export formats.
+const (
+	CSV   Format = iota // comma separated values with a header row.
+	JSONL               // JSON Lines: one JSON object per line.
+)
+
+// Sampler is implemented by queues whose length and capacity can be sampled.
+type Sampler interface {
+	Len() int
+	Cap() int
+}
+
+// Sample is a point-in-time reading of a queue's length and capacity.
+type Sample struct {
+	Time time.Time `json:"time"`
+	Len  int       `json:"len"`
+	Cap  int       `json:"cap"`
+}
+
+func (s Sample) columns() []string {
+	return []string{"time", "len", "cap"}
+}
+
+func (s Sample) values() []string {
+	return []string{s.Time.Format(time.RFC3339Nano), strconv.Itoa(s.Len), strconv.Itoa(s.Cap)}
+}
+
+// resultJSON is how a Result is written as JSON; durations are in
+// nanoseconds.
+type resultJSON struct {
+	Offered    int     `json:"offered"`
+	Enqueued   int     `json:"enqueued"`
+	Dropped    int     `json:"dropped"`
+	Dequeued   int     `json:"dequeued"`
+	Elapsed    int64   `json:"elapsed_ns"`
+	Throughput float64 `json:"throughput"`
+	DropRate   float64 `json:"drop_rate"`
+	P50        int64   `json:"p50_ns"`
+	P90        int64   `json:"p90_ns"`
+	P99        int64   `json:"p99_ns"`
+	Max        int64   `json:"max_ns"`
+}
+
+func (r Result) columns() []string {
+	return []string{"offered", "enqueued", "dropped", "dequeued", "elapsed_ns", "throughput", "drop_rate", "p50_ns", "p90_ns", "p99_ns", "max_ns"}
+}
+
+func (r Result) values() []string {
+	return []string{
+		strconv.Itoa(r.Offered), strconv.Itoa(r.Enqueued), strconv.Itoa(r.Dropped), strconv.Itoa(r.Dequeued),
+		strconv.FormatInt(int64(r.Elapsed), 10), strconv.FormatFloat(r.Throughput, 'f', -1, 64),
+		strconv.FormatFloat(r.DropRate, 'f', -1, 64), strconv.FormatInt(int64(r.P50), 10),
+		strconv.FormatInt(int64(r.P90), 10), strconv.FormatInt(int64(r.P99), 10), strconv.FormatInt(int64(r.Max), 10),
+	}
+}
+
+func (r Result) json() interface{} {
+	return resultJSON{
+		Offered: r.Offered, Enqueued: r.Enqueued, Dropped: r.Dropped, Dequeued: r.Dequeued,
+		Elapsed: int64(r.Elapsed), Throughput: r.Throughput, DropRate: r.DropRate,
+		P50: int64(r.P50), P90: int64(r.P90), P99: int64(r.P99), Max: int64(r.Max),
+	}
+}
+
+// record is something an Exporter can write.
+type record interface {
+	columns() []string
+	values() []string
+}
+
+// Exporter writes Samples or Results to an io.Writer as CSV or JSON Lines. An
+// Exporter writes one kind of record: for CSV, the header is written with the
+// first record and writing a different kind of record after that is an
+// error. An Exporter is safe for concurrent use.
+type Exporter struct {
+	mu     sync.Mutex
+	format Format
+	w      io.Writer
+	csv    *csv.Writer
+	header []string
+}
+
+// NewExporter returns an Exporter that writes to w in the received format.
+func NewExporter(w io.Writer, format Format) *Exporter {
+	e := &Exporter{format: format, w: w}
+	if format == CSV {
+		e.csv = csv.NewWriter(w)
+	}
+	return e
+}
+
+// WriteSample writes a Sample.
+func (e *Exporter) WriteSample(s Sample) error {
+	return e.write(s, s)
+}
+
+// WriteResult writes a Result.
+func (e *Exporter) WriteResult(r Result) error {
+	return e.write(r, r.json())
+}
+
+func (e *Exporter) write(r record, v interface{}) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	cols := r.columns()
+	if e.header == nil {
+		e.header = cols
+		if e.format == CSV {
+			if err := e.csv.Write(cols); err != nil {
+				return err
+			}
+		}
+	} else if e.header[0] != cols[0] {
+		return fmt.Errorf("export: cannot write a %q record after a %q record", cols[0], e.header[0])
+	}
+	if e.format == CSV {
+		if err := e.csv.Write(r.values()); err != nil {
+			return err
+		}
+		e.csv.Flush()
+		return e.csv.Error()
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(append(b, '\n'))
+	return err
+}
+
+// Run samples q every interval, writing each sample, until ctx is done or a
+// write fails. The error from the failed write, or ctx's error, is returned.
+func (e *Exporter) Run(ctx context.Context, q Sampler, interval time.Duration) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-t.C:
+			if err := e.WriteSample(Sample{Time: now, Len: q.Len(), Cap: q.Cap()}); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package loadgen
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mohae/firkin/queue"
+)
+
+func TestExporter(t *testing.T) {
+	tm := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+	r := Result{Offered: 10, Enqueued: 9, Dropped: 1, Dequeued: 9, Elapsed: time.Second, Throughput: 9, DropRate: 0.1, P50: 1, P90: 2, P99: 3, Max: 4}
+	tests := []struct {
+		format   Format
+		sample   bool
+		expected string
+	}{
+		{CSV, true, "time,len,cap\n2016-01-02T03:04:05Z,1,4\n2016-01-02T03:04:05Z,1,4\n"},
+		{JSONL, true, "{\"time\":\"2016-01-02T03:04:05Z\",\"len\":1,\"cap\":4}\n{\"time\":\"2016-01-02T03:04:05Z\",\"len\":1,\"cap\":4}\n"},
+		{CSV, false, "offered,enqueued,dropped,dequeued,elapsed_ns,throughput,drop_rate,p50_ns,p90_ns,p99_ns,max_ns\n10,9,1,9,1000000000,9,0.1,1,2,3,4\n10,9,1,9,1000000000,9,0.1,1,2,3,4\n"},
+		{JSONL, false, "{\"offered\":10,\"enqueued\":9,\"dropped\":1,\"dequeued\":9,\"elapsed_ns\":1000000000,\"throughput\":9,\"drop_rate\":0.1,\"p50_ns\":1,\"p90_ns\":2,\"p99_ns\":3,\"max_ns\":4}\n{\"offered\":10,\"enqueued\":9,\"dropped\":1,\"dequeued\":9,\"elapsed_ns\":1000000000,\"throughput\":9,\"drop_rate\":0.1,\"p50_ns\":1,\"p90_ns\":2,\"p99_ns\":3,\"max_ns\":4}\n"},
+	}
+	for i, test := range tests {
+		var buf bytes.Buffer
+		e := NewExporter(&buf, test.format)
+		for j := 0; j < 2; j++ {
+			var err error
+			if test.sample {
+				err = e.WriteSample(Sample{Time: tm, Len: 1, Cap: 4})
+			} else {
+				err = e.WriteResult(r)
+			}
+			if err != nil {
+				t.Errorf("%d: unexpected error: %s", i, err)
+			}
+		}
+		if buf.String() != test.expected {
+			t.Errorf("%d: expected %q, got %q", i, test.expected, buf.String())
+		}
+	}
+}
+
+func TestExporterMixedRecords(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewExporter(&buf, CSV)
+	_ = e.WriteSample(Sample{})
+	if err := e.WriteResult(Result{}); err == nil {
+		t.Error("expected an error writing a result after a sample, got nil")
+	}
+}
+
+func TestExporterRun(t *testing.T) {
+	var buf bytes.Buffer
+	q := queue.NewCircular(4)
+	_ = q.Enqueue(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 25*time.Millisecond)
+	defer cancel()
+	err := NewExporter(&buf, CSV).Run(ctx, q, 5*time.Millisecond)
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) < 2 {
+		t.Fatalf("expected a header and at least one sample, got %q", buf.String())
+	}
+	if !strings.HasSuffix(lines[1], ",1,4") {
+		t.Errorf("expected the sample to end with \",1,4\", got %q", lines[1])
+	}
+}
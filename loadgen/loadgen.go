@@ -0,0 +1,204 @@
+// Package loadgen simulates producer/consumer workloads against a queue and
+// reports throughput, the time items spent in the queue, and drop rates. It
+// is meant for capacity planning and for detecting performance regressions.
+package loadgen
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Queue is the interface of the queues that load can be generated against.
+type Queue interface {
+	Enqueue(interface{}) error
+	Dequeue() (interface{}, bool)
+}
+
+// Arrival returns how long a producer waits after offering its i'th item
+// before offering the next one.
+type Arrival func(i int, r *rand.Rand) time.Duration
+
+// Constant returns an Arrival that offers rate items per second, evenly
+// spaced.
+func Constant(rate float64) Arrival {
+	d := time.Duration(float64(time.Second) / rate)
+	return func(int, *rand.Rand) time.Duration {
+		return d
+	}
+}
+
+// Poisson returns an Arrival that offers, on average, rate items per second
+// with exponentially distributed gaps between them.
+func Poisson(rate float64) Arrival {
+	return func(_ int, r *rand.Rand) time.Duration {
+		return time.Duration(r.ExpFloat64() / rate * float64(time.Second))
+	}
+}
+
+// Burst returns an Arrival that offers size items back to back and then waits
+// for every before the next burst.
+func Burst(size int, every time.Duration) Arrival {
+	return func(i int, _ *rand.Rand) time.Duration {
+		if size < 1 || i%size == size-1 {
+			return every
+		}
+		return 0
+	}
+}
+
+// Service returns how long a consumer spends processing an item.
+type Service func(r *rand.Rand) time.Duration
+
+// Fixed returns a Service that takes d to process every item.
+func Fixed(d time.Duration) Service {
+	return func(*rand.Rand) time.Duration {
+		return d
+	}
+}
+
+// Uniform returns a Service whose processing times are uniformly distributed
+// between min and max.
+func Uniform(min, max time.Duration) Service {
+	return func(r *rand.Rand) time.Duration {
+		if max <= min {
+			return min
+		}
+		return min + time.Duration(r.Int63n(int64(max-min)))
+	}
+}
+
+// Config describes a workload.
+type Config struct {
+	Producers int           // the number of producing goroutines; defaults to 1.
+	Consumers int           // the number of consuming goroutines; defaults to 1.
+	Duration  time.Duration // how long the producers produce for.
+	Arrival   Arrival       // each producer's arrival pattern; defaults to as fast as possible.
+	Service   Service       // each consumer's processing time; defaults to none.
+	Seed      int64         // the seed for the random sources.
+}
+
+// Result is the outcome of running a workload.
+type Result struct {
+	Offered    int           // the number of items the producers tried to enqueue.
+	Enqueued   int           // the number of items enqueued.
+	Dropped    int           // the number of items whose enqueue failed.
+	Dequeued   int           // the number of items dequeued.
+	Elapsed    time.Duration // how long the workload took, including draining the queue.
+	Throughput float64       // dequeued items per second.
+	DropRate   float64       // Dropped / Offered.
+	// The time items spent in the queue, from enqueue to dequeue.
+	P50, P90, P99, Max time.Duration
+}
+
+// stamp is the item enqueued by the producers.
+type stamp struct {
+	enqueued time.Time
+}
+
+// Run runs the workload described by cfg against q and returns the results.
+// The producers run for cfg.Duration; the consumers then drain whatever is
+// left in the queue.
+func Run(q Queue, cfg Config) Result {
+	if cfg.Producers < 1 {
+		cfg.Producers = 1
+	}
+	if cfg.Consumers < 1 {
+		cfg.Consumers = 1
+	}
+	var (
+		mu       sync.Mutex
+		res      Result
+		waits    []time.Duration
+		produced sync.WaitGroup
+		consumed sync.WaitGroup
+		done     = make(chan struct{})
+	)
+	start := time.Now()
+	end := start.Add(cfg.Duration)
+	for p := 0; p < cfg.Producers; p++ {
+		produced.Add(1)
+		go func(r *rand.Rand) {
+			defer produced.Done()
+			var offered, dropped int
+			next := time.Now()
+			for i := 0; time.Now().Before(end); i++ {
+				offered++
+				if q.Enqueue(stamp{time.Now()}) != nil {
+					dropped++
+				}
+				if cfg.Arrival == nil {
+					continue
+				}
+				next = next.Add(cfg.Arrival(i, r))
+				if d := time.Until(next); d > 0 {
+					time.Sleep(d)
+				}
+			}
+			mu.Lock()
+			res.Offered += offered
+			res.Dropped += dropped
+			mu.Unlock()
+		}(rand.New(rand.NewSource(cfg.Seed + int64(p))))
+	}
+	for c := 0; c < cfg.Consumers; c++ {
+		consumed.Add(1)
+		go func(r *rand.Rand) {
+			defer consumed.Done()
+			var w []time.Duration
+			for {
+				v, ok := q.Dequeue()
+				if !ok {
+					select {
+					case <-done:
+						// the producers are done; make sure nothing is left.
+						if v, ok = q.Dequeue(); !ok {
+							mu.Lock()
+							waits = append(waits, w...)
+							mu.Unlock()
+							return
+						}
+					default:
+						time.Sleep(50 * time.Microsecond)
+						continue
+					}
+				}
+				w = append(w, time.Since(v.(stamp).enqueued))
+				if cfg.Service != nil {
+					time.Sleep(cfg.Service(r))
+				}
+			}
+		}(rand.New(rand.NewSource(cfg.Seed + int64(cfg.Producers+c))))
+	}
+	produced.Wait()
+	close(done)
+	consumed.Wait()
+
+	res.Elapsed = time.Since(start)
+	res.Enqueued = res.Offered - res.Dropped
+	res.Dequeued = len(waits)
+	if res.Elapsed > 0 {
+		res.Throughput = float64(res.Dequeued) / res.Elapsed.Seconds()
+	}
+	if res.Offered > 0 {
+		res.DropRate = float64(res.Dropped) / float64(res.Offered)
+	}
+	if len(waits) > 0 {
+		sort.Slice(waits, func(i, j int) bool { return waits[i] < waits[j] })
+		res.P50 = percentile(waits, 50)
+		res.P90 = percentile(waits, 90)
+		res.P99 = percentile(waits, 99)
+		res.Max = waits[len(waits)-1]
+	}
+	return res
+}
+
+// percentile returns the p'th percentile of the sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	i := (len(sorted)*p + 99) / 100
+	if i > 0 {
+		i--
+	}
+	return sorted[i]
+}
@@ -0,0 +1,69 @@
+package loadgen
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/mohae/firkin/queue"
+)
+
+func TestArrival(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	if d := Constant(100)(0, r); d != 10*time.Millisecond {
+		t.Errorf("constant: expected 10ms, got %s", d)
+	}
+	b := Burst(3, time.Second)
+	for i, expected := range []time.Duration{0, 0, time.Second, 0, 0, time.Second} {
+		if d := b(i, r); d != expected {
+			t.Errorf("burst %d: expected %s, got %s", i, expected, d)
+		}
+	}
+	var total time.Duration
+	p := Poisson(1000)
+	for i := 0; i < 10000; i++ {
+		total += p(i, r)
+	}
+	if mean := total / 10000; mean < 900*time.Microsecond || mean > 1100*time.Microsecond {
+		t.Errorf("poisson: expected the mean gap to be about 1ms, got %s", mean)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	d := make([]time.Duration, 100)
+	for i := range d {
+		d[i] = time.Duration(i + 1)
+	}
+	for _, p := range []int{50, 90, 99, 100} {
+		if got := percentile(d, p); got != time.Duration(p) {
+			t.Errorf("expected the %dth percentile to be %d, got %d", p, p, got)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		q       Queue
+		cfg     Config
+		dropped bool
+	}{
+		{"unbounded", queue.NewQueue(16), Config{Producers: 2, Consumers: 2, Duration: 20 * time.Millisecond, Arrival: Poisson(10000)}, false},
+		{"overloaded", queue.NewCircular(4), Config{Producers: 2, Consumers: 1, Duration: 20 * time.Millisecond, Service: Fixed(time.Millisecond)}, true},
+	}
+	for _, test := range tests {
+		r := Run(test.q, test.cfg)
+		if r.Offered == 0 {
+			t.Errorf("%s: expected items to be offered", test.name)
+		}
+		if r.Enqueued != r.Dequeued {
+			t.Errorf("%s: expected all %d enqueued items to be dequeued, got %d", test.name, r.Enqueued, r.Dequeued)
+		}
+		if (r.Dropped > 0) != test.dropped {
+			t.Errorf("%s: expected dropped to be %t, got %d drops", test.name, test.dropped, r.Dropped)
+		}
+		if r.P50 > r.P90 || r.P90 > r.P99 || r.P99 > r.Max {
+			t.Errorf("%s: expected ordered percentiles, got %s %s %s %s", test.name, r.P50, r.P90, r.P99, r.Max)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+// Package qio exports and imports the contents of queues, so that they can be
+// moved between environments and inspected with standard tools. Items are
+// encoded by a pluggable Codec and framed as either JSON Lines or as length
+// prefixed records.
+package qio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Format is how encoded items are framed.
+type Format int
+
+// The supported formats.
+const (
+	// JSONL is JSON Lines: one item per line. The codec must produce JSON,
+	// or at least, output without newlines.
+	JSONL Format = iota
+	// Delimited prefixes each item with its length as a uvarint. This is
+	// the framing used for streams of protobuf messages, e.g. Java's
+	// writeDelimitedTo, so a Codec that marshals protobuf messages produces
+	// a standard delimited protobuf stream.
+	Delimited
+)
+
+// MaxItemSize is the largest encoded item that a Reader will accept.
+const MaxItemSize = 64 << 20
+
+// Codec encodes and decodes items.
+type Codec interface {
+	Marshal(item interface{}) ([]byte, error)
+	Unmarshal(data []byte) (interface{}, error)
+}
+
+// JSON is a Codec that encodes items as JSON. Items are decoded into the
+// value returned by New, which should be a pointer; without a New, items are
+// decoded as they would be by json.Unmarshal into an interface{}.
+type JSON struct {
+	New func() interface{}
+}
+
+// Marshal encodes item as JSON.
+func (j JSON) Marshal(item interface{}) ([]byte, error) {
+	return json.Marshal(item)
+}
+
+// Unmarshal decodes a JSON encoded item.
+func (j JSON) Unmarshal(data []byte) (interface{}, error) {
+	if j.New == nil {
+		var v interface{}
+		err := json.Unmarshal(data, &v)
+		return v, err
+	}
+	v := j.New()
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Writer writes encoded items to an io.Writer.
+type Writer struct {
+	w     *bufio.Writer
+	f     Format
+	codec Codec
+	buf   [binary.MaxVarintLen64]byte
+}
+
+// NewWriter returns a Writer that writes items to w, in the format f, using
+// the codec c.
+func NewWriter(w io.Writer, f Format, c Codec) *Writer {
+	return &Writer{w: bufio.NewWriter(w), f: f, codec: c}
+}
+
+// Write encodes and writes an item. Writes are buffered; Flush must be called
+// once all of the items have been written.
+func (w *Writer) Write(item interface{}) error {
+	b, err := w.codec.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("qio: encode %v: %w", item, err)
+	}
+	switch w.f {
+	case JSONL:
+		if bytes.IndexByte(b, '\n') >= 0 {
+			return fmt.Errorf("qio: encode %v: JSONL item contains a newline", item)
+		}
+		if _, err := w.w.Write(b); err != nil {
+			return err
+		}
+		return w.w.WriteByte('\n')
+	case Delimited:
+		n := binary.PutUvarint(w.buf[:], uint64(len(b)))
+		if _, err := w.w.Write(w.buf[:n]); err != nil {
+			return err
+		}
+		_, err := w.w.Write(b)
+		return err
+	}
+	return fmt.Errorf("qio: unknown format %d", w.f)
+}
+
+// Flush writes any buffered data to the underlying io.Writer.
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+// Reader reads encoded items from an io.Reader.
+type Reader struct {
+	r     *bufio.Reader
+	f     Format
+	codec Codec
+}
+
+// NewReader returns a Reader that reads items from r, in the format f, using
+// the codec c.
+func NewReader(r io.Reader, f Format, c Codec) *Reader {
+	return &Reader{r: bufio.NewReader(r), f: f, codec: c}
+}
+
+// Read reads and decodes the next item. At the end of the input, io.EOF is
+// returned; if the input ends in the middle of an item, io.ErrUnexpectedEOF
+// is returned.
+func (r *Reader) Read() (interface{}, error) {
+	var b []byte
+	switch r.f {
+	case JSONL:
+		for len(b) == 0 {
+			line, err := r.r.ReadBytes('\n')
+			if err == io.EOF && len(line) > 0 {
+				err = nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			b = bytes.TrimSpace(line)
+		}
+	case Delimited:
+		n, err := binary.ReadUvarint(r.r)
+		if err != nil {
+			if err != io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		if n > MaxItemSize {
+			return nil, fmt.Errorf("qio: item of %d bytes exceeds the maximum of %d", n, MaxItemSize)
+		}
+		b = make([]byte, n)
+		if _, err := io.ReadFull(r.r, b); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("qio: unknown format %d", r.f)
+	}
+	v, err := r.codec.Unmarshal(b)
+	if err != nil {
+		return nil, fmt.Errorf("qio: decode: %w", err)
+	}
+	return v, nil
+}
+
+// Snapshotter is implemented by queues whose contents can be copied.
+type Snapshotter interface {
+	Snapshot() []interface{}
+}
+
+// Enqueuer is implemented by queues that items can be enqueued on.
+type Enqueuer interface {
+	Enqueue(item interface{}) error
+}
+
+// Export writes a snapshot of q's contents, in FIFO order, to w. The queue is
+// not modified. The number of items written is returned.
+func Export(w io.Writer, q Snapshotter, f Format, c Codec) (int, error) {
+	qw := NewWriter(w, f, c)
+	var n int
+	for _, item := range q.Snapshot() {
+		if err := qw.Write(item); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, qw.Flush()
+}
+
+// Import reads items from r and enqueues them on q, in order, until the end of
+// the input. The number of items enqueued is returned; if an enqueue fails,
+// e.g. because the queue is full, the import stops and the error is returned.
+func Import(r io.Reader, q Enqueuer, f Format, c Codec) (int, error) {
+	qr := NewReader(r, f, c)
+	var n int
+	for {
+		v, err := qr.Read()
+		if errors.Is(err, io.EOF) {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		if err := q.Enqueue(v); err != nil {
+			return n, err
+		}
+		n++
+	}
+}
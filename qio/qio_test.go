@@ -0,0 +1,115 @@
+package qio
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/mohae/firkin/queue"
+)
+
+type job struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// str is a Codec for strings that doesn't produce JSON.
+type str struct{}
+
+func (str) Marshal(item interface{}) ([]byte, error) {
+	return []byte(item.(string)), nil
+}
+
+func (str) Unmarshal(data []byte) (interface{}, error) {
+	return string(data), nil
+}
+
+func TestExportImport(t *testing.T) {
+	newJob := func() interface{} { return &job{} }
+	tests := []struct {
+		name     string
+		format   Format
+		codec    Codec
+		items    []interface{}
+		expected string // the export; only checked if not empty.
+	}{
+		{"jsonl", JSONL, JSON{New: newJob}, []interface{}{&job{1, "a"}, &job{2, "b"}}, "{\"id\":1,\"name\":\"a\"}\n{\"id\":2,\"name\":\"b\"}\n"},
+		{"jsonl untyped", JSONL, JSON{}, []interface{}{"a", 1.5, map[string]interface{}{"k": "v"}}, "\"a\"\n1.5\n{\"k\":\"v\"}\n"},
+		{"delimited", Delimited, str{}, []interface{}{"ab", "", "c\nd"}, "\x02ab\x00\x03c\nd"},
+		{"delimited json", Delimited, JSON{New: newJob}, []interface{}{&job{3, "c"}}, ""},
+		{"empty", JSONL, JSON{}, nil, ""},
+	}
+	for _, test := range tests {
+		q := queue.NewCircular(4)
+		for _, item := range test.items {
+			_ = q.Enqueue(item)
+		}
+		var buf bytes.Buffer
+		n, err := Export(&buf, q, test.format, test.codec)
+		if err != nil {
+			t.Errorf("%s: unexpected export error: %s", test.name, err)
+			continue
+		}
+		if n != len(test.items) {
+			t.Errorf("%s: expected %d items to be exported, got %d", test.name, len(test.items), n)
+		}
+		if q.Len() != len(test.items) {
+			t.Errorf("%s: expected export to not modify the queue", test.name)
+		}
+		if test.expected != "" && buf.String() != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expected, buf.String())
+		}
+		dst := queue.NewQueue(4)
+		n, err = Import(&buf, dst, test.format, test.codec)
+		if err != nil {
+			t.Errorf("%s: unexpected import error: %s", test.name, err)
+			continue
+		}
+		if n != len(test.items) {
+			t.Errorf("%s: expected %d items to be imported, got %d", test.name, len(test.items), n)
+		}
+		if got := dst.Snapshot(); !reflect.DeepEqual(got, append([]interface{}(nil), test.items...)) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.items, got)
+		}
+	}
+}
+
+func TestWriterJSONLNewline(t *testing.T) {
+	w := NewWriter(io.Discard, JSONL, str{})
+	if err := w.Write("a\nb"); err == nil {
+		t.Error("expected an item with a newline to be rejected")
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		format Format
+	}{
+		{"truncated item", "\x05ab", Delimited},
+		{"truncated length", "\x80", Delimited},
+		{"bad json", "{\n", JSONL},
+	}
+	for _, test := range tests {
+		r := NewReader(bytes.NewBufferString(test.input), test.format, JSON{})
+		if _, err := r.Read(); err == nil || err == io.EOF {
+			t.Errorf("%s: expected an error, got %v", test.name, err)
+		}
+	}
+}
+
+func TestImportFull(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, JSONL, JSON{})
+	for i := 0; i < 3; i++ {
+		_ = w.Write(strconv.Itoa(i))
+	}
+	_ = w.Flush()
+	n, err := Import(&buf, queue.NewCircular(2), JSONL, JSON{})
+	if err == nil || n != 2 {
+		t.Errorf("expected import to stop with a full queue error after 2 items, got %d %v", n, err)
+	}
+}
@@ -0,0 +1,173 @@
+package qio
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// Registry is a Codec for queues holding items of more than one type. Each
+// type is registered with its own Codec under a name; an item is encoded by
+// its type's codec and is tagged with the type's name so that it is decoded by
+// the same codec, as the same type.
+//
+// Encoded items are JSON objects: the type's name, the version of its codec,
+// and the item's encoding. If the item's codec produces JSON, it is embedded
+// as is, so the output can be read by standard JSON tools; otherwise it is
+// base64 encoded.
+//
+// A type can have more than one version of its codec registered. Items are
+// always encoded with the latest version, but are decoded with the version
+// that encoded them, so that, during a rolling upgrade that changes an item
+// type, items encoded before the upgrade can still be decoded. An item
+// encoded by a version that isn't registered is an error, a *VersionError,
+// rather than being decoded by the wrong codec.
+type Registry struct {
+	mu     sync.RWMutex
+	codecs map[string]map[int]Codec // by name, then version.
+	latest map[string]int           // the latest version of each name.
+	names  map[reflect.Type]string
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		codecs: make(map[string]map[int]Codec),
+		latest: make(map[string]int),
+		names:  make(map[reflect.Type]string),
+	}
+}
+
+// VersionError is returned when an item was encoded by a version of its
+// type's codec that isn't registered.
+type VersionError struct {
+	Type     string // the item's type name.
+	Version  int    // the version that encoded the item.
+	Versions []int  // the registered versions, in ascending order.
+}
+
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("%s: item encoded by codec version %d; registered versions are %v", e.Type, e.Version, e.Versions)
+}
+
+// TypeName returns the name an item's type is registered under by default:
+// its import path qualified name, e.g. "*github.com/you/jobs.Job".
+func TypeName(item interface{}) string {
+	t := reflect.TypeOf(item)
+	if t == nil {
+		return "nil"
+	}
+	var ptr string
+	for t.Kind() == reflect.Ptr && t.Name() == "" {
+		ptr += "*"
+		t = t.Elem()
+	}
+	if t.PkgPath() == "" {
+		return ptr + t.String()
+	}
+	return ptr + t.PkgPath() + "." + t.Name()
+}
+
+// Register registers the type of sample, under its TypeName, with the codec
+// c. The name is returned.
+func (r *Registry) Register(sample interface{}, c Codec) string {
+	name := TypeName(sample)
+	r.RegisterName(name, sample, c)
+	return name
+}
+
+// RegisterName registers the type of sample under name with the codec c, as
+// version 0. A name should be used instead of the TypeName when the type may
+// be renamed or moved, e.g. for data that is persisted.
+func (r *Registry) RegisterName(name string, sample interface{}, c Codec) {
+	r.RegisterVersion(name, 0, sample, c)
+}
+
+// RegisterVersion registers the type of sample under name with version
+// version of its codec, c. Registering a version that is already registered
+// replaces the earlier registration. Items of sample's type are encoded with
+// the latest registered version of the name, so the samples of older versions
+// should only be decoded, not enqueued.
+func (r *Registry) RegisterVersion(name string, version int, sample interface{}, c Codec) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	versions, ok := r.codecs[name]
+	if !ok {
+		versions = make(map[int]Codec)
+		r.codecs[name] = versions
+		r.latest[name] = version
+	}
+	versions[version] = c
+	if version > r.latest[name] {
+		r.latest[name] = version
+	}
+	r.names[reflect.TypeOf(sample)] = name
+}
+
+// tagged is an encoded item. Exactly one of Data and Bin is set.
+type tagged struct {
+	Type    string          `json:"type"`
+	Version int             `json:"v,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"` // the encoding, if it is JSON.
+	Bin     []byte          `json:"bin,omitempty"`  // the encoding, if it isn't.
+}
+
+// Marshal encodes item with the latest version of the codec registered for
+// its type. An error is returned if the type isn't registered.
+func (r *Registry) Marshal(item interface{}) ([]byte, error) {
+	r.mu.RLock()
+	name, ok := r.names[reflect.TypeOf(item)]
+	version := r.latest[name]
+	c := r.codecs[name][version]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("type %s is not registered", TypeName(item))
+	}
+	b, err := c.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	t := tagged{Type: name, Version: version}
+	if json.Valid(b) {
+		t.Data = b
+	} else {
+		t.Bin = b
+	}
+	return json.Marshal(t)
+}
+
+// Unmarshal decodes an item encoded by Marshal with the version of the codec
+// that encoded it. An error is returned if the item's type name isn't
+// registered; a *VersionError is returned if the name is registered but the
+// version isn't.
+func (r *Registry) Unmarshal(data []byte) (interface{}, error) {
+	var t tagged
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	versions, ok := r.codecs[t.Type]
+	c, vok := versions[t.Version]
+	var err error
+	if ok && !vok {
+		verr := &VersionError{Type: t.Type, Version: t.Version}
+		for v := range versions {
+			verr.Versions = append(verr.Versions, v)
+		}
+		sort.Ints(verr.Versions)
+		err = verr
+	}
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("type %q is not registered", t.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if t.Data != nil {
+		return c.Unmarshal(t.Data)
+	}
+	return c.Unmarshal(t.Bin)
+}
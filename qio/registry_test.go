@@ -0,0 +1,119 @@
+package qio
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mohae/firkin/queue"
+)
+
+type alert struct {
+	Level string `json:"level"`
+}
+
+func TestTypeName(t *testing.T) {
+	tests := []struct {
+		item     interface{}
+		expected string
+	}{
+		{&job{}, "*github.com/mohae/firkin/qio.job"},
+		{alert{}, "github.com/mohae/firkin/qio.alert"},
+		{"", "string"},
+		{[]int{}, "[]int"},
+		{nil, "nil"},
+	}
+	for _, test := range tests {
+		if got := TypeName(test.item); got != test.expected {
+			t.Errorf("%T: expected %s, got %s", test.item, test.expected, got)
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	r.Register(&job{}, JSON{New: func() interface{} { return &job{} }})
+	r.RegisterName("alert", alert{}, JSON{New: func() interface{} { return &alert{} }})
+	r.Register("", str{})
+
+	items := []interface{}{&job{1, "a"}, alert{"warn"}, "not json"}
+	q := queue.NewQueue(4)
+	for _, item := range items {
+		_ = q.Enqueue(item)
+	}
+	var buf bytes.Buffer
+	if _, err := Export(&buf, q, JSONL, r); err != nil {
+		t.Fatalf("unexpected export error: %s", err)
+	}
+	expected := `{"type":"*github.com/mohae/firkin/qio.job","data":{"id":1,"name":"a"}}
+{"type":"alert","data":{"level":"warn"}}
+{"type":"string","bin":"bm90IGpzb24="}
+`
+	if buf.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, buf.String())
+	}
+	dst := queue.NewQueue(4)
+	if _, err := Import(&buf, dst, JSONL, r); err != nil {
+		t.Fatalf("unexpected import error: %s", err)
+	}
+	// the alert codec decodes to a pointer.
+	items[1] = &alert{"warn"}
+	if got := dst.Snapshot(); !reflect.DeepEqual(got, items) {
+		t.Errorf("expected %v, got %v", items, got)
+	}
+}
+
+func TestRegistryUnregistered(t *testing.T) {
+	r := NewRegistry()
+	if _, err := r.Marshal(1); err == nil || !strings.Contains(err.Error(), "int is not registered") {
+		t.Errorf("expected a not registered error, got %v", err)
+	}
+	if _, err := r.Unmarshal([]byte(`{"type":"x","data":1}`)); err == nil {
+		t.Error("expected an error decoding an unregistered type")
+	}
+}
+
+// jobV1 is job before the Name field was renamed to Title.
+type jobV1 struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+func TestRegistryVersions(t *testing.T) {
+	old := NewRegistry()
+	old.RegisterName("job", &job{}, JSON{New: func() interface{} { return &job{} }})
+	b, err := old.Marshal(&job{1, "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the new registry can decode both versions, and encodes with the latest.
+	r := NewRegistry()
+	r.RegisterVersion("job", 1, &jobV1{}, JSON{New: func() interface{} { return &jobV1{} }})
+	r.RegisterVersion("job", 0, &job{}, JSON{New: func() interface{} { return &job{} }})
+	v, err := r.Unmarshal(b)
+	if err != nil || !reflect.DeepEqual(v, &job{1, "a"}) {
+		t.Errorf("expected the old item to decode as a job, got %v %v", v, err)
+	}
+	nb, err := r.Marshal(&jobV1{2, "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"type":"job","v":1,"data":{"id":2,"title":"b"}}`; string(nb) != expected {
+		t.Errorf("expected %s, got %s", expected, nb)
+	}
+
+	// the old registry can't decode the new version.
+	_, err = old.Unmarshal(nb)
+	verr, ok := err.(*VersionError)
+	if !ok {
+		t.Fatalf("expected a *VersionError, got %v", err)
+	}
+	if verr.Type != "job" || verr.Version != 1 || !reflect.DeepEqual(verr.Versions, []int{0}) {
+		t.Errorf("unexpected version error: %+v", verr)
+	}
+	if expected := "job: item encoded by codec version 1; registered versions are [0]"; verr.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, verr.Error())
+	}
+}
@@ -0,0 +1,106 @@
+package qio
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+)
+
+// State is the encoded form of a queue: its capacity and its items, in FIFO
+// order. Unlike the queue's internal fields, it doesn't depend on where in the
+// queue's underlying slice the items happen to be.
+type State struct {
+	Cap   int           `json:"cap"`
+	Items []interface{} `json:"items"`
+}
+
+// Stater is implemented by queues whose State can be taken, e.g.
+// queue.Queue and queue.Circular.
+type Stater interface {
+	Snapshotter
+	Cap() int
+}
+
+// Loader is implemented by queues whose contents can be replaced, e.g.
+// queue.Queue and queue.Circular.
+type Loader interface {
+	Cap() int
+	Load(items []interface{}) error
+}
+
+// Capture returns q's State. The capacity and the items are read separately,
+// so q shouldn't be changing while it is captured.
+func Capture(q Stater) State {
+	return State{Cap: q.Cap(), Items: q.Snapshot()}
+}
+
+// Restore replaces the contents of q with s's items. A queue with a fixed
+// capacity, i.e. one with a SetCap method like queue.Circular, has its
+// capacity set to s's; an error is returned, and q isn't changed, if the
+// items don't fit in it. A queue that can be resized, i.e. one with a Resize
+// method like queue.Queue, has its capacity raised to s's if it is less.
+func Restore(q Loader, s State) error {
+	switch r := q.(type) {
+	case interface{ SetCap(int) error }:
+		if s.Cap < 1 || len(s.Items) > s.Cap {
+			return fmt.Errorf("cannot restore %d items to a queue with a cap of %d", len(s.Items), s.Cap)
+		}
+		// the queue's current items may not fit in the new capacity, and
+		// the new items may not fit in the current one; change whichever
+		// makes room first.
+		if len(s.Items) <= q.Cap() {
+			if err := q.Load(s.Items); err != nil {
+				return err
+			}
+			return r.SetCap(s.Cap)
+		}
+		if err := r.SetCap(s.Cap); err != nil {
+			return err
+		}
+	case interface{ Resize(int) int }:
+		if q.Cap() < s.Cap {
+			r.Resize(s.Cap)
+		}
+	}
+	return q.Load(s.Items)
+}
+
+// EncodeJSON encodes q's State as JSON:
+//
+//	{"cap":8,"items":[1,2,3]}
+func EncodeJSON(q Stater) ([]byte, error) {
+	return json.Marshal(Capture(q))
+}
+
+// DecodeJSON replaces the contents of q with the JSON encoded State; see
+// Restore. Items are decoded the way encoding/json decodes into an
+// interface{}, e.g. numbers as float64s and objects as maps; to decode items
+// as their own types, use Import with a Codec.
+func DecodeJSON(data []byte, q Loader) error {
+	var s State
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return Restore(q, s)
+}
+
+// EncodeGob gob encodes q's State. The items' types must be registered with
+// gob.Register.
+func EncodeGob(q Stater) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(Capture(q)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeGob replaces the contents of q with the gob encoded State; see
+// Restore.
+func DecodeGob(data []byte, q Loader) error {
+	var s State
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&s); err != nil {
+		return err
+	}
+	return Restore(q, s)
+}
@@ -0,0 +1,109 @@
+package qio
+
+import (
+	"encoding/gob"
+	"testing"
+
+	"github.com/mohae/firkin/queue"
+)
+
+func TestQueueJSON(t *testing.T) {
+	q := queue.NewQueue(4)
+	for i := 0; i < 3; i++ {
+		q.Enqueue(i)
+	}
+	q.Dequeue()
+	b, err := EncodeJSON(q)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(b) != `{"cap":4,"items":[1,2]}` {
+		t.Errorf("expected the items in FIFO order, got %s", b)
+	}
+	var restored queue.Queue
+	if err := DecodeJSON(b, &restored); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if restored.Cap() != 4 || restored.Len() != 2 {
+		t.Errorf("expected len 2 cap 4, got len %d cap %d", restored.Len(), restored.Cap())
+	}
+	if v, _ := restored.Dequeue(); v != float64(1) {
+		t.Errorf("expected 1, got %v", v)
+	}
+}
+
+func TestCircularJSON(t *testing.T) {
+	c := queue.NewCircular(3)
+	for i := 0; i < 3; i++ {
+		c.Enqueue(i)
+	}
+	c.Dequeue()
+	c.Enqueue(3) // wrapped
+	b, err := EncodeJSON(c)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(b) != `{"cap":3,"items":[1,2,3]}` {
+		t.Errorf("expected the items in FIFO order, got %s", b)
+	}
+	full := queue.NewCircular(1)
+	full.Enqueue("x")
+	tests := []struct {
+		name string
+		c    *queue.Circular
+	}{
+		{"zero", &queue.Circular{}},
+		{"smaller", queue.NewCircular(1)},
+		{"smaller, full", full},
+		{"larger", queue.NewCircular(8)},
+	}
+	for _, test := range tests {
+		if err := DecodeJSON(b, test.c); err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+		if test.c.Cap() != 3 || !test.c.IsFull() {
+			t.Errorf("%s: expected a full queue with a cap of 3, got len %d cap %d", test.name, test.c.Len(), test.c.Cap())
+		}
+		if v, _ := test.c.Dequeue(); v != float64(1) {
+			t.Errorf("%s: expected 1, got %v", test.name, v)
+		}
+	}
+	if err := DecodeJSON([]byte(`{"cap":1,"items":[1,2]}`), queue.NewCircular(4)); err == nil {
+		t.Error("expected an error decoding more items than the cap")
+	}
+}
+
+func TestGob(t *testing.T) {
+	gob.Register(job{})
+	q := queue.NewQueue(2)
+	q.Enqueue(job{ID: 1})
+	q.Enqueue("two")
+	c := queue.NewCircular(2)
+	c.Enqueue(job{ID: 1})
+	c.Enqueue("two")
+	for _, test := range []struct {
+		name     string
+		q        Stater
+		restored interface {
+			Loader
+			Dequeue() (interface{}, bool)
+		}
+	}{
+		{"queue", q, queue.NewQueue(0)},
+		{"circular", c, queue.NewCircular(1)},
+	} {
+		b, err := EncodeGob(test.q)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", test.name, err)
+		}
+		if err := DecodeGob(b, test.restored); err != nil {
+			t.Fatalf("%s: unexpected error: %s", test.name, err)
+		}
+		for _, expected := range []interface{}{job{ID: 1}, "two"} {
+			if v, ok := test.restored.Dequeue(); !ok || v != expected {
+				t.Errorf("%s: expected %v, got %v %t", test.name, expected, v, ok)
+			}
+		}
+	}
+}
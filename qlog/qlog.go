@@ -0,0 +1,67 @@
+// Package qlog logs queue events with log/slog. It is kept out of package
+// queue so that users of the in-memory queues don't import log/slog.
+package qlog
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+
+	"github.com/mohae/firkin/queue"
+)
+
+// eventLevel is the level each kind of event is logged at: the notable
+// events, drops and pressure changes, are warnings; enqueues and dequeues are only logged at the
+// debug level.
+var eventLevel = map[queue.EventKind]slog.Level{
+	queue.EventEnqueue:  slog.LevelDebug,
+	queue.EventDequeue:  slog.LevelDebug,
+	queue.EventDrop:     slog.LevelWarn,
+	queue.EventReset:    slog.LevelInfo,
+	queue.EventResize:   slog.LevelInfo,
+	queue.EventPressure: slog.LevelWarn,
+}
+
+// LogEvents subscribes to b and logs its events to l. Each kind of event is
+// logged at its own level, so the logger's level controls which events are
+// logged: drops and pressure changes are logged at warn, resets and resizes
+// at info, and enqueues and dequeues at debug. The queue's name and labels,
+// if it has them, and the attrs are added to every record. The returned func
+// stops the logging.
+func LogEvents(b *queue.Bus, l *slog.Logger, attrs ...slog.Attr) (unsubscribe func()) {
+	ctx := context.Background()
+	return b.Subscribe(func(e queue.Event) {
+		level, ok := eventLevel[e.Kind]
+		if !ok {
+			level = slog.LevelInfo
+		}
+		if !l.Enabled(ctx, level) {
+			return
+		}
+		a := make([]slog.Attr, 0, len(attrs)+4)
+		if e.Queue != "" {
+			a = append(a, slog.String("queue", e.Queue))
+		}
+		if len(e.Labels) > 0 {
+			keys := make([]string, 0, len(e.Labels))
+			for k := range e.Labels {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			labels := make([]any, 0, len(keys))
+			for _, k := range keys {
+				labels = append(labels, slog.String(k, e.Labels[k]))
+			}
+			a = append(a, slog.Group("labels", labels...))
+		}
+		a = append(a, attrs...)
+		a = append(a, slog.Int("len", e.Len))
+		if e.Item != nil {
+			a = append(a, slog.Any("item", e.Item))
+		}
+		if e.Kind == queue.EventPressure {
+			a = append(a, slog.String("pressure", e.Pressure.String()))
+		}
+		l.LogAttrs(ctx, level, "queue "+e.Kind.String(), a...)
+	})
+}
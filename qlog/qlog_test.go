@@ -0,0 +1,80 @@
+package qlog
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/mohae/firkin/queue"
+)
+
+func TestLogEvents(t *testing.T) {
+	tests := []struct {
+		level    slog.Level
+		expected []string
+	}{
+		{slog.LevelWarn, []string{
+			"level=WARN msg=\"queue pressure\" queue=jobs len=1 pressure=full",
+			"level=WARN msg=\"queue drop\" queue=jobs len=1 item=2",
+			"level=WARN msg=\"queue pressure\" queue=jobs len=0 pressure=ok",
+		}},
+		{slog.LevelDebug, []string{
+			"level=DEBUG msg=\"queue enqueue\" queue=jobs len=1 item=1",
+			"level=WARN msg=\"queue pressure\" queue=jobs len=1 pressure=full",
+			"level=WARN msg=\"queue drop\" queue=jobs len=1 item=2",
+			"level=DEBUG msg=\"queue dequeue\" queue=jobs len=0 item=1",
+			"level=WARN msg=\"queue pressure\" queue=jobs len=0 pressure=ok",
+			"level=INFO msg=\"queue reset\" queue=jobs len=0",
+		}},
+	}
+	for i, test := range tests {
+		var buf bytes.Buffer
+		l := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+			Level: test.level,
+			ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		}))
+		b := queue.NewBus()
+		c := queue.NewCircular(1)
+		c.SetBus(b)
+		_ = c.SetWatermarks(0, 1, nil)
+		unsubscribe := LogEvents(b, l, slog.String("queue", "jobs"))
+		_ = c.Enqueue(1)
+		_ = c.Enqueue(2)
+		c.Dequeue()
+		c.Reset()
+		unsubscribe()
+		_ = c.Enqueue(3)
+		got := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if strings.Join(got, "\n") != strings.Join(test.expected, "\n") {
+			t.Errorf("%d: expected\n%s\ngot\n%s", i, strings.Join(test.expected, "\n"), buf.String())
+		}
+	}
+}
+
+func TestLogEventsName(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	b := queue.NewBus()
+	q := queue.NewQueue(1)
+	q.SetName("jobs", map[string]string{"tier": "db", "region": "east"})
+	q.SetBus(b)
+	defer LogEvents(b, l)()
+	q.Reset()
+	expected := "level=INFO msg=\"queue reset\" queue=jobs labels.region=east labels.tier=db len=0"
+	if got := strings.TrimSpace(buf.String()); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+}
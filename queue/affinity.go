@@ -0,0 +1,212 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Sessioner is implemented by items that belong to a session, e.g. a user's
+// connection, whose items must all be processed by the same consumer.
+type Sessioner interface {
+	Session() string
+}
+
+// Affinity dispatches items to named consumers, its members, so that every
+// item of a session is delivered to the same member for as long as that
+// member is in the Affinity. A session is assigned to the member with the
+// fewest sessions when its first item is enqueued; when a member leaves, its
+// sessions are reassigned and its undelivered items are redelivered, in
+// order, to the sessions' new members. Items that aren't Sessioners are
+// delivered to the members round-robin.
+//
+// Items enqueued while there are no members are held until one joins.
+type Affinity struct {
+	mu       sync.Mutex
+	members  []*Member          // in the order they joined.
+	ids      map[string]*Member // members by id.
+	sessions map[string]*Member // the member each session is assigned to.
+	next     int                // the next member for items without a session.
+	pending  *Queue             // items enqueued while there were no members.
+}
+
+// Member is a consumer of an Affinity; it has its own queue of the items
+// delivered to it.
+type Member struct {
+	a        *Affinity
+	id       string
+	q        *Queue
+	sessions int // the number of sessions assigned; protected by a's lock.
+	t        tracker
+}
+
+// NewAffinity returns an Affinity with no members.
+func NewAffinity() *Affinity {
+	return &Affinity{
+		ids:      make(map[string]*Member),
+		sessions: make(map[string]*Member),
+		pending:  NewQueue(0),
+	}
+}
+
+// Join adds a member with the received id; an error is returned if there is
+// already a member with the id. If items are being held for lack of members,
+// they are delivered.
+func (a *Affinity) Join(id string) (*Member, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.ids[id]; ok {
+		return nil, fmt.Errorf("affinity: member %q already exists", id)
+	}
+	m := &Member{a: a, id: id, q: NewQueue(0)}
+	a.members = append(a.members, m)
+	a.ids[id] = m
+	for _, item := range a.pending.Drain() {
+		a.route(item)
+	}
+	return m, nil
+}
+
+// Enqueue delivers the item to the member its session is assigned to.
+func (a *Affinity) Enqueue(item interface{}) error {
+	a.mu.Lock()
+	a.route(item)
+	a.mu.Unlock()
+	return nil
+}
+
+// route delivers item to its member. The caller must hold the lock.
+func (a *Affinity) route(item interface{}) {
+	if len(a.members) == 0 {
+		a.pending.Enqueue(item)
+		return
+	}
+	s, ok := item.(Sessioner)
+	if !ok {
+		a.next %= len(a.members)
+		a.members[a.next].q.Enqueue(item)
+		a.next++
+		return
+	}
+	key := s.Session()
+	m, ok := a.sessions[key]
+	if !ok {
+		m = a.members[0]
+		for _, mm := range a.members[1:] {
+			if mm.sessions < m.sessions {
+				m = mm
+			}
+		}
+		a.sessions[key] = m
+		m.sessions++
+	}
+	m.q.Enqueue(item)
+}
+
+// Member returns the member that the session is assigned to, if any.
+func (a *Affinity) Member(session string) (*Member, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	m, ok := a.sessions[session]
+	return m, ok
+}
+
+// Forget ends a session: its next item starts a new session, which may be
+// assigned to a different member. Sessions that are never forgotten are
+// remembered for as long as their member is in the Affinity.
+func (a *Affinity) Forget(session string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if m, ok := a.sessions[session]; ok {
+		m.sessions--
+		delete(a.sessions, session)
+	}
+}
+
+// Members returns the ids of the members, in the order they joined.
+func (a *Affinity) Members() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	ids := make([]string, len(a.members))
+	for i, m := range a.members {
+		ids[i] = m.id
+	}
+	return ids
+}
+
+// Stats returns the delivery stats of each member, by id.
+func (a *Affinity) Stats() map[string]ConsumerStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	stats := make(map[string]ConsumerStats, len(a.members))
+	for _, m := range a.members {
+		stats[m.id] = m.Stats()
+	}
+	return stats
+}
+
+// ID returns the member's id.
+func (m *Member) ID() string {
+	return m.id
+}
+
+// Len returns the number of items waiting to be dequeued by the member.
+func (m *Member) Len() int {
+	return m.q.Len()
+}
+
+// Dequeue removes the next item delivered to the member. If there isn't
+// one, a false will be returned.
+func (m *Member) Dequeue() (interface{}, bool) {
+	v, ok := m.q.Dequeue()
+	m.t.dequeued(ok)
+	return v, ok
+}
+
+// DequeueCtx removes the next item delivered to the member, waiting for one
+// if there isn't one, until ctx is done; see Queue.DequeueCtx.
+func (m *Member) DequeueCtx(ctx context.Context) (interface{}, error) {
+	v, err := m.q.DequeueCtx(ctx)
+	m.t.dequeued(err == nil)
+	return v, err
+}
+
+// Done reports the handling of a dequeued item: how long it took and the
+// error, if there was one. It is counted in the member's Stats.
+func (m *Member) Done(latency time.Duration, err error) {
+	m.t.done(latency, err)
+}
+
+// Stats returns the member's delivery stats.
+func (m *Member) Stats() ConsumerStats {
+	return m.t.get()
+}
+
+// Leave removes the member from its Affinity. Its sessions are reassigned to
+// the remaining members and the items that it hasn't dequeued are
+// redelivered.
+func (m *Member) Leave() {
+	a := m.a
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.ids[m.id] != m {
+		return
+	}
+	delete(a.ids, m.id)
+	for i, mm := range a.members {
+		if mm == m {
+			a.members = append(a.members[:i], a.members[i+1:]...)
+			break
+		}
+	}
+	for key, mm := range a.sessions {
+		if mm == m {
+			delete(a.sessions, key)
+		}
+	}
+	m.sessions = 0
+	for _, item := range m.q.Drain() {
+		a.route(item)
+	}
+}
@@ -0,0 +1,141 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// sess is an item in a session.
+type sess struct {
+	key string
+	n   int
+}
+
+func (s sess) Session() string { return s.key }
+
+func TestAffinity(t *testing.T) {
+	a := NewAffinity()
+	// items enqueued without members are held.
+	a.Enqueue(sess{"s0", 0})
+	m1, err := a.Join("m1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := a.Join("m1"); err == nil {
+		t.Error("expected an error joining with a duplicate id")
+	}
+	if m1.Len() != 1 {
+		t.Errorf("expected the held item to be delivered, got %d", m1.Len())
+	}
+	m2, _ := a.Join("m2")
+	// s0 is assigned to m1; s1 and s2 to the member with fewest sessions.
+	for i := 1; i <= 3; i++ {
+		for _, key := range []string{"s0", "s1", "s2"} {
+			a.Enqueue(sess{key, i})
+		}
+	}
+	tests := []struct {
+		session string
+		member  *Member
+	}{
+		{"s0", m1},
+		{"s1", m2},
+		{"s2", m1},
+	}
+	for _, test := range tests {
+		if m, ok := a.Member(test.session); !ok || m != test.member {
+			t.Errorf("%s: expected %s, got %v", test.session, test.member.ID(), m)
+		}
+	}
+	if m1.Len() != 7 || m2.Len() != 3 {
+		t.Errorf("expected 7 and 3 items, got %d and %d", m1.Len(), m2.Len())
+	}
+	// items of a session are delivered in order.
+	last := map[string]int{}
+	for {
+		v, ok := m2.Dequeue()
+		if !ok {
+			break
+		}
+		s := v.(sess)
+		if s.key != "s1" || s.n < last[s.key] {
+			t.Errorf("unexpected item %v", s)
+		}
+		last[s.key] = s.n
+	}
+	// when m1 leaves, its sessions and items move to m2.
+	m1.Dequeue()
+	m1.Leave()
+	if ids := a.Members(); len(ids) != 1 || ids[0] != "m2" {
+		t.Errorf("expected only m2 to be left, got %v", ids)
+	}
+	if m1.Len() != 0 || m2.Len() != 6 {
+		t.Errorf("expected 0 and 6 items, got %d and %d", m1.Len(), m2.Len())
+	}
+	last = map[string]int{}
+	for {
+		v, ok := m2.Dequeue()
+		if !ok {
+			break
+		}
+		s := v.(sess)
+		if s.n < last[s.key] {
+			t.Errorf("%s: item %d redelivered after %d", s.key, s.n, last[s.key])
+		}
+		last[s.key] = s.n
+	}
+	for _, key := range []string{"s0", "s2"} {
+		if m, _ := a.Member(key); m != m2 {
+			t.Errorf("%s: expected the session to be reassigned to m2", key)
+		}
+	}
+	m1.Leave() // leaving twice is a no-op.
+}
+
+func TestAffinityNoSession(t *testing.T) {
+	a := NewAffinity()
+	m1, _ := a.Join("m1")
+	m2, _ := a.Join("m2")
+	for i := 0; i < 10; i++ {
+		a.Enqueue(i)
+	}
+	if m1.Len() != 5 || m2.Len() != 5 {
+		t.Errorf("expected items to be delivered round-robin, got %d and %d", m1.Len(), m2.Len())
+	}
+}
+
+func TestAffinityForget(t *testing.T) {
+	a := NewAffinity()
+	m1, _ := a.Join("m1")
+	m2, _ := a.Join("m2")
+	a.Enqueue(sess{"a", 0})
+	a.Enqueue(sess{"b", 0})
+	a.Forget("a")
+	if _, ok := a.Member("a"); ok {
+		t.Error("expected a forgotten session to have no member")
+	}
+	// m1 has no sessions now, so the next new session goes to it.
+	a.Enqueue(sess{"c", 0})
+	if m, _ := a.Member("c"); m != m1 {
+		t.Errorf("expected c to be assigned to m1, got %s", m.ID())
+	}
+	if m, _ := a.Member("b"); m != m2 {
+		t.Errorf("expected b to be assigned to m2, got %s", m.ID())
+	}
+}
+
+func TestMemberDequeueCtx(t *testing.T) {
+	a := NewAffinity()
+	m, _ := a.Join("m")
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		a.Enqueue(sess{"s", 1})
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	v, err := m.DequeueCtx(ctx)
+	if err != nil || v != (sess{"s", 1}) {
+		t.Errorf("expected {s 1} nil, got %v %v", v, err)
+	}
+}
@@ -0,0 +1,54 @@
+package queue
+
+import (
+	"time"
+)
+
+// SetAging makes the queue age its items: an item's effective priority is
+// fn(priority, waited), where waited is how long the item has been in the
+// queue, so that low priority items aren't starved by a steady stream of
+// higher priority ones. fn should not decrease as waited grows. Items with
+// the same effective priority are dequeued in the order they were enqueued.
+// A nil fn, the default, turns aging off.
+//
+// Effective priorities are evaluated under the queue's lock at dequeue, or
+// peek, time, so Dequeue and Peek are O(n) while aging is on. Only the items
+// enqueued after aging is turned on are aged; a bounded queue evicts by the
+// items' enqueued priorities.
+func (p *Priority) SetAging(fn func(priority int, waited time.Duration) int) {
+	p.mu.Lock()
+	p.aging = fn
+	p.mu.Unlock()
+}
+
+// LinearAging returns an aging func, for SetAging, that raises an item's
+// priority by 1 for every per that it waits.
+func LinearAging(per time.Duration) func(priority int, waited time.Duration) int {
+	return func(priority int, waited time.Duration) int {
+		return priority + int(waited/per)
+	}
+}
+
+// next returns the item to dequeue next, or nil if the queue is empty. The
+// caller must hold the lock.
+func (p *Priority) next() *Item {
+	if len(p.items) == 0 {
+		return nil
+	}
+	if p.aging == nil {
+		return p.items[0]
+	}
+	now := time.Now()
+	var best *Item
+	var bestPriority int
+	for _, it := range p.items {
+		priority := it.priority
+		if !it.enqueued.IsZero() {
+			priority = p.aging(it.priority, now.Sub(it.enqueued))
+		}
+		if best == nil || priority > bestPriority || (priority == bestPriority && it.seq < best.seq) {
+			best, bestPriority = it, priority
+		}
+	}
+	return best
+}
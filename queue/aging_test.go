@@ -0,0 +1,63 @@
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPriorityAging(t *testing.T) {
+	p := NewPriority(4)
+	p.SetAging(LinearAging(10 * time.Millisecond))
+	p.Enqueue("low", 1)
+	time.Sleep(25 * time.Millisecond)
+	// low has aged to 3: it goes ahead of a 2, and, as it was enqueued first,
+	// ahead of the newer 3.
+	p.Enqueue("medium", 2)
+	p.Enqueue("high", 3)
+	p.Enqueue("urgent", 9)
+	for _, expected := range []struct {
+		item     string
+		priority int
+	}{{"urgent", 9}, {"low", 1}, {"high", 3}, {"medium", 2}} {
+		if v, _ := p.Peek(); v != expected.item {
+			t.Errorf("expected peek to return %s, got %v", expected.item, v)
+		}
+		if v, priority, ok := p.DequeuePriority(); !ok || v != expected.item || priority != expected.priority {
+			t.Errorf("expected %s %d true, got %v %d %t", expected.item, expected.priority, v, priority, ok)
+		}
+	}
+	if _, ok := p.Dequeue(); ok {
+		t.Error("expected the queue to be empty")
+	}
+}
+
+func TestPriorityAgingOff(t *testing.T) {
+	p := NewPriority(4)
+	p.SetAging(LinearAging(time.Millisecond))
+	p.Enqueue("low", 1)
+	time.Sleep(5 * time.Millisecond)
+	p.Enqueue("high", 2)
+	p.SetAging(nil)
+	if v, _ := p.Dequeue(); v != "high" {
+		t.Errorf("expected aging to be off, got %v", v)
+	}
+}
+
+func TestLinearAging(t *testing.T) {
+	fn := LinearAging(time.Second)
+	tests := []struct {
+		priority int
+		waited   time.Duration
+		expected int
+	}{
+		{0, 0, 0},
+		{5, 999 * time.Millisecond, 5},
+		{5, 3 * time.Second, 8},
+		{-2, time.Minute, 58},
+	}
+	for _, test := range tests {
+		if v := fn(test.priority, test.waited); v != test.expected {
+			t.Errorf("%d after %s: expected %d, got %d", test.priority, test.waited, test.expected, v)
+		}
+	}
+}
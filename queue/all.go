@@ -0,0 +1,26 @@
+//go:build go1.23
+
+package queue
+
+import (
+	"iter"
+)
+
+// All returns an iterator over a snapshot of the items in the queue, in FIFO
+// order, with their positions; see Range. Iterators need go1.23, so All is
+// only built with it.
+func (q *Queue) All() iter.Seq2[int, interface{}] {
+	return q.Range
+}
+
+// All returns an iterator over a snapshot of the items in the queue, in FIFO
+// order, with their positions; see Range.
+func (c *Circular) All() iter.Seq2[int, interface{}] {
+	return c.Range
+}
+
+// All returns an iterator over a snapshot of the items in the queue, in FIFO
+// order, with their positions; see Range.
+func (r ReadOnly) All() iter.Seq2[int, interface{}] {
+	return r.Range
+}
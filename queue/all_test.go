@@ -0,0 +1,61 @@
+//go:build go1.23
+
+package queue
+
+import (
+	"iter"
+	"testing"
+)
+
+func TestAll(t *testing.T) {
+	c := NewCircular(4)
+	for i := 0; i < 3; i++ {
+		c.Enqueue(i)
+	}
+	for _, test := range []struct {
+		name string
+		q    interface {
+			All() iter.Seq2[int, interface{}]
+		}
+	}{
+		{"queue", NewQueue(2)},
+		{"circular", c},
+		{"read only", NewReadOnly(c)},
+	} {
+		if q, ok := test.q.(*Queue); ok {
+			for i := 0; i < 3; i++ {
+				q.Enqueue(i)
+			}
+		}
+		// stopping early.
+		var got []interface{}
+		for i, v := range test.q.All() {
+			if i != len(got) {
+				t.Errorf("%s: expected position %d, got %d", test.name, len(got), i)
+			}
+			if got = append(got, v); len(got) == 2 {
+				break
+			}
+		}
+		if len(got) != 2 || got[0] != 0 || got[1] != 1 {
+			t.Errorf("%s: expected [0 1], got %v", test.name, got)
+		}
+	}
+}
+
+func TestRangeModify(t *testing.T) {
+	// fn may use the queue; it walks the snapshot taken when Range was
+	// called.
+	q := NewQueue(2)
+	q.Enqueue(1)
+	q.Enqueue(2)
+	var got []interface{}
+	for _, v := range q.All() {
+		q.Dequeue()
+		q.Enqueue(v.(int) * 10)
+		got = append(got, v)
+	}
+	if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Errorf("expected [1 2], got %v", got)
+	}
+}
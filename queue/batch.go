@@ -0,0 +1,182 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+)
+
+// EnqueueAll adds the items to the queue, in order, taking the lock once. The
+// number of items enqueued is returned; for an unbounded queue that is all of
+// them and the error is always nil.
+func (q *Queue) EnqueueAll(items []interface{}) (n int, err error) {
+	q.Lock()
+	if len(q.items)+len(items) > cap(q.items) {
+		_ = q.shift()
+	}
+	q.items = append(q.items, items...)
+	for _, item := range items {
+		q.account(item, 1)
+	}
+	q.publish()
+	q.Unlock()
+	for _, item := range items {
+		q.emit(EventEnqueue, item)
+	}
+	return len(items), nil
+}
+
+// DequeueN removes up to n items from the queue, taking the lock once, and
+// returns them in FIFO order. If the queue is empty, or n < 1, nil is
+// returned.
+func (q *Queue) DequeueN(n int) []interface{} {
+	q.Lock()
+	items, shrunk := q.dequeueN(n)
+	q.Unlock()
+	q.emitDequeueN(items, shrunk)
+	return items
+}
+
+// Drain removes all of the items from the queue and returns them in FIFO
+// order. If the queue is empty, nil is returned.
+func (q *Queue) Drain() []interface{} {
+	q.Lock()
+	items, shrunk := q.dequeueN(len(q.items) - q.head)
+	q.Unlock()
+	q.emitDequeueN(items, shrunk)
+	return items
+}
+
+// dequeueN is the unexported version of DequeueN; it also returns whether
+// or not the queue was shrunk. The caller must hold the lock.
+func (q *Queue) dequeueN(n int) ([]interface{}, bool) {
+	if l := len(q.items) - q.head; n > l {
+		n = l
+	}
+	if n < 1 || q.claimed {
+		return nil, false
+	}
+	items := append([]interface{}(nil), q.items[q.head:q.head+n]...)
+	clear(q.items[q.head : q.head+n])
+	q.head += n
+	for _, item := range items {
+		q.account(item, -1)
+	}
+	shrunk := q.shrink()
+	q.publish()
+	return items, shrunk
+}
+
+// emitDequeueN emits the events for a batch dequeue.
+func (q *Queue) emitDequeueN(items []interface{}, shrunk bool) {
+	for _, item := range items {
+		q.emit(EventDequeue, item)
+	}
+	if shrunk {
+		q.emit(EventResize, nil)
+	}
+}
+
+// event is an event to be emitted once the lock has been released.
+type event struct {
+	kind EventKind
+	item interface{}
+}
+
+// EnqueueAll adds the items to the queue, in order, taking the lock once. The
+// number of items enqueued is returned. When the queue fills up, the queue's
+// overflow policy is applied to each of the remaining items: with
+// OverflowError, the enqueue stops and an error is returned for the first
+// item that didn't fit; with OverflowBlock, the remaining items are enqueued
+// as room is made for them.
+func (c *Circular) EnqueueAll(items []interface{}) (n int, err error) {
+	// the events are emitted once the lock is released.
+	var events []event
+	c.Lock()
+	i := 0
+	for ; i < len(items); i++ {
+		item := items[i]
+		if c.enqueue(item) {
+			n++
+			events = append(events, event{EventEnqueue, item})
+			continue
+		}
+		if c.policy == OverflowDropOldest {
+			c.claimed = false
+			evicted, _ := c.dequeue()
+			c.enqueue(item)
+			n++
+			events = append(events, event{EventDrop, evicted}, event{EventEnqueue, item})
+			continue
+		}
+		if c.policy == OverflowDropNewest {
+			events = append(events, event{EventDrop, item})
+			continue
+		}
+		break
+	}
+	c.Unlock()
+	for _, e := range events {
+		c.emit(e.kind, e.item)
+	}
+	rest := items[i:]
+	if len(rest) == 0 {
+		return n, nil
+	}
+	if c.policy == OverflowBlock {
+		for _, item := range rest {
+			if err := c.EnqueueCtx(context.Background(), item); err != nil {
+				return n, err
+			}
+			n++
+		}
+		return n, nil
+	}
+	c.emit(EventDrop, rest[0])
+	return n, fmt.Errorf("queue full: cannot enqueue %v", rest[0])
+}
+
+// DequeueN removes up to n items from the queue, taking the lock once, and
+// returns them in FIFO order. If the queue is empty, or n < 1, nil is
+// returned.
+func (c *Circular) DequeueN(n int) []interface{} {
+	c.Lock()
+	items := c.dequeueN(n)
+	c.Unlock()
+	for _, item := range items {
+		c.emit(EventDequeue, item)
+	}
+	return items
+}
+
+// Drain removes all of the items from the queue and returns them in FIFO
+// order. If the queue is empty, nil is returned.
+func (c *Circular) Drain() []interface{} {
+	c.Lock()
+	items := c.dequeueN(c.plen())
+	c.Unlock()
+	for _, item := range items {
+		c.emit(EventDequeue, item)
+	}
+	return items
+}
+
+// dequeueN is the unexported version of DequeueN; the caller must hold the
+// lock.
+func (c *Circular) dequeueN(n int) []interface{} {
+	if l := c.plen(); n > l {
+		n = l
+	}
+	if n < 1 || c.claimed {
+		return nil
+	}
+	items := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		item := c.items[c.head]
+		items = append(items, item)
+		c.account(item, -1)
+		c.items[c.head] = nil
+		c.head = c.inc(c.head)
+	}
+	c.publish()
+	return items
+}
@@ -0,0 +1,119 @@
+package queue
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestQueueBatch(t *testing.T) {
+	q := NewQueue(2)
+	_ = q.Enqueue(0)
+	n, err := q.EnqueueAll([]interface{}{1, 2, 3, 4})
+	if n != 4 || err != nil {
+		t.Errorf("expected 4 <nil>, got %d %v", n, err)
+	}
+	if got := fmt.Sprint(q.DequeueN(2)); got != "[0 1]" {
+		t.Errorf("expected [0 1], got %s", got)
+	}
+	if got := q.DequeueN(0); got != nil {
+		t.Errorf("expected nil, got %v", got)
+	}
+	if got := fmt.Sprint(q.DequeueN(10)); got != "[2 3 4]" {
+		t.Errorf("expected [2 3 4], got %s", got)
+	}
+	_, _ = q.EnqueueAll([]interface{}{5, 6})
+	if got := fmt.Sprint(q.Drain()); got != "[5 6]" {
+		t.Errorf("expected [5 6], got %s", got)
+	}
+	if !q.IsEmpty() || q.Drain() != nil {
+		t.Error("expected the drained queue to be empty")
+	}
+}
+
+func TestCircularBatch(t *testing.T) {
+	tests := []struct {
+		policy   OverflowPolicy
+		n        int
+		err      string
+		expected string
+		events   string
+	}{
+		{OverflowError, 2, "queue full: cannot enqueue 3", "[1 2]", "[enqueue 1 enqueue 2 drop 3]"},
+		{OverflowDropOldest, 4, "", "[3 4]", "[enqueue 1 enqueue 2 drop 1 enqueue 3 drop 2 enqueue 4]"},
+		{OverflowDropNewest, 2, "", "[1 2]", "[enqueue 1 enqueue 2 drop 3 drop 4]"},
+	}
+	for _, test := range tests {
+		var events []string
+		b := NewBus()
+		b.Subscribe(func(e Event) { events = append(events, fmt.Sprint(e.Kind, " ", e.Item)) })
+		c := NewCircularWithPolicy(2, test.policy)
+		c.SetBus(b)
+		n, err := c.EnqueueAll([]interface{}{1, 2, 3, 4})
+		if n != test.n {
+			t.Errorf("%s: expected %d items enqueued, got %d", test.policy, test.n, n)
+		}
+		if (err == nil && test.err != "") || (err != nil && err.Error() != test.err) {
+			t.Errorf("%s: expected error %q, got %v", test.policy, test.err, err)
+		}
+		if got := fmt.Sprint(events); got != test.events {
+			t.Errorf("%s: expected events %s, got %s", test.policy, test.events, got)
+		}
+		if got := fmt.Sprint(c.Drain()); got != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.policy, test.expected, got)
+		}
+	}
+
+	// wrap around.
+	c := NewCircular(3)
+	_, _ = c.EnqueueAll([]interface{}{1, 2})
+	c.DequeueN(2)
+	_, _ = c.EnqueueAll([]interface{}{3, 4, 5})
+	if got := fmt.Sprint(c.DequeueN(2)); got != "[3 4]" {
+		t.Errorf("expected [3 4], got %s", got)
+	}
+	if c.Len() != 1 {
+		t.Errorf("expected len to be 1, got %d", c.Len())
+	}
+}
+
+func TestCircularBatchBlock(t *testing.T) {
+	c := NewCircularWithPolicy(2, OverflowBlock)
+	done := make(chan int)
+	go func() {
+		n, _ := c.EnqueueAll([]interface{}{1, 2, 3, 4})
+		done <- n
+	}()
+	var got []interface{}
+	for len(got) < 4 {
+		got = append(got, c.DequeueN(4)...)
+	}
+	if n := <-done; n != 4 {
+		t.Errorf("expected 4 items enqueued, got %d", n)
+	}
+	if fmt.Sprint(got) != "[1 2 3 4]" {
+		t.Errorf("expected [1 2 3 4], got %v", got)
+	}
+}
+
+func BenchmarkQueueEnqueueAll(b *testing.B) {
+	q := NewQueue(512)
+	items := make([]interface{}, 256)
+	for i := range items {
+		items[i] = payload
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = q.EnqueueAll(items)
+		q.Drain()
+	}
+}
+
+func TestEnqueueAllStats(t *testing.T) {
+	// the events are emitted, and counted, without a bus.
+	c := NewCircularWithPolicy(2, OverflowDropNewest)
+	c.SetStats(true)
+	c.EnqueueAll([]interface{}{1, 2, 3})
+	if s := c.Stats(); s.Enqueued != 2 || s.Dropped != 1 {
+		t.Errorf("expected 2 enqueued and 1 dropped, got %d and %d", s.Enqueued, s.Dropped)
+	}
+}
@@ -0,0 +1,58 @@
+package queue
+
+import (
+	"context"
+)
+
+// Blocker is implemented by queues with blocking enqueues and dequeues, e.g.
+// Queue and Circular.
+type Blocker interface {
+	EnqueueCtx(ctx context.Context, item interface{}) error
+	DequeueCtx(ctx context.Context) (interface{}, error)
+}
+
+// Bridge exposes q as a pair of channels so that it can be used from select
+// based code: items sent on in are enqueued on q and items dequeued from q
+// are received on out. A goroutine moves the items in each direction; both
+// stop when ctx is done, and out is closed.
+//
+// Items are enqueued with EnqueueCtx, so a full bounded queue applies back
+// pressure to the senders on in, or drops items, according to its overflow
+// policy. Closing in stops its goroutine; the senders on in must stop sending
+// once ctx is done, as nothing receives from in after that. An item that has
+// been received from in, but not yet enqueued, or dequeued, but not yet
+// received from out, when ctx is done, is lost.
+func Bridge(ctx context.Context, q Blocker) (in chan<- interface{}, out <-chan interface{}) {
+	i := make(chan interface{})
+	o := make(chan interface{})
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case item, ok := <-i:
+				if !ok {
+					return
+				}
+				if q.EnqueueCtx(ctx, item) != nil && ctx.Err() != nil {
+					return
+				}
+			}
+		}
+	}()
+	go func() {
+		defer close(o)
+		for {
+			item, err := q.DequeueCtx(ctx)
+			if err != nil {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case o <- item:
+			}
+		}
+	}()
+	return i, o
+}
@@ -0,0 +1,76 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBridge(t *testing.T) {
+	tests := []struct {
+		name string
+		q    Blocker
+	}{
+		{"queue", NewQueue(2)},
+		{"circular", NewCircular(2)},
+		{"drop oldest", NewCircularWithPolicy(2, OverflowDropOldest)},
+	}
+	for _, test := range tests {
+		ctx, cancel := context.WithCancel(context.Background())
+		in, out := Bridge(ctx, test.q)
+		go func() {
+			for i := 0; i < 10; i++ {
+				in <- i
+			}
+		}()
+		// a bounded queue applies back pressure, so nothing is lost while
+		// the receiver keeps up; drop oldest never blocks, so only the
+		// order is checked.
+		last := -1
+		for i := 0; i < 10; i++ {
+			var v interface{}
+			select {
+			case v = <-out:
+			case <-time.After(100 * time.Millisecond):
+			}
+			if v == nil {
+				if test.name == "drop oldest" {
+					break
+				}
+				t.Fatalf("%s: %d: timed out", test.name, i)
+			}
+			if v.(int) <= last {
+				t.Errorf("%s: received %v after %d", test.name, v, last)
+			}
+			if test.name != "drop oldest" && v != i {
+				t.Errorf("%s: expected %d, got %v", test.name, i, v)
+			}
+			last = v.(int)
+		}
+		cancel()
+		select {
+		case _, ok := <-out:
+			for ok {
+				_, ok = <-out
+			}
+		case <-time.After(time.Second):
+			t.Errorf("%s: expected out to be closed once ctx is done", test.name)
+		}
+	}
+}
+
+func TestBridgeSelect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q := NewQueue(2)
+	_, out := Bridge(ctx, q)
+	q.Enqueue("x")
+	select {
+	case v := <-out:
+		if v != "x" {
+			t.Errorf("expected x, got %v", v)
+		}
+	case <-time.After(time.Second):
+		t.Error("timed out")
+	}
+}
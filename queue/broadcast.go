@@ -0,0 +1,129 @@
+package queue
+
+import (
+	"sync"
+)
+
+// SlowPolicy is what a Broadcast does when a subscriber's queue is full.
+type SlowPolicy int
+
+// The slow subscriber policies.
+const (
+	// SlowDropOldest evicts the subscriber's oldest item to make room for
+	// the new one; a slow subscriber misses items but stays subscribed.
+	// This is the default.
+	SlowDropOldest SlowPolicy = iota
+	// SlowDisconnect unsubscribes the subscriber; it can still dequeue the
+	// items already in its queue, but it gets no new ones.
+	SlowDisconnect
+)
+
+func (p SlowPolicy) String() string {
+	switch p {
+	case SlowDropOldest:
+		return "drop oldest"
+	case SlowDisconnect:
+		return "disconnect"
+	}
+	return "unknown"
+}
+
+// Broadcast is a fan-out queue: every item enqueued is delivered to every
+// subscriber, each of which has its own bounded Circular queue. Subscribers
+// consume at their own pace; when one falls so far behind that its queue is
+// full, the Broadcast's SlowPolicy decides what happens. Every subscriber
+// gets the items in the same order.
+type Broadcast struct {
+	mu     sync.Mutex
+	size   int
+	policy SlowPolicy
+	subs   []*Subscription
+}
+
+// Subscription is a subscriber to a Broadcast. Its Circular queue holds the
+// items delivered to it; it should only be dequeued from.
+type Subscription struct {
+	*Circular
+	b    *Broadcast
+	once sync.Once
+	done chan struct{}
+}
+
+// NewBroadcast returns a Broadcast with no subscribers whose subscribers'
+// queues hold size items.
+func NewBroadcast(size int, policy SlowPolicy) *Broadcast {
+	return &Broadcast{size: size, policy: policy}
+}
+
+// Subscribe adds a subscriber; it gets every item enqueued after Subscribe
+// returns.
+func (b *Broadcast) Subscribe() *Subscription {
+	policy := OverflowDropOldest
+	if b.policy == SlowDisconnect {
+		policy = OverflowError
+	}
+	s := &Subscription{Circular: NewCircularWithPolicy(b.size, policy), b: b, done: make(chan struct{})}
+	b.mu.Lock()
+	b.subs = append(b.subs, s)
+	b.mu.Unlock()
+	return s
+}
+
+// Enqueue delivers item to every subscriber. This never fails; with the
+// SlowDisconnect policy, a subscriber whose queue is full is unsubscribed
+// instead.
+func (b *Broadcast) Enqueue(item interface{}) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	j := 0
+	for _, s := range b.subs {
+		if s.Circular.Enqueue(item) != nil {
+			s.close()
+			continue
+		}
+		b.subs[j] = s
+		j++
+	}
+	clear(b.subs[j:])
+	b.subs = b.subs[:j]
+	return nil
+}
+
+// Subscribers returns the number of subscribers.
+func (b *Broadcast) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+// remove unsubscribes s.
+func (b *Broadcast) remove(s *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, ss := range b.subs {
+		if ss == s {
+			n := copy(b.subs[i:], b.subs[i+1:])
+			b.subs[i+n] = nil
+			b.subs = b.subs[:i+n]
+			return
+		}
+	}
+}
+
+// Unsubscribe stops delivery to the subscriber. The items already in its
+// queue can still be dequeued.
+func (s *Subscription) Unsubscribe() {
+	s.b.remove(s)
+	s.close()
+}
+
+// Done returns a channel that is closed once the subscriber is unsubscribed
+// or disconnected, so a consumer waiting in DequeueCtx can stop.
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// close closes the done channel, once.
+func (s *Subscription) close() {
+	s.once.Do(func() { close(s.done) })
+}
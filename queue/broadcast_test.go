@@ -0,0 +1,64 @@
+package queue
+
+import (
+	"testing"
+)
+
+func TestBroadcast(t *testing.T) {
+	b := NewBroadcast(2, SlowDropOldest)
+	early := b.Subscribe()
+	b.Enqueue(0)
+	late := b.Subscribe()
+	for i := 1; i < 4; i++ {
+		b.Enqueue(i)
+	}
+	// early missed 0 and 1, its oldest; late only ever got 1, 2, and 3.
+	for _, s := range []*Subscription{early, late} {
+		for _, expected := range []int{2, 3} {
+			if v, ok := s.Dequeue(); !ok || v != expected {
+				t.Errorf("expected %d true, got %v %t", expected, v, ok)
+			}
+		}
+	}
+	if b.Subscribers() != 2 {
+		t.Errorf("expected 2 subscribers, got %d", b.Subscribers())
+	}
+	late.Unsubscribe()
+	late.Unsubscribe()
+	b.Enqueue(4)
+	if late.Len() != 0 || early.Len() != 1 || b.Subscribers() != 1 {
+		t.Errorf("expected only early to get 4, got lens %d %d", early.Len(), late.Len())
+	}
+	select {
+	case <-late.Done():
+	default:
+		t.Error("expected an unsubscribed subscriber to be done")
+	}
+}
+
+func TestBroadcastDisconnect(t *testing.T) {
+	b := NewBroadcast(1, SlowDisconnect)
+	slow := b.Subscribe()
+	fast := b.Subscribe()
+	b.Enqueue(1)
+	fast.Dequeue()
+	b.Enqueue(2)
+	if b.Subscribers() != 1 {
+		t.Fatalf("expected the slow subscriber to be disconnected, got %d subscribers", b.Subscribers())
+	}
+	select {
+	case <-slow.Done():
+	default:
+		t.Error("expected a disconnected subscriber to be done")
+	}
+	// the slow subscriber still has the item it hadn't dequeued.
+	if v, ok := slow.Dequeue(); !ok || v != 1 {
+		t.Errorf("expected 1 true, got %v %t", v, ok)
+	}
+	if v, _ := fast.Dequeue(); v != 2 {
+		t.Errorf("expected 2, got %v", v)
+	}
+	if SlowDisconnect.String() != "disconnect" || SlowPolicy(9).String() != "unknown" {
+		t.Error("unexpected SlowPolicy strings")
+	}
+}
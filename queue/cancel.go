@@ -0,0 +1,71 @@
+package queue
+
+// Tagger is implemented by items that carry a cancellation tag, e.g. the ID
+// of the request or user the work is being done for.
+type Tagger interface {
+	Tag() string
+}
+
+// hasTag returns whether or not item is a Tagger with the received tag.
+func hasTag(item interface{}, tag string) bool {
+	t, ok := item.(Tagger)
+	return ok && t.Tag() == tag
+}
+
+// CancelTag cancels the work tagged with tag: every queued item whose Tag is
+// tag is removed from the queue and, from now on, IsCancelled reports those
+// items, and any already dequeued, in flight, items with the tag, as
+// cancelled. The number of items removed is returned; as with RemoveWhere,
+// each is emitted as a dequeue.
+//
+// The queue remembers cancelled tags until they are forgotten with ForgetTag.
+func (q *Queue) CancelTag(tag string) int {
+	q.Lock()
+	q.cancel(tag)
+	removed := q.removeWhere(func(item interface{}) bool { return hasTag(item, tag) })
+	q.Unlock()
+	q.emitRemoved(removed)
+	return len(removed)
+}
+
+// CancelTag cancels the work tagged with tag; see Queue.CancelTag. The
+// remaining items are moved to the front of the queue.
+func (c *Circular) CancelTag(tag string) int {
+	c.Lock()
+	c.cancel(tag)
+	removed := c.removeWhere(func(item interface{}) bool { return hasTag(item, tag) })
+	c.Unlock()
+	c.emitRemoved(removed)
+	return len(removed)
+}
+
+// cancel adds tag to the cancelled tags. The caller must hold the lock.
+func (q *Queue) cancel(tag string) {
+	if q.cancelled == nil {
+		q.cancelled = make(map[string]struct{})
+	}
+	q.cancelled[tag] = struct{}{}
+}
+
+// IsCancelled returns whether or not item's tag has been cancelled. Items that
+// aren't Taggers are never cancelled. Consumers should check this before, and
+// while, doing an item's work.
+func (q *Queue) IsCancelled(item interface{}) bool {
+	t, ok := item.(Tagger)
+	if !ok {
+		return false
+	}
+	q.Lock()
+	defer q.Unlock()
+	_, ok = q.cancelled[t.Tag()]
+	return ok
+}
+
+// ForgetTag removes tag from the cancelled tags, e.g. once all of its in
+// flight work has finished. IsCancelled no longer reports items with the tag
+// as cancelled.
+func (q *Queue) ForgetTag(tag string) {
+	q.Lock()
+	defer q.Unlock()
+	delete(q.cancelled, tag)
+}
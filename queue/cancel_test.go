@@ -0,0 +1,74 @@
+package queue
+
+import (
+	"testing"
+)
+
+type job struct {
+	tag string
+	n   int
+}
+
+func (j job) Tag() string {
+	return j.tag
+}
+
+func TestCancelTag(t *testing.T) {
+	tests := []struct {
+		name string
+		q    interface {
+			Queuer
+			CancelTag(string) int
+			IsCancelled(interface{}) bool
+			OnDequeue(func(interface{})) func()
+		}
+	}{
+		{"queue", NewQueue(2)},
+		{"circular", NewCircular(6)},
+	}
+	for _, test := range tests {
+		q := test.q
+		_ = q.Enqueue(job{"a", 0})
+		inFlight, _ := q.Dequeue()
+		for i, tag := range []string{"a", "b", "a", "b"} {
+			_ = q.Enqueue(job{tag, i + 1})
+		}
+		_ = q.Enqueue("untagged")
+		var dequeued int
+		remove := q.OnDequeue(func(interface{}) { dequeued++ })
+		if n := q.CancelTag("a"); n != 2 {
+			t.Errorf("%s: expected 2 items to be removed, got %d", test.name, n)
+		}
+		if dequeued != 2 {
+			t.Errorf("%s: expected the 2 removed items to be emitted, got %d", test.name, dequeued)
+		}
+		remove()
+		if q.Len() != 3 {
+			t.Errorf("%s: expected len to be 3, got %d", test.name, q.Len())
+		}
+		if !q.IsCancelled(inFlight) {
+			t.Errorf("%s: expected the in flight item to be cancelled", test.name)
+		}
+		for i, expected := range []interface{}{job{"b", 2}, job{"b", 4}, "untagged"} {
+			v, ok := q.Dequeue()
+			if !ok || v != expected {
+				t.Errorf("%s: %d: expected %v, got %v %t", test.name, i, expected, v, ok)
+			}
+			if q.IsCancelled(v) {
+				t.Errorf("%s: %d: expected %v to not be cancelled", test.name, i, v)
+			}
+		}
+	}
+}
+
+func TestForgetTag(t *testing.T) {
+	q := NewQueue(1)
+	q.CancelTag("a")
+	if !q.IsCancelled(job{tag: "a"}) {
+		t.Error("expected tag a to be cancelled")
+	}
+	q.ForgetTag("a")
+	if q.IsCancelled(job{tag: "a"}) {
+		t.Error("expected tag a to have been forgotten")
+	}
+}
@@ -0,0 +1,90 @@
+package queue
+
+// Capabilities describes what a queue can do, so that code that composes
+// queues, e.g. a Dispatcher or a Bridge, can check that a queue is suitable
+// when it is configured instead of failing in confusing ways when it is used.
+type Capabilities struct {
+	Bounded        bool // the queue has a fixed capacity; enqueues can fail or drop items when it's full.
+	Drops          bool // enqueues to a full queue drop an item instead of failing.
+	Blocking       bool // the queue has EnqueueCtx and DequeueCtx, which wait; see Blocker.
+	Priority       bool // items are dequeued by priority, not in FIFO order.
+	Delayed        bool // items can't be dequeued until their ready time.
+	Claims         bool // the queue supports Claim, Commit, and Abort.
+	LockFree       bool // the queue doesn't use a lock.
+	SingleConsumer bool // only one goroutine at a time may dequeue.
+}
+
+// Capable is implemented by queues that report their Capabilities.
+type Capable interface {
+	Capabilities() Capabilities
+}
+
+// claimer is implemented by queues that support claims.
+type claimer interface {
+	Claim() (interface{}, bool)
+	Commit() bool
+	Abort() bool
+}
+
+// CapabilitiesOf returns q's Capabilities. If q isn't Capable, the
+// capabilities that can be told from q's methods, Blocking and Claims, are
+// returned.
+func CapabilitiesOf(q interface{}) Capabilities {
+	if c, ok := q.(Capable); ok {
+		return c.Capabilities()
+	}
+	_, blocking := q.(Blocker)
+	_, claims := q.(claimer)
+	return Capabilities{Blocking: blocking, Claims: claims}
+}
+
+// Capabilities returns the queue's Capabilities.
+func (q *Queue) Capabilities() Capabilities {
+	return Capabilities{Blocking: true, Claims: true}
+}
+
+// Capabilities returns the queue's Capabilities; whether it drops items
+// depends on its overflow policy.
+func (c *Circular) Capabilities() Capabilities {
+	drops := c.policy == OverflowDropOldest || c.policy == OverflowDropNewest
+	return Capabilities{Bounded: true, Drops: drops, Blocking: true, Claims: true}
+}
+
+// Capabilities returns the queue's Capabilities.
+func (p *Priority) Capabilities() Capabilities {
+	return Capabilities{Bounded: p.cap > 0, Drops: p.cap > 0, Priority: true}
+}
+
+// Capabilities returns the queue's Capabilities.
+func (d *Delay) Capabilities() Capabilities {
+	return Capabilities{Delayed: true}
+}
+
+// Capabilities returns the queue's Capabilities.
+func (s *Sharded) Capabilities() Capabilities {
+	return Capabilities{Bounded: true}
+}
+
+// Capabilities returns the queue's Capabilities.
+func (q *MPMC) Capabilities() Capabilities {
+	return Capabilities{Bounded: true, LockFree: true}
+}
+
+// Capabilities returns the queue's Capabilities.
+func (q *MPSC) Capabilities() Capabilities {
+	return Capabilities{LockFree: true, SingleConsumer: true}
+}
+
+// Capabilities returns the queue's Capabilities. The queue's links are lock
+// free but its freelist isn't.
+func (q *LinkedMPSC) Capabilities() Capabilities {
+	return Capabilities{SingleConsumer: true}
+}
+
+// Capabilities returns the primary's Capabilities, less those the Mirror
+// doesn't pass through: it doesn't block or support claims.
+func (m *Mirror) Capabilities() Capabilities {
+	c := CapabilitiesOf(m.primary)
+	c.Blocking, c.Claims = false, false
+	return c
+}
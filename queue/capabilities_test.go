@@ -0,0 +1,36 @@
+package queue
+
+import (
+	"testing"
+)
+
+func TestCapabilities(t *testing.T) {
+	tests := []struct {
+		name     string
+		q        interface{}
+		expected Capabilities
+	}{
+		{"queue", NewQueue(2), Capabilities{Blocking: true, Claims: true}},
+		{"circular", NewCircular(2), Capabilities{Bounded: true, Blocking: true, Claims: true}},
+		{"drop oldest", NewCircularWithPolicy(2, OverflowDropOldest), Capabilities{Bounded: true, Drops: true, Blocking: true, Claims: true}},
+		{"block", NewCircularWithPolicy(2, OverflowBlock), Capabilities{Bounded: true, Blocking: true, Claims: true}},
+		{"priority", NewPriority(2), Capabilities{Priority: true}},
+		{"bounded priority", NewBoundedPriority(2, nil), Capabilities{Bounded: true, Drops: true, Priority: true}},
+		{"delay", NewDelay(2), Capabilities{Delayed: true}},
+		{"sharded", NewSharded(2, 2), Capabilities{Bounded: true}},
+		{"mpmc", NewMPMC(2), Capabilities{Bounded: true, LockFree: true}},
+		{"mpsc", NewMPSC(), Capabilities{LockFree: true, SingleConsumer: true}},
+		{"linked mpsc", NewLinkedMPSC(0), Capabilities{SingleConsumer: true}},
+		{"lanes", NewLanes(10, 0.1), Capabilities{Bounded: true, Priority: true}},
+		{"mirror", NewMirror(NewCircular(2), NewCircular(2), nil), Capabilities{Bounded: true}},
+		// without Capabilities, what the methods tell.
+		{"member", &Member{q: NewQueue(0)}, Capabilities{}},
+		{"blocker", struct{ Blocker }{NewQueue(0)}, Capabilities{Blocking: true}},
+		{"nil", nil, Capabilities{}},
+	}
+	for _, test := range tests {
+		if c := CapabilitiesOf(test.q); c != test.expected {
+			t.Errorf("%s: expected %+v, got %+v", test.name, test.expected, c)
+		}
+	}
+}
@@ -1,17 +1,21 @@
 package queue
 
 import (
+	"context"
 	"fmt"
-	"math"
 )
 
-// Circular is a bounded queue implemented as a circular queue.  Even though
-// Items, Head, and Tail are exported, in most cases, they should not be
-// directly.  Doing so may lead to outcomes less than desirable. Use the
-// exported methods to interact with the Circular queue.
+// Circular is a bounded queue implemented as a circular queue. Its internal
+// state is unexported; Positions, Snapshot, At, PeekAt, PeekN, and Contains
+// provide safe, read-only, access to it. The underlying slice has one slot
+// more than the queue's capacity, for empty/full detection, but that slot is
+// never visible: Cap, Resize, and IsFull all work in terms of the queue's
+// current capacity, which SetCap and a Tuner can change, excluding the spare
+// slot.
 type Circular struct {
 	Queue
-	Tail int
+	tail   int // the slot the next item will be enqueued in.
+	policy OverflowPolicy
 }
 
 // NewCircular returns an initialized circular queue. Even though creating
@@ -26,37 +30,103 @@ func NewCircular(size int) *Circular {
 	size++
 	c := Circular{Queue: *NewQueue(size)}
 	_ = c.zeroQueue()
+	c.publish()
 	return &c
 }
 
-// Enqueue will return an error if the queue is full
+// publish updates the queue's packed len and cap, and wakes any goroutines
+// waiting for the queue to change. This must be called, while holding the
+// lock, after any operation that changes either.
+func (c *Circular) publish() {
+	l := c.plen()
+	c.state.Store(pack(l, cap(c.items)-1))
+	c.stats.mark(l)
+	c.gauge(l, cap(c.items)-1, true)
+	c.broadcast()
+}
+
+// Enqueue will return an error if the queue is full, unless the queue has an
+// overflow policy other than OverflowError; see NewCircularWithPolicy.
 func (c *Circular) Enqueue(item interface{}) error {
+	if c.policy == OverflowBlock {
+		return c.EnqueueCtx(context.Background(), item)
+	}
 	c.Lock()
-	if c.isFull() {
+	if c.enqueue(item) {
 		c.Unlock()
-		return fmt.Errorf("queue full: cannot enqueue %v", item)
+		c.emit(EventEnqueue, item)
+		return nil
+	}
+	switch c.policy {
+	case OverflowDropOldest:
+		// evicting the head ends its claim.
+		c.claimed = false
+		evicted, _ := c.dequeue()
+		c.enqueue(item)
+		c.Unlock()
+		c.emit(EventDrop, evicted)
+		c.emit(EventEnqueue, item)
+		return nil
+	case OverflowDropNewest:
+		c.Unlock()
+		c.emit(EventDrop, item)
+		return nil
 	}
-	c.Items[c.Tail] = item
-	c.Tail = int(math.Mod(float64(c.Tail+1), float64(cap(c.Items))))
 	c.Unlock()
-	return nil
+	c.emit(EventDrop, item)
+	return fmt.Errorf("queue full: cannot enqueue %v", item)
+}
+
+// enqueue is the unexported version of Enqueue; it returns false if the
+// queue is full. The caller must hold the lock.
+func (c *Circular) enqueue(item interface{}) bool {
+	if c.isFull() {
+		return false
+	}
+	c.items[c.tail] = item
+	c.account(item, 1)
+	c.tail = c.inc(c.tail)
+	c.publish()
+	return true
 }
 
 // Dequeue will remove an item from the queue and return it. If the queue is
 // empty, a false will be returned.
 func (c *Circular) Dequeue() (interface{}, bool) {
 	c.Lock()
+	item, ok := c.dequeue()
+	c.Unlock()
+	if ok {
+		c.emit(EventDequeue, item)
+	}
+	return item, ok
+}
+
+// dequeue is the unexported version of Dequeue; the caller must hold the
+// lock.
+func (c *Circular) dequeue() (interface{}, bool) {
+	if c.claimed {
+		return nil, false
+	}
 	item, ok := c.peek()
 	if ok {
-		c.Head = int(math.Mod(float64(c.Head+1), float64(cap(c.Items))))
+		// release the slot's reference so the item can be collected.
+		c.items[c.head] = nil
+		c.head = c.inc(c.head)
+		c.account(item, -1)
+		c.publish()
 	}
-	c.Unlock()
 	return item, ok
 }
 
 // Peek will return the next item in the queue without removing it from the
-// queue. If the queue is empty, a false will be returned.
+// queue. If the queue is empty, a false will be returned without taking the
+// lock; otherwise the lock is taken, as the head slot is overwritten by
+// dequeues and enqueues.
 func (c *Circular) Peek() (interface{}, bool) {
+	if c.IsEmpty() {
+		return nil, false
+	}
 	c.Lock()
 	defer c.Unlock()
 	return c.peek()
@@ -67,99 +137,191 @@ func (c *Circular) peek() (interface{}, bool) {
 	if c.isEmpty() {
 		return nil, false
 	}
-	return c.Items[c.Head], true
+	return c.items[c.head], true
 }
 
-// IsEmpty returns whether or not the queue is empty
+// IsEmpty returns whether or not the queue is empty. This does not take the
+// lock.
 func (c *Circular) IsEmpty() bool {
-	c.Lock()
-	defer c.Unlock()
-	return c.isEmpty()
+	return c.Len() == 0
 }
 
 // isEmpty is an unexported version that expects the caller to handle locking.
 // This eliminates double locking on dequeue and peek
 func (c *Circular) isEmpty() bool {
-	if c.Head == c.Tail {
+	if c.head == c.tail {
 		return true
 	}
 	return false
 }
 
-// IsFull returns whether or not the queue is full
+// IsFull returns whether or not the queue is full. This does not take the
+// lock.
 func (c *Circular) IsFull() bool {
-	c.Lock()
-	defer c.Unlock()
-	return c.isFull()
+	l, cp := unpack(c.state.Load())
+	return l == cp
 }
 
 // isFull is an unexported version that expects the caller to handle locking.
 // This eliminates double locking on enqueue
 func (c *Circular) isFull() bool {
-	if c.Head == int(math.Mod(float64(c.Tail+1), float64(cap(c.Items)))) {
+	if c.head == c.inc(c.tail) {
 		return true
 	}
 	return false
 }
 
-// Len returns the current length of the queue (# of items in queue)
+// Len returns the current length of the queue (# of items in queue). This
+// does not take the lock.
 func (c *Circular) Len() int {
-	c.Lock()
-	defer c.Unlock()
-	return c.plen()
+	l, _ := unpack(c.state.Load())
+	return l
+}
+
+// inc returns the position after i in the underlying slice, wrapping around
+// to 0 at the end. Positions are always in [0, cap(items)), so wrapping is a
+// compare, not a division; see also at.
+func (c *Circular) inc(i int) int {
+	if i++; i == cap(c.items) {
+		return 0
+	}
+	return i
 }
 
 // plen returns the current length of the queue (# items in queue).  This
 // unexported method does not do any locking of its own, it relies on
 // the caller to take care of locking.
 func (c *Circular) plen() int {
-	l := c.Tail
-	if c.Tail < c.Head {
-		l += cap(c.Items)
+	l := c.tail
+	if c.tail < c.head {
+		l += cap(c.items)
 	}
-	return l - c.Head
+	return l - c.head
 }
 
-// Cap returns the current queue capacity:
-//    queue cap = cap(queue) - 1
+// Cap returns the current capacity of the queue: the number of items it can
+// hold. This does not take the lock.
 func (c *Circular) Cap() int {
+	_, cp := unpack(c.state.Load())
+	return cp
+}
+
+// Positions returns the current positions of the queue's head and tail in
+// its underlying slice.
+func (c *Circular) Positions() (head, tail int) {
+	c.Lock()
+	defer c.Unlock()
+	return c.head, c.tail
+}
+
+// Snapshot returns a copy of the items in the queue, in FIFO order. The queue
+// is not modified. Snapshot doesn't block on a frozen queue; see Freeze.
+func (c *Circular) Snapshot() []interface{} {
+	if items, ok := c.frozenView(c.snapshot); ok {
+		return items
+	}
 	c.Lock()
 	defer c.Unlock()
-	return cap(c.Items) - 1
+	return c.snapshot()
 }
 
-// Resize resizes a queue; zeroing out the slots.
+// snapshot is the unexported version of Snapshot; the caller must hold the
+// lock.
+func (c *Circular) snapshot() []interface{} {
+	items := make([]interface{}, 0, c.plen())
+	for i := c.head; i != c.tail; i = c.inc(i) {
+		items = append(items, c.items[i])
+	}
+	return items
+}
+
+// Resize resizes the queue, keeping its items, in order. The new capacity
+// is size, but never less than the capacity the queue was created with, or
+// the number of items in the queue. The queue's new capacity is returned.
 func (c *Circular) Resize(size int) int {
 	c.Lock()
-	if ((size + 1) == c.InitCap) || (size == 0 && cap(c.Items) == c.InitCap) {
+	if size < c.initCap-1 {
+		size = c.initCap - 1
+	}
+	if l := c.plen(); size < l {
+		size = l
+	}
+	if size+1 == cap(c.items) {
 		c.Unlock()
 		return size
 	}
-	// tmp slice of remaining Items
-	tmp := make([]interface{}, 0, c.plen())
-	for i := 0; i < cap(tmp); i++ {
-		tmp = append(tmp, c.Items[c.Head:c.Head])
-		c.Head = int(math.Mod(float64(c.Head+1), float64(cap(c.Items))))
+	c.recap(size)
+	c.Unlock()
+	c.emit(EventResize, nil)
+	return size
+}
+
+// setCap sets the queue's capacity to exactly n, keeping its items, in
+// order; unlike Resize, there is no minimum capacity. If n is less than the
+// number of items in the queue, the capacity is set to the number of items.
+// The new capacity is returned.
+func (c *Circular) setCap(n int) int {
+	c.Lock()
+	if l := c.plen(); n < l {
+		n = l
+	}
+	if n < 1 {
+		n = 1
 	}
-	c.Items = tmp
-	c.Head = 0
-	c.Tail = len(tmp)
+	c.recap(n)
 	c.Unlock()
-	x := c.Queue.Resize(size + 1)
+	c.emit(EventResize, nil)
+	return n
+}
+
+// SetCap grows or shrinks the queue, in place, to a capacity of exactly n,
+// keeping its items, in order. Unlike Resize, there is no minimum capacity
+// and the capacity is never adjusted: if n is less than 1, or less than the
+// number of items in the queue, an error is returned and the queue is not
+// changed. Goroutines blocked on a full queue are woken if it grew.
+func (c *Circular) SetCap(n int) error {
 	c.Lock()
-	_ = c.zeroQueue()
+	if n < 1 {
+		c.Unlock()
+		return fmt.Errorf("cannot set cap to %d: cap must be at least 1", n)
+	}
+	if l := c.plen(); n < l {
+		c.Unlock()
+		return fmt.Errorf("cannot set cap to %d: queue has %d items", n, l)
+	}
+	c.recap(n)
 	c.Unlock()
-	return x
+	c.emit(EventResize, nil)
+	return nil
+}
+
+// recap replaces the queue's slice with one with a capacity of n, copying its
+// items to the front. The caller must hold the lock and n must be at least
+// the number of items in the queue.
+func (c *Circular) recap(n int) {
+	items := c.snapshot()
+	c.items = make([]interface{}, n+1)
+	copy(c.items, items)
+	c.head = 0
+	c.tail = len(items)
+	c.publish()
 }
 
 // Reset resets a queue, zeroing out the remaining slots.
 func (c *Circular) Reset() {
-	c.Queue.Reset()
 	c.Lock()
-	c.Tail = 0
+	c.reset()
+	c.tail = 0
 	_ = c.zeroQueue()
+	c.publish()
 	c.Unlock()
+	c.emit(EventReset, nil)
+}
 
+// Clear removes all of the items from the queue and releases the queue's
+// references to them; it is the same as Reset.
+func (c *Circular) Clear() {
+	c.Reset()
 }
 
 // zeroQueue appends the zero value to the queue unti the queue is at cap.
@@ -170,8 +332,8 @@ func (c *Circular) Reset() {
 // the enqueue operation..
 func (c *Circular) zeroQueue() int {
 	var x int
-	for i := len(c.Items); i < cap(c.Items); i++ {
-		c.Items = append(c.Items, nil)
+	for i := len(c.items); i < cap(c.items); i++ {
+		c.items = append(c.items, nil)
 		x++
 	}
 	return x
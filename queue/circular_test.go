@@ -1,7 +1,9 @@
 package queue
 
 import (
+	"math"
 	"testing"
+	"time"
 )
 
 func TestCircular(t *testing.T) {
@@ -40,11 +42,11 @@ func TestCircular(t *testing.T) {
 		for _, v := range test.items {
 			_ = cq.Enqueue(v)
 		}
-		if cq.Head != test.initHead {
-			t.Errorf("%d initial: expected Head to be %d, got %d", i, test.initHead, cq.Head)
+		if cq.head != test.initHead {
+			t.Errorf("%d initial: expected Head to be %d, got %d", i, test.initHead, cq.head)
 		}
-		if cq.Tail != test.initTail {
-			t.Errorf("%d initial: expected Tail to be %d, got %d", i, test.initTail, cq.Tail)
+		if cq.tail != test.initTail {
+			t.Errorf("%d initial: expected Tail to be %d, got %d", i, test.initTail, cq.tail)
 		}
 		if cq.IsEmpty() != test.initIsEmpty {
 			t.Errorf("%d initial: expected isEmpty to be %t, got %t", i, test.initIsEmpty, cq.IsEmpty())
@@ -61,11 +63,11 @@ func TestCircular(t *testing.T) {
 				t.Errorf("%d: dequeue item %d: expected %v got %v", i, j, v, val)
 			}
 		}
-		if cq.Head != test.dequeueHead {
-			t.Errorf("%d dequeue: expected Head to be %d, got %d", i, test.dequeueHead, cq.Head)
+		if cq.head != test.dequeueHead {
+			t.Errorf("%d dequeue: expected Head to be %d, got %d", i, test.dequeueHead, cq.head)
 		}
-		if cq.Tail != test.dequeueTail {
-			t.Errorf("%d dequeue: expected Tail to be %d, got %d", i, test.dequeueTail, cq.Tail)
+		if cq.tail != test.dequeueTail {
+			t.Errorf("%d dequeue: expected Tail to be %d, got %d", i, test.dequeueTail, cq.tail)
 		}
 		if cq.IsEmpty() != test.dequeueIsEmpty {
 			t.Errorf("%d dequeue: expected isEmpty to be %t, got %t", i, test.dequeueIsEmpty, cq.IsEmpty())
@@ -88,11 +90,11 @@ func TestCircular(t *testing.T) {
 		if err == nil && test.err != "" {
 			t.Errorf("%d enqueue: expected error an error: %q, got none", i, test.err)
 		}
-		if cq.Head != test.enqueueHead {
-			t.Errorf("%d enqueue: expected Head to be %d, got %d", i, test.enqueueHead, cq.Head)
+		if cq.head != test.enqueueHead {
+			t.Errorf("%d enqueue: expected Head to be %d, got %d", i, test.enqueueHead, cq.head)
 		}
-		if cq.Tail != test.enqueueTail {
-			t.Errorf("%d enqueue: expected Tail to be %d, got %d", i, test.enqueueTail, cq.Tail)
+		if cq.tail != test.enqueueTail {
+			t.Errorf("%d enqueue: expected Tail to be %d, got %d", i, test.enqueueTail, cq.tail)
 		}
 		if cq.IsEmpty() != test.enqueueIsEmpty {
 			t.Errorf("%d enqueue: expected isEmpty to be %t, got %t", i, test.enqueueIsEmpty, cq.IsEmpty())
@@ -171,11 +173,11 @@ func TestCircularResetResize(t *testing.T) {
 			t.Errorf("%d: expected enqueue error to bet %q, got nil", i, test.enqueueErr)
 		}
 		q.Resize(test.resize)
-		if q.Head != test.resizeHead {
-			t.Errorf("%d: post resize, expected head pos to be %d, got %d", i, test.resizeHead, q.Head)
+		if q.head != test.resizeHead {
+			t.Errorf("%d: post resize, expected head pos to be %d, got %d", i, test.resizeHead, q.head)
 		}
-		if q.Tail != test.resizeTail {
-			t.Errorf("%d: post resize, expected tail pos to be %d, got %d", i, test.resizeTail, q.Tail)
+		if q.tail != test.resizeTail {
+			t.Errorf("%d: post resize, expected tail pos to be %d, got %d", i, test.resizeTail, q.tail)
 		}
 		if q.Len() != test.resizeLen {
 			t.Errorf("%d: post resize, expected len to be %d, got %d", i, test.resizeLen, q.Len())
@@ -184,11 +186,11 @@ func TestCircularResetResize(t *testing.T) {
 			t.Errorf("%d: post resize, expected cap to be %d, got %d", i, test.resizeCap, q.Cap())
 		}
 		q.Reset()
-		if q.Head != test.resetHead {
-			t.Errorf("%d: post reset, expected head pos to be %d, got %d", i, test.resetHead, q.Head)
+		if q.head != test.resetHead {
+			t.Errorf("%d: post reset, expected head pos to be %d, got %d", i, test.resetHead, q.head)
 		}
-		if q.Tail != test.resetTail {
-			t.Errorf("%d: post reset, expected tail pos to be %d, got %d", i, test.resetTail, q.Tail)
+		if q.tail != test.resetTail {
+			t.Errorf("%d: post reset, expected tail pos to be %d, got %d", i, test.resetTail, q.tail)
 		}
 		if q.Len() != test.resetLen {
 			t.Errorf("%d: post reset, expected len to be %d, got %d", i, test.resetLen, q.Len())
@@ -199,3 +201,346 @@ func TestCircularResetResize(t *testing.T) {
 
 	}
 }
+
+// Len, IsEmpty, IsFull, and Peek on an empty queue must not take the lock.
+func TestCircularLockFreeAccessors(t *testing.T) {
+	c := NewCircular(2)
+	c.Lock()
+	if !c.IsEmpty() || c.IsFull() || c.Len() != 0 {
+		t.Errorf("expected empty queue, got IsEmpty %t, IsFull %t, Len %d", c.IsEmpty(), c.IsFull(), c.Len())
+	}
+	if _, ok := c.Peek(); ok {
+		t.Error("expected peek of an empty queue to return false")
+	}
+	c.Unlock()
+	for i := 0; i < 3; i++ {
+		_ = c.Enqueue(i)
+	}
+	c.Dequeue()
+	_ = c.Enqueue(3)
+	c.Lock()
+	if c.IsEmpty() || !c.IsFull() || c.Len() != 2 {
+		t.Errorf("expected a full queue, got IsEmpty %t, IsFull %t, Len %d", c.IsEmpty(), c.IsFull(), c.Len())
+	}
+	c.Unlock()
+	c.Reset()
+	if c.Len() != 0 {
+		t.Errorf("expected len to be 0 after reset, got %d", c.Len())
+	}
+}
+
+// FuzzCircular interprets its input as a sequence of operations on a
+// Circular queue and checks the queue against a slice based model after each
+// one. The first byte is the queue's size.
+func FuzzCircular(f *testing.F) {
+	f.Add([]byte{2, 0, 0, 0, 1, 0, 1, 1, 1})
+	f.Add([]byte{1, 0, 1, 0, 1, 0, 2, 3, 0})
+	f.Add([]byte{4, 0, 0, 0, 0, 1, 1, 0, 0, 0, 2, 1, 1, 1, 1})
+	// grow with SetCap, fill, then Resize below the number of items.
+	f.Add([]byte{3, 65, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 16, 1, 0, 0})
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		if len(ops) == 0 {
+			return
+		}
+		size := int(ops[0]%16) + 1
+		c := NewCircular(size)
+		cp := size // the model's capacity.
+		var model []int
+		for i, op := range ops[1:] {
+			// the resize ops take their size from the rest of the op.
+			n := int(op/6) % 16
+			switch op % 6 {
+			case 0:
+				err := c.Enqueue(i)
+				if len(model) == cp {
+					if err == nil {
+						t.Fatalf("op %d: expected enqueue onto a full queue to fail", i)
+					}
+					break
+				}
+				if err != nil {
+					t.Fatalf("op %d: unexpected error: %s", i, err)
+				}
+				model = append(model, i)
+			case 1:
+				v, ok := c.Dequeue()
+				if ok != (len(model) > 0) {
+					t.Fatalf("op %d: expected dequeue to return %t, got %t", i, len(model) > 0, ok)
+				}
+				if ok {
+					if v != model[0] {
+						t.Fatalf("op %d: expected %d, got %v", i, model[0], v)
+					}
+					model = model[1:]
+				}
+			case 2:
+				v, ok := c.Peek()
+				if ok != (len(model) > 0) {
+					t.Fatalf("op %d: expected peek to return %t, got %t", i, len(model) > 0, ok)
+				}
+				if ok && v != model[0] {
+					t.Fatalf("op %d: expected %d, got %v", i, model[0], v)
+				}
+			case 3:
+				c.Reset()
+				model = model[:0]
+			case 4:
+				cp = max(n, size, len(model))
+				if got := c.Resize(n); got != cp {
+					t.Fatalf("op %d: expected Resize(%d) to return %d, got %d", i, n, cp, got)
+				}
+			case 5:
+				err := c.SetCap(n)
+				if n < 1 || n < len(model) {
+					if err == nil {
+						t.Fatalf("op %d: expected SetCap(%d) with %d items to fail", i, n, len(model))
+					}
+					break
+				}
+				if err != nil {
+					t.Fatalf("op %d: unexpected error: %s", i, err)
+				}
+				cp = n
+			}
+			if c.Cap() != cp {
+				t.Fatalf("op %d: expected cap to be %d, got %d", i, cp, c.Cap())
+			}
+			if c.Len() != len(model) {
+				t.Fatalf("op %d: expected len to be %d, got %d", i, len(model), c.Len())
+			}
+			if c.IsEmpty() != (len(model) == 0) || c.IsFull() != (len(model) == cp) {
+				t.Fatalf("op %d: expected IsEmpty %t and IsFull %t, got %t and %t", i, len(model) == 0, len(model) == cp, c.IsEmpty(), c.IsFull())
+			}
+			if c.head < 0 || c.head > cp || c.tail < 0 || c.tail > cp {
+				t.Fatalf("op %d: head %d or tail %d out of bounds for cap %d", i, c.head, c.tail, cp)
+			}
+		}
+	})
+}
+
+func TestCircularPositionsSnapshot(t *testing.T) {
+	c := NewCircular(3)
+	if s := c.Snapshot(); len(s) != 0 {
+		t.Errorf("expected an empty snapshot, got %v", s)
+	}
+	for i := 0; i < 3; i++ {
+		_ = c.Enqueue(i)
+	}
+	c.Dequeue()
+	c.Dequeue()
+	_ = c.Enqueue(3)
+	_ = c.Enqueue(4)
+	head, tail := c.Positions()
+	if head != 2 || tail != 1 {
+		t.Errorf("expected head 2 and tail 1, got %d and %d", head, tail)
+	}
+	s := c.Snapshot()
+	expected := []interface{}{2, 3, 4}
+	if len(s) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, s)
+	}
+	for i, v := range expected {
+		if s[i] != v {
+			t.Errorf("%d: expected %v, got %v", i, v, s[i])
+		}
+	}
+	// modifying the snapshot must not modify the queue.
+	s[0] = 42
+	if v, _ := c.Peek(); v != 2 {
+		t.Errorf("expected 2, got %v", v)
+	}
+}
+
+func BenchmarkCircularEnqueueDequeue(b *testing.B) {
+	c := NewCircular(1024)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = c.Enqueue(payload)
+		c.Dequeue()
+	}
+}
+
+func BenchmarkCircularFillDrain(b *testing.B) {
+	c := NewCircular(1024)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for !c.IsFull() {
+			_ = c.Enqueue(payload)
+		}
+		for !c.IsEmpty() {
+			c.Dequeue()
+		}
+	}
+}
+
+// wrapSink keeps the wrap benchmarks' results live.
+var wrapSink int
+
+// BenchmarkWrapMod is the index arithmetic Circular used to do, for comparison
+// with BenchmarkWrapInc.
+func BenchmarkWrapMod(b *testing.B) {
+	var i int
+	n := 1025
+	for j := 0; j < b.N; j++ {
+		i = int(math.Mod(float64(i+1), float64(n)))
+	}
+	wrapSink = i
+}
+
+func BenchmarkWrapInc(b *testing.B) {
+	c := NewCircular(1024)
+	var i int
+	for j := 0; j < b.N; j++ {
+		i = c.inc(i)
+	}
+	wrapSink = i
+}
+
+func TestCircularResizeKeepsItems(t *testing.T) {
+	q := NewCircular(4)
+	for i := 0; i < 4; i++ {
+		q.Enqueue(i)
+	}
+	q.Dequeue()
+	q.Enqueue(4)
+	q.Resize(8)
+	for i := 1; i < 5; i++ {
+		if v, ok := q.Dequeue(); !ok || v != i {
+			t.Errorf("expected %d true, got %v %t", i, v, ok)
+		}
+	}
+}
+
+func TestCircularSetCap(t *testing.T) {
+	tests := []struct {
+		n   int
+		err string
+	}{
+		{0, "cannot set cap to 0: cap must be at least 1"},
+		{2, "cannot set cap to 2: queue has 3 items"},
+		{3, ""},
+		{5, ""},
+	}
+	for _, test := range tests {
+		q := NewCircular(4)
+		for i := 0; i < 4; i++ {
+			q.Enqueue(i)
+		}
+		q.Dequeue()
+		q.Dequeue()
+		q.Enqueue(4) // wrapped
+		err := q.SetCap(test.n)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%d: expected error %q, got %v", test.n, test.err, err)
+			}
+			if q.Cap() != 4 || q.Len() != 3 {
+				t.Errorf("%d: expected a failed SetCap not to change the queue, got len %d cap %d", test.n, q.Len(), q.Cap())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%d: unexpected error: %s", test.n, err)
+			continue
+		}
+		if q.Cap() != test.n {
+			t.Errorf("%d: expected cap %d, got %d", test.n, test.n, q.Cap())
+		}
+		if h, tl := q.Positions(); h != 0 || tl != 3 {
+			t.Errorf("%d: expected positions 0 3, got %d %d", test.n, h, tl)
+		}
+		if got := q.Snapshot(); len(got) != 3 || got[0] != 2 || got[1] != 3 || got[2] != 4 {
+			t.Errorf("%d: expected [2 3 4], got %v", test.n, got)
+		}
+		if full := test.n == 3; q.IsFull() != full {
+			t.Errorf("%d: expected IsFull %t, got %t", test.n, full, q.IsFull())
+		}
+	}
+}
+
+func TestCircularSetCapWakesEnqueue(t *testing.T) {
+	q := NewCircular(1)
+	q.Enqueue(0)
+	done := make(chan error)
+	go func() { done <- q.EnqueueWait(1, time.Second) }()
+	time.Sleep(10 * time.Millisecond)
+	if err := q.SetCap(2); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("expected the waiting enqueue to succeed, got %s", err)
+	}
+	if q.Len() != 2 {
+		t.Errorf("expected len 2, got %d", q.Len())
+	}
+}
+
+func TestCircularResizeCap(t *testing.T) {
+	tests := []struct {
+		size   int
+		resize int
+		cap    int
+	}{
+		{4, 4, 4},
+		{4, 0, 4},
+		{4, 8, 8},
+		{8, 2, 8},
+	}
+	for i, test := range tests {
+		c := NewCircular(test.size)
+		if c.Cap() != test.size {
+			t.Errorf("%d: expected cap to be %d, got %d", i, test.size, c.Cap())
+		}
+		if n := c.Resize(test.resize); n != test.cap {
+			t.Errorf("%d: expected Resize to return %d, got %d", i, test.cap, n)
+		}
+		if c.Cap() != test.cap {
+			t.Errorf("%d: after Resize, expected cap to be %d, got %d", i, test.cap, c.Cap())
+		}
+		for j := 0; j < test.cap; j++ {
+			if err := c.Enqueue(j); err != nil {
+				t.Errorf("%d: enqueue %d: unexpected error: %s", i, j, err)
+			}
+		}
+		if !c.IsFull() {
+			t.Errorf("%d: expected the queue to be full with %d items", i, test.cap)
+		}
+	}
+}
+
+func TestCircularSetCapResize(t *testing.T) {
+	c := NewCircular(4)
+	if err := c.SetCap(8); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	// resizing back to the original size must resize, not compare against
+	// the original capacity.
+	if n := c.Resize(4); n != 4 || c.Cap() != 4 {
+		t.Errorf("expected Resize to set the cap to 4, got %d %d", n, c.Cap())
+	}
+	if err := c.SetCap(6); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if n := c.Resize(6); n != 6 || c.Cap() != 6 {
+		t.Errorf("expected Resize to the current cap to return 6, got %d %d", n, c.Cap())
+	}
+	// Resize never shrinks the queue below its items.
+	if err := c.SetCap(10); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for i := 0; i < 10; i++ {
+		_ = c.Enqueue(i)
+	}
+	if n := c.Resize(2); n != 10 || c.Cap() != 10 || c.Len() != 10 {
+		t.Errorf("expected Resize to keep a cap of 10 with 10 items, got %d %d %d", n, c.Cap(), c.Len())
+	}
+	c.Dequeue()
+	if err := c.Enqueue(10); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	for i := 1; i <= 10; i++ {
+		if v, ok := c.Dequeue(); !ok || v != i {
+			t.Errorf("expected %d true, got %v %t", i, v, ok)
+		}
+	}
+}
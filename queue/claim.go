@@ -0,0 +1,87 @@
+package queue
+
+// Claim claims the item at the head of the queue so that it can be processed
+// speculatively: the item stays in the queue, and can be seen by Peek, until
+// the claim is committed or aborted. While the head is claimed nothing can be
+// dequeued, so the claimed item keeps its place in the queue; Commit removes
+// it and Abort leaves it at the head for a retry. This gives a consumer
+// at-least-once processing of the queue's items.
+//
+// If the queue is empty, or its head has already been claimed, a false will
+// be returned. A claim is held by a single consumer: only the consumer that
+// claimed the item should commit or abort it. Resetting the queue, cancelling
+// the head's tag, or, for a circular queue, the head being evicted by the
+// OverflowDropOldest policy, ends the claim without removing another item;
+// Commit and Abort then return false.
+func (q *Queue) Claim() (interface{}, bool) {
+	q.Lock()
+	defer q.Unlock()
+	if q.claimed || q.isEmpty() {
+		return nil, false
+	}
+	q.claimed = true
+	return q.items[q.head], true
+}
+
+// Commit removes the claimed item from the queue. If there is no claim, a
+// false will be returned.
+func (q *Queue) Commit() bool {
+	q.Lock()
+	if !q.claimed {
+		q.Unlock()
+		return false
+	}
+	q.claimed = false
+	item, shrunk, _ := q.dequeue()
+	q.Unlock()
+	q.emitDequeue(item, shrunk)
+	return true
+}
+
+// Abort releases the claim, leaving the claimed item at the head of the
+// queue. If there is no claim, a false will be returned.
+func (q *Queue) Abort() bool {
+	q.Lock()
+	defer q.Unlock()
+	if !q.claimed {
+		return false
+	}
+	q.claimed = false
+	// the head can be dequeued again.
+	q.broadcast()
+	return true
+}
+
+// Claimed returns whether or not the head of the queue is claimed.
+func (q *Queue) Claimed() bool {
+	q.Lock()
+	defer q.Unlock()
+	return q.claimed
+}
+
+// Claim claims the item at the head of the queue; see Queue.Claim.
+func (c *Circular) Claim() (interface{}, bool) {
+	c.Lock()
+	defer c.Unlock()
+	if c.claimed {
+		return nil, false
+	}
+	item, ok := c.peek()
+	c.claimed = ok
+	return item, ok
+}
+
+// Commit removes the claimed item from the queue. If there is no claim, a
+// false will be returned.
+func (c *Circular) Commit() bool {
+	c.Lock()
+	if !c.claimed {
+		c.Unlock()
+		return false
+	}
+	c.claimed = false
+	item, _ := c.dequeue()
+	c.Unlock()
+	c.emit(EventDequeue, item)
+	return true
+}
@@ -0,0 +1,121 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// claimQueue is the claim API shared by Queue and Circular.
+type claimQueue interface {
+	Enqueue(interface{}) error
+	Dequeue() (interface{}, bool)
+	DequeueN(int) []interface{}
+	Len() int
+	Claim() (interface{}, bool)
+	Commit() bool
+	Abort() bool
+	Claimed() bool
+	Reset()
+}
+
+func TestClaim(t *testing.T) {
+	tests := []struct {
+		name string
+		q    claimQueue
+	}{
+		{"queue", NewQueue(2)},
+		{"circular", NewCircular(4)},
+	}
+	for _, test := range tests {
+		q := test.q
+		if _, ok := q.Claim(); ok {
+			t.Errorf("%s: expected claiming an empty queue to fail", test.name)
+		}
+		if q.Commit() || q.Abort() {
+			t.Errorf("%s: expected commit and abort without a claim to fail", test.name)
+		}
+		for i := 0; i < 3; i++ {
+			q.Enqueue(i)
+		}
+		v, ok := q.Claim()
+		if !ok || v != 0 || !q.Claimed() {
+			t.Fatalf("%s: expected 0 true, got %v %t", test.name, v, ok)
+		}
+		if _, ok := q.Claim(); ok {
+			t.Errorf("%s: expected a second claim to fail", test.name)
+		}
+		// the claimed head can't be dequeued.
+		if v, ok := q.Dequeue(); ok {
+			t.Errorf("%s: expected dequeue of a claimed head to fail, got %v", test.name, v)
+		}
+		if items := q.DequeueN(2); items != nil {
+			t.Errorf("%s: expected DequeueN of a claimed head to fail, got %v", test.name, items)
+		}
+		// abort leaves the item for a retry.
+		if !q.Abort() || q.Claimed() || q.Len() != 3 {
+			t.Errorf("%s: expected abort to leave the 3 items, got %d", test.name, q.Len())
+		}
+		if v, _ := q.Claim(); v != 0 {
+			t.Errorf("%s: expected to reclaim 0, got %v", test.name, v)
+		}
+		// commit removes it.
+		if !q.Commit() || q.Len() != 2 {
+			t.Errorf("%s: expected commit to remove the item, got len %d", test.name, q.Len())
+		}
+		if v, ok := q.Dequeue(); !ok || v != 1 {
+			t.Errorf("%s: expected 1 true, got %v %t", test.name, v, ok)
+		}
+		// a reset ends the claim.
+		q.Claim()
+		q.Reset()
+		if q.Claimed() || q.Commit() {
+			t.Errorf("%s: expected reset to end the claim", test.name)
+		}
+	}
+}
+
+func TestClaimDequeueCtx(t *testing.T) {
+	q := NewQueue(2)
+	q.Enqueue(1)
+	q.Claim()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.Abort()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	// the waiter is woken when the claim is aborted.
+	if v, err := q.DequeueCtx(ctx); err != nil || v != 1 {
+		t.Errorf("expected 1 nil, got %v %v", v, err)
+	}
+}
+
+func TestClaimEvicted(t *testing.T) {
+	c := NewCircularWithPolicy(2, OverflowDropOldest)
+	c.Enqueue(1)
+	c.Enqueue(2)
+	c.Claim()
+	c.Enqueue(3)
+	if c.Claimed() || c.Commit() {
+		t.Error("expected evicting the head to end its claim")
+	}
+	if items := c.Snapshot(); len(items) != 2 || items[0] != 2 || items[1] != 3 {
+		t.Errorf("expected [2 3], got %v", items)
+	}
+}
+
+func TestClaimCancelTag(t *testing.T) {
+	q := NewQueue(2)
+	q.Enqueue(job{"a", 1})
+	q.Enqueue(job{"b", 2})
+	q.Claim()
+	q.CancelTag("b")
+	if !q.Claimed() {
+		t.Error("expected cancelling another tag to keep the claim")
+	}
+	q.CancelTag("a")
+	if q.Claimed() || q.Len() != 0 {
+		t.Errorf("expected cancelling the head's tag to end the claim, got len %d", q.Len())
+	}
+}
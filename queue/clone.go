@@ -0,0 +1,160 @@
+package queue
+
+import (
+	"fmt"
+	"math"
+	"unsafe"
+)
+
+// Clone returns a copy of the queue: a new queue with the same items, in the
+// same order, capacity, and settings, i.e. its shift percent, shrink
+// threshold, Sizer, PanicHandler, and whether it counts Stats. The items
+// themselves aren't copied. The clone doesn't share the queue's Bus, hooks,
+// taps, watermarks, stats counts, claim, reservations, or cancelled tags.
+func (q *Queue) Clone() *Queue {
+	q.Lock()
+	defer q.Unlock()
+	clone := &Queue{initCap: q.initCap, shiftPercent: q.shiftPercent}
+	clone.items = append(make([]interface{}, 0, cap(q.items)), q.items[q.head:]...)
+	q.cloneSettings(clone)
+	clone.publish()
+	return clone
+}
+
+// cloneSettings copies the queue's settings to clone. The caller must hold the
+// lock.
+func (q *Queue) cloneSettings(clone *Queue) {
+	clone.shrinkPercent = q.shrinkPercent
+	clone.stats.on.Store(q.stats.on.Load())
+	clone.sizer = q.sizer
+	clone.panics.Store(q.panics.Load())
+	clone.bytes.Store(q.bytes.Load())
+}
+
+// Clone returns a copy of the queue, including its overflow policy; see
+// Queue.Clone.
+func (c *Circular) Clone() *Circular {
+	c.Lock()
+	defer c.Unlock()
+	clone := &Circular{policy: c.policy}
+	clone.initCap = c.initCap
+	clone.shiftPercent = c.shiftPercent
+	items := c.snapshot()
+	clone.items = make([]interface{}, cap(c.items))
+	copy(clone.items, items)
+	clone.tail = len(items)
+	c.cloneSettings(&clone.Queue)
+	clone.publish()
+	return clone
+}
+
+// transferer is implemented by the queues Append and Merge support.
+type transferer interface {
+	atomicEnqueuer
+	room() int               // the number of items that fit; the caller must hold the lock.
+	contents() []interface{} // a copy of the items; the caller must hold the lock.
+	empty()                  // removes every item; the caller must hold the lock.
+}
+
+// Append adds a copy of other's items to the end of the queue, in order;
+// other isn't changed. Both queues are locked, in a fixed order so that
+// concurrent Appends and Merges can't deadlock, so no items are enqueued or
+// dequeued in the middle. other must be a Queue or a Circular; otherwise an
+// error is returned.
+func (q *Queue) Append(other Queuer) error {
+	return transfer(q, other, false)
+}
+
+// Merge moves other's items to the end of the queue, in order, leaving other
+// empty; see Append.
+func (q *Queue) Merge(other Queuer) error {
+	return transfer(q, other, true)
+}
+
+// Append adds a copy of other's items to the end of the queue; see
+// Queue.Append. If they don't all fit, an error is returned and neither queue
+// is changed.
+func (c *Circular) Append(other Queuer) error {
+	return transfer(c, other, false)
+}
+
+// Merge moves other's items to the end of the queue, leaving other empty; see
+// Queue.Merge. If they don't all fit, an error is returned and neither queue
+// is changed.
+func (c *Circular) Merge(other Queuer) error {
+	return transfer(c, other, true)
+}
+
+// transfer appends src's items to dst under both locks; if move is true src
+// is emptied.
+func transfer(dst transferer, other Queuer, move bool) error {
+	src, ok := other.(transferer)
+	if !ok {
+		return fmt.Errorf("cannot transfer the items of a %T", other)
+	}
+	a, b := dst.base(), src.base()
+	if a == b {
+		return fmt.Errorf("cannot transfer the items of a queue to itself")
+	}
+	if uintptr(unsafe.Pointer(b)) < uintptr(unsafe.Pointer(a)) {
+		a, b = b, a
+	}
+	a.Lock()
+	b.Lock()
+	items := src.contents()
+	if room := dst.room(); len(items) > room {
+		b.Unlock()
+		a.Unlock()
+		return fmt.Errorf("queue full: cannot transfer %d items to a queue with room for %d", len(items), room)
+	}
+	for _, item := range items {
+		dst.put(item)
+	}
+	if move {
+		src.empty()
+	}
+	b.Unlock()
+	a.Unlock()
+	if move {
+		src.base().emit(EventReset, nil)
+	}
+	for _, item := range items {
+		dst.base().emit(EventEnqueue, item)
+	}
+	return nil
+}
+
+// room returns the number of items that fit: an unbounded queue always has
+// room.
+func (q *Queue) room() int {
+	return math.MaxInt
+}
+
+// contents returns a copy of the items in the queue; the caller must hold
+// the lock.
+func (q *Queue) contents() []interface{} {
+	return append([]interface{}(nil), q.items[q.head:]...)
+}
+
+// empty removes every item from the queue; the caller must hold the lock.
+func (q *Queue) empty() {
+	q.reset()
+	q.publish()
+}
+
+// room returns the number of items that fit in the queue; the caller must
+// hold the lock.
+func (c *Circular) room() int {
+	return cap(c.items) - 1 - c.plen()
+}
+
+// contents returns a copy of the items in the queue; the caller must hold
+// the lock.
+func (c *Circular) contents() []interface{} {
+	return c.snapshot()
+}
+
+// empty removes every item from the queue; the caller must hold the lock.
+func (c *Circular) empty() {
+	c.load(nil)
+}
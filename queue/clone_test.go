@@ -0,0 +1,97 @@
+package queue
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	q := NewQueue(4)
+	q.SetShrinkThreshold(10)
+	for i := 0; i < 3; i++ {
+		q.Enqueue(i)
+	}
+	q.Dequeue()
+	clone := q.Clone()
+	q.Enqueue(3)
+	if s := clone.Snapshot(); !reflect.DeepEqual(s, []interface{}{1, 2}) {
+		t.Errorf("expected the clone to have 1 and 2, got %v", s)
+	}
+	if clone.Len() != 2 || clone.Cap() != q.Cap() || clone.shrinkPercent != 10 {
+		t.Errorf("expected a len of 2, a cap of %d, and a shrink threshold of 10, got %d %d %d", q.Cap(), clone.Len(), clone.Cap(), clone.shrinkPercent)
+	}
+
+	c := NewCircularWithPolicy(3, OverflowDropOldest)
+	for i := 0; i < 5; i++ {
+		c.Enqueue(i)
+	}
+	cc := c.Clone()
+	c.Dequeue()
+	if s := cc.Snapshot(); !reflect.DeepEqual(s, []interface{}{2, 3, 4}) {
+		t.Errorf("expected the clone to have 2, 3, and 4, got %v", s)
+	}
+	if !cc.IsFull() || cc.Cap() != 3 {
+		t.Errorf("expected a full clone with a cap of 3, got %d of %d", cc.Len(), cc.Cap())
+	}
+	// the clone keeps the overflow policy.
+	cc.Enqueue(5)
+	if s := cc.Snapshot(); !reflect.DeepEqual(s, []interface{}{3, 4, 5}) {
+		t.Errorf("expected the oldest item to be dropped, got %v", s)
+	}
+}
+
+// opaque is a Queuer that hides its queue's unexported methods.
+type opaque struct {
+	Queuer
+}
+
+func TestAppendMerge(t *testing.T) {
+	shard := func(items ...interface{}) *Circular {
+		c := NewCircular(2)
+		for _, v := range items {
+			c.Enqueue(v)
+		}
+		return c
+	}
+	q := NewQueue(0)
+	q.Enqueue(0)
+	a, b := shard(1, 2), shard(3)
+	if err := q.Append(a); err != nil {
+		t.Errorf("expected no error, got %s", err)
+	}
+	if err := q.Merge(b); err != nil {
+		t.Errorf("expected no error, got %s", err)
+	}
+	if s := q.Snapshot(); !reflect.DeepEqual(s, []interface{}{0, 1, 2, 3}) {
+		t.Errorf("expected 0 through 3, got %v", s)
+	}
+	if a.Len() != 2 || !b.IsEmpty() {
+		t.Errorf("expected Append to leave its source alone and Merge to empty it, got %d %d", a.Len(), b.Len())
+	}
+
+	c := shard(4)
+	if err := c.Merge(a); err == nil || err.Error() != "queue full: cannot transfer 2 items to a queue with room for 1" {
+		t.Errorf("expected a queue full error, got %v", err)
+	}
+	if c.Len() != 1 || a.Len() != 2 {
+		t.Errorf("expected a failed merge to leave both queues alone, got %d %d", c.Len(), a.Len())
+	}
+	if err := c.Merge(q); err == nil {
+		t.Error("expected a queue full error")
+	}
+	if err := a.Merge(c); err == nil {
+		t.Error("expected a queue full error")
+	}
+	if err := c.Append(shard(5)); err != nil {
+		t.Errorf("expected no error, got %s", err)
+	}
+	if s := c.Snapshot(); !reflect.DeepEqual(s, []interface{}{4, 5}) {
+		t.Errorf("expected 4 and 5, got %v", s)
+	}
+	if err := c.Append(c); err == nil || err.Error() != "cannot transfer the items of a queue to itself" {
+		t.Errorf("expected an error, got %v", err)
+	}
+	if err := q.Append(opaque{NewQueue(1)}); err == nil || err.Error() != "cannot transfer the items of a queue.opaque" {
+		t.Errorf("expected an error, got %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package queue
+
+import (
+	"sync"
+	"time"
+)
+
+// ConsumerStats are the delivery stats of one consumer, e.g. a Group's
+// Consumer or an Affinity's Member, so that slow or stuck consumers can be
+// found. Dequeued counts the items the consumer dequeued; the rest come from
+// the consumer reporting each item's handling with Done.
+type ConsumerStats struct {
+	Dequeued   uint64        // the number of items dequeued.
+	Done       uint64        // the number of items reported done.
+	Errors     uint64        // the number of items reported done with an error.
+	Latency    time.Duration // the mean latency of the items reported done.
+	MaxLatency time.Duration // the highest latency of the items reported done.
+	LastActive time.Time     // the time of the last dequeue or Done; zero if there hasn't been one.
+}
+
+// ErrorRate returns the fraction of the items reported done that had an
+// error; it is 0 if no items have been reported done.
+func (s ConsumerStats) ErrorRate() float64 {
+	if s.Done == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Done)
+}
+
+// tracker tracks a consumer's ConsumerStats.
+type tracker struct {
+	mu    sync.Mutex
+	stats ConsumerStats
+	total time.Duration // the total latency of the items reported done.
+}
+
+// dequeued counts a dequeue, if ok.
+func (t *tracker) dequeued(ok bool) {
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	t.stats.Dequeued++
+	t.stats.LastActive = time.Now()
+	t.mu.Unlock()
+}
+
+// done counts an item's handling.
+func (t *tracker) done(latency time.Duration, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stats.Done++
+	if err != nil {
+		t.stats.Errors++
+	}
+	t.total += latency
+	t.stats.Latency = t.total / time.Duration(t.stats.Done)
+	if latency > t.stats.MaxLatency {
+		t.stats.MaxLatency = latency
+	}
+	t.stats.LastActive = time.Now()
+}
+
+// get returns the current stats.
+func (t *tracker) get() ConsumerStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stats
+}
@@ -0,0 +1,60 @@
+package queue
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConsumerStats(t *testing.T) {
+	s := NewSharded(2, 4)
+	g := NewGroup(s)
+	c := g.Join()
+	idle := g.Join()
+	for i := 0; i < 4; i++ {
+		s.Enqueue(i)
+	}
+	for i := 0; i < 2; i++ {
+		if _, ok := c.Dequeue(); !ok {
+			t.Fatal("expected an item")
+		}
+	}
+	c.Done(10*time.Millisecond, nil)
+	c.Done(30*time.Millisecond, errors.New("failed"))
+
+	got := g.Stats()
+	if len(got) != 2 {
+		t.Fatalf("expected stats for 2 consumers, got %d", len(got))
+	}
+	st := got[0]
+	if st.Dequeued != 2 || st.Done != 2 || st.Errors != 1 {
+		t.Errorf("expected 2 dequeued, 2 done, 1 error, got %+v", st)
+	}
+	if st.Latency != 20*time.Millisecond || st.MaxLatency != 30*time.Millisecond {
+		t.Errorf("expected a mean latency of 20ms and a max of 30ms, got %s %s", st.Latency, st.MaxLatency)
+	}
+	if st.ErrorRate() != 0.5 {
+		t.Errorf("expected an error rate of 0.5, got %v", st.ErrorRate())
+	}
+	if time.Since(st.LastActive) > time.Second {
+		t.Errorf("expected a recent last activity, got %s", st.LastActive)
+	}
+	if st := idle.Stats(); st != (ConsumerStats{}) || st.ErrorRate() != 0 {
+		t.Errorf("expected no stats for the idle consumer, got %+v", st)
+	}
+}
+
+func TestMemberStats(t *testing.T) {
+	a := NewAffinity()
+	m, _ := a.Join("a")
+	a.Join("b")
+	a.Enqueue(1)
+	a.Enqueue(2)
+	m.Dequeue()
+	m.Dequeue() // the second item went to b.
+	m.Done(time.Millisecond, nil)
+	got := a.Stats()
+	if got["a"].Dequeued != 1 || got["a"].Done != 1 || got["b"].Dequeued != 0 {
+		t.Errorf("expected a to have dequeued and done 1 item, and b none, got %+v", got)
+	}
+}
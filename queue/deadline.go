@@ -0,0 +1,97 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Contexter is implemented by items that carry the context of the work they
+// are for, e.g. an incoming request's, so that the request's deadline and
+// cancellation survive the trip through the queue.
+type Contexter interface {
+	Context() context.Context
+}
+
+// ContextItem is an item with a context attached; see WithContext.
+type ContextItem struct {
+	Ctx  context.Context
+	Item interface{}
+}
+
+// Context returns the item's context.
+func (c ContextItem) Context() context.Context {
+	return c.Ctx
+}
+
+// WithContext attaches ctx to item, for items that don't implement Contexter
+// themselves. Consumers get the ContextItem back from the queue; the original
+// item is its Item.
+func WithContext(ctx context.Context, item interface{}) ContextItem {
+	return ContextItem{Ctx: ctx, Item: item}
+}
+
+// ItemContext returns item's context, if it is a Contexter, or the background
+// context, which is never done, if it isn't.
+func ItemContext(item interface{}) context.Context {
+	if c, ok := item.(Contexter); ok {
+		if ctx := c.Context(); ctx != nil {
+			return ctx
+		}
+	}
+	return context.Background()
+}
+
+// Expirer skips the items dequeued from a queue whose context is done, e.g.
+// because the request they were for timed out or was cancelled, so that
+// consumers don't spend time on work nobody is waiting for. Skipped items are
+// counted and passed to the expired func, if there is one.
+type Expirer struct {
+	q       Dequeuer
+	expired func(item interface{})
+	n       atomic.Uint64
+	mu      sync.Mutex // protects panics.
+	panics  PanicHandler
+}
+
+// NewExpirer returns an Expirer for the items dequeued from q; a nil expired
+// func is allowed.
+func NewExpirer(q Dequeuer, expired func(item interface{})) *Expirer {
+	return &Expirer{q: q, expired: expired}
+}
+
+// Dequeue returns the next item from the queue whose context isn't done,
+// skipping the ones whose context is. If the queue runs out of items, a false
+// will be returned.
+func (e *Expirer) Dequeue() (interface{}, bool) {
+	for {
+		item, ok := e.q.Dequeue()
+		if !ok {
+			return nil, false
+		}
+		if ItemContext(item).Err() == nil {
+			return item, true
+		}
+		e.n.Add(1)
+		if e.expired != nil {
+			e.mu.Lock()
+			h := e.panics
+			e.mu.Unlock()
+			protect(h, "expire", func() { e.expired(item) })
+		}
+	}
+}
+
+// Expired returns the number of items that have been skipped.
+func (e *Expirer) Expired() uint64 {
+	return e.n.Load()
+}
+
+// SetPanicHandler sets the handler that panics in the expired func are
+// reported to; with a handler, a panicking expired func is recovered and
+// Dequeue carries on. A nil handler, the default, doesn't recover panics.
+func (e *Expirer) SetPanicHandler(h PanicHandler) {
+	e.mu.Lock()
+	e.panics = h
+	e.mu.Unlock()
+}
@@ -0,0 +1,85 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type request struct {
+	id  int
+	ctx context.Context
+}
+
+func (r request) Context() context.Context { return r.ctx }
+
+func TestItemContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tests := []struct {
+		name string
+		item interface{}
+		ctx  context.Context
+	}{
+		{"plain", 1, context.Background()},
+		{"contexter", request{1, ctx}, ctx},
+		{"nil context", request{1, nil}, context.Background()},
+		{"with context", WithContext(ctx, 1), ctx},
+	}
+	for _, test := range tests {
+		if got := ItemContext(test.item); got != test.ctx {
+			t.Errorf("%s: expected %v, got %v", test.name, test.ctx, got)
+		}
+	}
+}
+
+func TestExpirer(t *testing.T) {
+	live := context.Background()
+	cancelled, cancel := context.WithCancel(live)
+	cancel()
+	past, cancel := context.WithDeadline(live, time.Now().Add(-time.Second))
+	defer cancel()
+
+	q := NewQueue(8)
+	q.Enqueue(request{0, cancelled})
+	q.Enqueue(request{1, live})
+	q.Enqueue(WithContext(past, 2))
+	q.Enqueue(3)
+	q.Enqueue(request{4, cancelled})
+
+	var expired []interface{}
+	e := NewExpirer(q, func(item interface{}) { expired = append(expired, item) })
+	v, ok := e.Dequeue()
+	if !ok || v.(request).id != 1 {
+		t.Errorf("expected request 1, got %v %t", v, ok)
+	}
+	if v, ok = e.Dequeue(); !ok || v != 3 {
+		t.Errorf("expected 3, got %v %t", v, ok)
+	}
+	if v, ok = e.Dequeue(); ok {
+		t.Errorf("expected no item, got %v", v)
+	}
+	if e.Expired() != 3 || len(expired) != 3 {
+		t.Fatalf("expected 3 expired items, got %d, %v", e.Expired(), expired)
+	}
+	if expired[1].(ContextItem).Item != 2 {
+		t.Errorf("expected the second expired item to be 2, got %v", expired[1])
+	}
+}
+
+func TestExpirerPanic(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	q := NewQueue(2)
+	q.Enqueue(WithContext(ctx, 0))
+	q.Enqueue(1)
+	e := NewExpirer(q, func(interface{}) { panic("boom") })
+	var callback string
+	e.SetPanicHandler(func(c string, _ interface{}) { callback = c })
+	if v, ok := e.Dequeue(); !ok || v != 1 {
+		t.Errorf("expected 1, got %v %t", v, ok)
+	}
+	if callback != "expire" {
+		t.Errorf("expected the expire callback's panic to be handled, got %q", callback)
+	}
+}
@@ -0,0 +1,171 @@
+package queue
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// delayed is an item in a Delay queue.
+type delayed struct {
+	item  interface{}
+	ready time.Time
+	seq   uint64 // orders items with the same ready time.
+}
+
+// delayHeap is a min heap of delayed items, by ready time.
+type delayHeap []delayed
+
+func (h delayHeap) Len() int { return len(h) }
+
+func (h delayHeap) Less(i, j int) bool {
+	if h[i].ready.Equal(h[j].ready) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].ready.Before(h[j].ready)
+}
+
+func (h delayHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *delayHeap) Push(x interface{}) { *h = append(*h, x.(delayed)) }
+
+func (h *delayHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	d := old[n-1]
+	old[n-1] = delayed{}
+	*h = old[:n-1]
+	return d
+}
+
+// Delay is an unbounded queue of items that each have a ready time: an item
+// can't be dequeued until its ready time has passed. Ready items are dequeued
+// in order of their ready times; items with the same ready time are dequeued
+// in the order they were enqueued. This is a building block for retry
+// schedulers and rate limited workers.
+type Delay struct {
+	mu      sync.Mutex
+	items   delayHeap
+	seq     uint64
+	changed chan struct{} // closed when an item is enqueued, if anyone is waiting.
+}
+
+// NewDelay returns an empty delay queue with an initial capacity equal to the
+// received size.
+func NewDelay(size int) *Delay {
+	if size < 0 {
+		size = 0
+	}
+	return &Delay{items: make(delayHeap, 0, size)}
+}
+
+// Enqueue adds an item to the queue that is ready at the received time.
+func (d *Delay) Enqueue(item interface{}, ready time.Time) {
+	d.mu.Lock()
+	heap.Push(&d.items, delayed{item: item, ready: ready, seq: d.seq})
+	d.seq++
+	if d.changed != nil {
+		close(d.changed)
+		d.changed = nil
+	}
+	d.mu.Unlock()
+}
+
+// EnqueueAfter adds an item to the queue that is ready once the received
+// duration has passed.
+func (d *Delay) EnqueueAfter(item interface{}, delay time.Duration) {
+	d.Enqueue(item, time.Now().Add(delay))
+}
+
+// Dequeue removes the next ready item from the queue and returns it. If no
+// item is ready, a false will be returned.
+func (d *Delay) Dequeue() (interface{}, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	item, ok, _ := d.dequeue(time.Now())
+	return item, ok
+}
+
+// dequeue removes the next item if it's ready at now. If it isn't, the time
+// the queue's next item is ready is returned; a zero time means the queue is
+// empty. The caller must hold the lock.
+func (d *Delay) dequeue(now time.Time) (interface{}, bool, time.Time) {
+	if len(d.items) == 0 {
+		return nil, false, time.Time{}
+	}
+	if next := d.items[0].ready; next.After(now) {
+		return nil, false, next
+	}
+	return heap.Pop(&d.items).(delayed).item, true, time.Time{}
+}
+
+// DequeueCtx removes the next ready item from the queue, blocking until an
+// item is ready or ctx is done. If ctx is done first, ctx's error is returned.
+func (d *Delay) DequeueCtx(ctx context.Context) (interface{}, error) {
+	for {
+		d.mu.Lock()
+		item, ok, next := d.dequeue(time.Now())
+		if ok {
+			d.mu.Unlock()
+			return item, nil
+		}
+		// wait for the next item to be ready or for an item to be enqueued:
+		// it may be ready sooner.
+		if d.changed == nil {
+			d.changed = make(chan struct{})
+		}
+		changed := d.changed
+		d.mu.Unlock()
+		var t *time.Timer
+		var ready <-chan time.Time
+		if !next.IsZero() {
+			t = time.NewTimer(time.Until(next))
+			ready = t.C
+		}
+		select {
+		case <-ctx.Done():
+		case <-changed:
+		case <-ready:
+		}
+		if t != nil {
+			t.Stop()
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Next returns the time the queue's next item is ready. If the queue is
+// empty, a false will be returned.
+func (d *Delay) Next() (time.Time, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.items) == 0 {
+		return time.Time{}, false
+	}
+	return d.items[0].ready, true
+}
+
+// IsEmpty returns whether or not the queue is empty, whether or not its items
+// are ready.
+func (d *Delay) IsEmpty() bool {
+	return d.Len() == 0
+}
+
+// Len returns the number of items in the queue, whether or not they are
+// ready.
+func (d *Delay) Len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.items)
+}
+
+// Reset removes all of the items from the queue.
+func (d *Delay) Reset() {
+	d.mu.Lock()
+	clear(d.items)
+	d.items = d.items[:0]
+	d.mu.Unlock()
+}
@@ -0,0 +1,79 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDelay(t *testing.T) {
+	now := time.Now()
+	d := NewDelay(2)
+	if _, ok := d.Next(); ok {
+		t.Error("expected an empty queue to have no next item")
+	}
+	tests := []struct {
+		item  string
+		ready time.Time
+	}{
+		{"later", now.Add(time.Hour)},
+		{"a", now.Add(-time.Second)},
+		{"b", now.Add(-time.Minute)},
+		{"c", now.Add(-time.Second)},
+		{"d", now.Add(-time.Second)},
+	}
+	for _, test := range tests {
+		d.Enqueue(test.item, test.ready)
+	}
+	if d.Len() != 5 {
+		t.Errorf("expected len 5, got %d", d.Len())
+	}
+	if next, ok := d.Next(); !ok || !next.Equal(now.Add(-time.Minute)) {
+		t.Errorf("expected the next item to be b's, got %s %t", next, ok)
+	}
+	// ready items come out by ready time, in FIFO order among equals.
+	for _, expected := range []string{"b", "a", "c", "d"} {
+		if v, ok := d.Dequeue(); !ok || v != expected {
+			t.Errorf("expected %s true, got %v %t", expected, v, ok)
+		}
+	}
+	if v, ok := d.Dequeue(); ok {
+		t.Errorf("expected an item that isn't ready not to be dequeued, got %v", v)
+	}
+	if d.IsEmpty() {
+		t.Error("expected the queue not to be empty")
+	}
+	d.Reset()
+	if !d.IsEmpty() {
+		t.Errorf("expected reset to empty the queue, got %d", d.Len())
+	}
+}
+
+func TestDelayDequeueCtx(t *testing.T) {
+	d := NewDelay(0)
+	d.EnqueueAfter("slow", time.Hour)
+	d.EnqueueAfter("fast", 20*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	v, err := d.DequeueCtx(ctx)
+	if err != nil || v != "fast" {
+		t.Fatalf("expected fast nil, got %v %v", v, err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("expected to wait for the item to be ready, waited %s", elapsed)
+	}
+	// an item that is ready sooner than the waited for one wakes the waiter.
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		d.EnqueueAfter("sooner", 0)
+	}()
+	if v, err := d.DequeueCtx(ctx); err != nil || v != "sooner" {
+		t.Errorf("expected sooner nil, got %v %v", v, err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := d.DequeueCtx(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected %s, got %v", context.DeadlineExceeded, err)
+	}
+}
@@ -0,0 +1,114 @@
+package queue
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Change is an item that differs between two queues. From is the item's
+// position in the first queue and To its position in the second; the
+// position is -1 if the item isn't in that queue.
+type Change struct {
+	Key  string
+	Item interface{}
+	From int
+	To   int
+}
+
+// Delta is the difference between two queues, as reported by Diff: the items
+// that are only in the second queue, the items that are only in the first,
+// and the items that are in both but out of order relative to the others.
+// Items that are at a different position only because items before them were
+// added or removed are not moved. When more than one set of items could be
+// the moved ones, e.g. two items that swapped places, either may be reported.
+type Delta struct {
+	Added   []Change
+	Removed []Change
+	Moved   []Change
+}
+
+// Equal returns whether or not the two queues had the same items, in the same
+// order.
+func (d Delta) Equal() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Moved) == 0
+}
+
+// Diff compares the contents of two queues, e.g. a Mirror's primary and
+// secondary or a queue before and after a migration, using snapshots taken
+// under each queue's lock. Items are matched by key; if key is nil, items are
+// matched by their %v formatting. If a key appears more than once, its first
+// occurrence in a is matched with its first occurrence in b, and so on.
+func Diff(a, b Viewer, key func(interface{}) string) Delta {
+	return DiffSlices(a.Snapshot(), b.Snapshot(), key)
+}
+
+// DiffSlices is Diff for two snapshots, e.g. ToSlice results that were saved
+// earlier.
+func DiffSlices(a, b []interface{}, key func(interface{}) string) Delta {
+	if key == nil {
+		key = func(v interface{}) string { return fmt.Sprintf("%v", v) }
+	}
+	// the positions, in b, of each key, in order.
+	at := make(map[string][]int, len(b))
+	keys := make([]string, len(b))
+	for i, v := range b {
+		keys[i] = key(v)
+		at[keys[i]] = append(at[keys[i]], i)
+	}
+
+	var d Delta
+	var common []Change // items in both, in a's order.
+	matched := make([]bool, len(b))
+	for i, v := range a {
+		k := key(v)
+		if len(at[k]) == 0 {
+			d.Removed = append(d.Removed, Change{Key: k, Item: v, From: i, To: -1})
+			continue
+		}
+		j := at[k][0]
+		at[k] = at[k][1:]
+		matched[j] = true
+		common = append(common, Change{Key: k, Item: v, From: i, To: j})
+	}
+	for j, v := range b {
+		if !matched[j] {
+			d.Added = append(d.Added, Change{Key: keys[j], Item: v, From: -1, To: j})
+		}
+	}
+
+	// the longest run of common items that kept their order didn't move; the
+	// rest did.
+	kept := increasing(common)
+	for i, c := range common {
+		if !kept[i] {
+			d.Moved = append(d.Moved, c)
+		}
+	}
+	return d
+}
+
+// increasing returns which of the changes are part of a longest subsequence
+// whose To positions are increasing, in O(n log n).
+func increasing(cs []Change) []bool {
+	var tails []int // tails[l] is the index, in cs, of the end of the best run of length l+1.
+	prev := make([]int, len(cs))
+	for i, c := range cs {
+		l := sort.Search(len(tails), func(n int) bool { return cs[tails[n]].To >= c.To })
+		prev[i] = -1
+		if l > 0 {
+			prev[i] = tails[l-1]
+		}
+		if l == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[l] = i
+		}
+	}
+	kept := make([]bool, len(cs))
+	if len(tails) > 0 {
+		for i := tails[len(tails)-1]; i >= 0; i = prev[i] {
+			kept[i] = true
+		}
+	}
+	return kept
+}
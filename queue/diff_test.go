@@ -0,0 +1,72 @@
+package queue
+
+import (
+	"testing"
+)
+
+func TestDiffSlices(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    []interface{}
+		added   []int // To positions
+		removed []int // From positions
+		moved   []int // From positions
+	}{
+		{"empty", nil, nil, nil, nil, nil},
+		{"equal", []interface{}{1, 2, 3}, []interface{}{1, 2, 3}, nil, nil, nil},
+		{"added", []interface{}{1, 3}, []interface{}{1, 2, 3, 4}, []int{1, 3}, nil, nil},
+		{"removed", []interface{}{1, 2, 3, 4}, []interface{}{2, 4}, nil, []int{0, 2}, nil},
+		{"moved", []interface{}{1, 2, 3, 4}, []interface{}{1, 3, 4, 2}, nil, nil, []int{1}},
+		{"swapped", []interface{}{1, 2}, []interface{}{2, 1}, nil, nil, []int{0}},
+		{"duplicates", []interface{}{1, 1, 2}, []interface{}{1, 2, 1}, nil, nil, []int{1}},
+		{"all", []interface{}{1, 2, 3}, []interface{}{3, 4, 1}, []int{1}, []int{1}, []int{0}},
+	}
+	for _, test := range tests {
+		d := DiffSlices(test.a, test.b, nil)
+		check := func(kind string, got []Change, expected []int, pos func(Change) int) {
+			if len(got) != len(expected) {
+				t.Errorf("%s: expected %d %s, got %v", test.name, len(expected), kind, got)
+				return
+			}
+			for i, c := range got {
+				if pos(c) != expected[i] {
+					t.Errorf("%s: expected %s at %v, got %v", test.name, kind, expected, got)
+					return
+				}
+			}
+		}
+		check("added", d.Added, test.added, func(c Change) int { return c.To })
+		check("removed", d.Removed, test.removed, func(c Change) int { return c.From })
+		check("moved", d.Moved, test.moved, func(c Change) int { return c.From })
+		equal := len(test.added)+len(test.removed)+len(test.moved) == 0
+		if d.Equal() != equal {
+			t.Errorf("%s: expected Equal %t, got %t", test.name, equal, d.Equal())
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	type job struct {
+		id      string
+		attempt int
+	}
+	a := NewQueue(4)
+	b := NewCircular(4)
+	for _, id := range []string{"a", "b", "c"} {
+		a.Enqueue(job{id, 1})
+	}
+	b.Enqueue(job{"c", 2})
+	b.Enqueue(job{"a", 2})
+	b.Enqueue(job{"d", 1})
+	d := Diff(a, b, func(v interface{}) string { return v.(job).id })
+	if len(d.Added) != 1 || d.Added[0].Key != "d" || d.Added[0].From != -1 || d.Added[0].To != 2 {
+		t.Errorf("expected d to be added at 2, got %v", d.Added)
+	}
+	if len(d.Removed) != 1 || d.Removed[0].Key != "b" || d.Removed[0].To != -1 {
+		t.Errorf("expected b to be removed, got %v", d.Removed)
+	}
+	// the item reported is a's.
+	if len(d.Moved) != 1 || d.Moved[0].Item != (job{"a", 1}) || d.Moved[0].From != 0 || d.Moved[0].To != 1 {
+		t.Errorf("expected a to be moved from 0 to 1, got %v", d.Moved)
+	}
+}
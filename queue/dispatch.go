@@ -0,0 +1,73 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Dispatcher dequeues items from a queue and calls a func for each of them,
+// each call in its own goroutine, with a limit on the number of calls that
+// can be in flight at once. When the limit is reached, the Dispatcher stops
+// dequeueing until a call returns, so the items back up in the queue instead
+// of in goroutines; with a bounded queue, that backpressure reaches the
+// producers as enqueue errors.
+type Dispatcher struct {
+	q     Dequeuer
+	fn    func(item interface{})
+	sem   chan struct{}
+	wg    sync.WaitGroup
+	h     PanicHandler
+	calls atomic.Int64 // the calls in flight; sem also holds Run's slot while it waits for an item.
+}
+
+// NewDispatcher returns a Dispatcher that calls fn for each item dequeued
+// from q, with at most max calls in flight. A max < 1 is set to 1.
+func NewDispatcher(q Dequeuer, max int, fn func(item interface{})) *Dispatcher {
+	if max < 1 {
+		max = 1
+	}
+	return &Dispatcher{q: q, fn: fn, sem: make(chan struct{}, max)}
+}
+
+// SetPanicHandler sets the handler that panics in the Dispatcher's func are
+// reported to; with a handler, a panicking call is recovered and the
+// Dispatcher keeps running. A nil handler, the default, doesn't recover
+// panics. This must be called before Run.
+func (d *Dispatcher) SetPanicHandler(h PanicHandler) {
+	d.h = h
+}
+
+// Run dispatches items until ctx is done. Once ctx is done, no more items are
+// dequeued; Run waits for the in flight calls to return and then returns
+// ctx's error.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	defer d.wg.Wait()
+	for {
+		select {
+		case d.sem <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		v, _, err := Select(ctx, d.q)
+		if err != nil {
+			<-d.sem
+			return err
+		}
+		d.calls.Add(1)
+		d.wg.Add(1)
+		go func() {
+			defer func() {
+				d.calls.Add(-1)
+				<-d.sem
+				d.wg.Done()
+			}()
+			protect(d.h, "dispatch", func() { d.fn(v) })
+		}()
+	}
+}
+
+// InFlight returns the number of calls currently in flight.
+func (d *Dispatcher) InFlight() int {
+	return int(d.calls.Load())
+}
@@ -0,0 +1,96 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDispatcher(t *testing.T) {
+	const n, max = 20, 3
+	q := NewQueue(n)
+	for i := 0; i < n; i++ {
+		_ = q.Enqueue(i)
+	}
+	var (
+		mu       sync.Mutex
+		seen     = make(map[interface{}]bool)
+		inFlight atomic.Int32
+		peak     atomic.Int32
+		done     = make(chan struct{})
+	)
+	d := NewDispatcher(q, max, func(item interface{}) {
+		cur := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if cur <= p || peak.CompareAndSwap(p, cur) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		seen[item] = true
+		if len(seen) == n {
+			close(done)
+		}
+		mu.Unlock()
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error)
+	go func() { errs <- d.Run(ctx) }()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the items to be dispatched")
+	}
+	cancel()
+	if err := <-errs; err != context.Canceled {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+	if p := peak.Load(); p > max {
+		t.Errorf("expected at most %d calls in flight, got %d", max, p)
+	}
+	if d.InFlight() != 0 {
+		t.Errorf("expected no calls in flight after Run returned, got %d", d.InFlight())
+	}
+}
+
+func TestDispatcherBackpressure(t *testing.T) {
+	q := NewQueue(4)
+	for i := 0; i < 4; i++ {
+		_ = q.Enqueue(i)
+	}
+	release := make(chan struct{})
+	d := NewDispatcher(q, 1, func(item interface{}) { <-release })
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error)
+	go func() { errs <- d.Run(ctx) }()
+	for q.Len() != 3 {
+		time.Sleep(time.Millisecond)
+	}
+	// the one call in flight is blocked, nothing else should be dequeued.
+	time.Sleep(10 * time.Millisecond)
+	if q.Len() != 3 {
+		t.Errorf("expected 3 items to still be queued, got %d", q.Len())
+	}
+	cancel()
+	close(release)
+	<-errs
+}
+
+func TestDispatcherInFlightIdle(t *testing.T) {
+	d := NewDispatcher(NewQueue(1), 2, func(interface{}) {})
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error)
+	go func() { errs <- d.Run(ctx) }()
+	// Run is waiting for an item; that isn't a call in flight.
+	time.Sleep(10 * time.Millisecond)
+	if n := d.InFlight(); n != 0 {
+		t.Errorf("expected no calls in flight while idle, got %d", n)
+	}
+	cancel()
+	<-errs
+}
@@ -0,0 +1,90 @@
+package queue
+
+import (
+	"fmt"
+	"sort"
+	"unsafe"
+)
+
+// atomicEnqueuer is implemented by the queues EnqueueEach supports.
+type atomicEnqueuer interface {
+	base() *Queue
+	fits() bool // whether an item can be enqueued; the caller must hold the lock.
+	put(item interface{})
+}
+
+// EnqueueEach enqueues one item into each of the queues, atomically: either
+// every item is enqueued or, if any of the queues is full, none are and an
+// error is returned. This keeps pipelines that are fed the same event
+// consistent. The queues must be Queues or Circulars; a Circular's overflow
+// policy doesn't apply, a full Circular fails the call without blocking or
+// dropping.
+//
+// The queues are locked in a fixed order, so concurrent calls over
+// overlapping queues can't deadlock.
+func EnqueueEach(items map[Queuer]interface{}) error {
+	qs := make([]atomicEnqueuer, 0, len(items))
+	for q := range items {
+		a, ok := q.(atomicEnqueuer)
+		if !ok {
+			return fmt.Errorf("cannot enqueue atomically to a %T", q)
+		}
+		qs = append(qs, a)
+	}
+	sort.Slice(qs, func(i, j int) bool {
+		return uintptr(unsafe.Pointer(qs[i].base())) < uintptr(unsafe.Pointer(qs[j].base()))
+	})
+	for _, q := range qs {
+		q.base().Lock()
+	}
+	var err error
+	for _, q := range qs {
+		if !q.fits() {
+			err = fmt.Errorf("queue full: cannot enqueue %v", items[q.(Queuer)])
+			break
+		}
+	}
+	if err == nil {
+		for _, q := range qs {
+			q.put(items[q.(Queuer)])
+		}
+	}
+	for _, q := range qs {
+		q.base().Unlock()
+	}
+	if err != nil {
+		return err
+	}
+	for _, q := range qs {
+		q.base().emit(EventEnqueue, items[q.(Queuer)])
+	}
+	return nil
+}
+
+// base returns the queue; it is promoted to the queues that embed a Queue,
+// so that they are locked, and ordered, by their Queue.
+func (q *Queue) base() *Queue {
+	return q
+}
+
+// fits returns true: an unbounded queue always has room.
+func (q *Queue) fits() bool {
+	return true
+}
+
+// put enqueues item; the caller must hold the lock.
+func (q *Queue) put(item interface{}) {
+	q.enqueue(item)
+}
+
+// fits returns whether or not the queue has room for an item; the caller
+// must hold the lock.
+func (c *Circular) fits() bool {
+	return !c.isFull()
+}
+
+// put enqueues item; the caller must hold the lock and the queue must have
+// room.
+func (c *Circular) put(item interface{}) {
+	c.enqueue(item)
+}
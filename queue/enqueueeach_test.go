@@ -0,0 +1,62 @@
+package queue
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestEnqueueEach(t *testing.T) {
+	q := NewQueue(1)
+	c := NewCircular(1)
+	full := NewCircular(1)
+	full.Enqueue(0)
+
+	if err := EnqueueEach(map[Queuer]interface{}{q: 1, c: 2}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if v, _ := q.Peek(); v != 1 {
+		t.Errorf("expected 1, got %v", v)
+	}
+	if v, _ := c.Peek(); v != 2 {
+		t.Errorf("expected 2, got %v", v)
+	}
+
+	// c is now full too; nothing is enqueued.
+	err := EnqueueEach(map[Queuer]interface{}{q: 3, full: 4})
+	if err == nil || err.Error() != "queue full: cannot enqueue 4" {
+		t.Errorf("expected a queue full error, got %v", err)
+	}
+	if q.Len() != 1 || full.Len() != 1 {
+		t.Errorf("expected a failed EnqueueEach not to enqueue, got lens %d %d", q.Len(), full.Len())
+	}
+
+	if err := EnqueueEach(map[Queuer]interface{}{q: 5, NewMirror(NewQueue(1), NewQueue(1), nil): 6}); err == nil {
+		t.Error("expected an error for an unsupported queue")
+	}
+	if q.Len() != 1 {
+		t.Errorf("expected an unsupported queue not to enqueue, got len %d", q.Len())
+	}
+}
+
+func TestEnqueueEachConcurrent(t *testing.T) {
+	a, b := NewQueue(8), NewCircular(1000)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 250; j++ {
+				// the maps order the queues differently; the locks must not.
+				if i%2 == 0 {
+					EnqueueEach(map[Queuer]interface{}{a: j, b: j})
+				} else {
+					EnqueueEach(map[Queuer]interface{}{b: j, a: j})
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	if a.Len() != 1000 || b.Len() != 1000 {
+		t.Errorf("expected 1000 items in each queue, got %d %d", a.Len(), b.Len())
+	}
+}
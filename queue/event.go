@@ -0,0 +1,164 @@
+package queue
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventKind is the kind of an Event.
+type EventKind int
+
+// The events queues emit.
+const (
+	EventEnqueue  EventKind = iota // an item was enqueued.
+	EventDequeue                   // an item was dequeued.
+	EventDrop                      // an item was rejected, or evicted by OverflowDropOldest, because the queue was full.
+	EventReset                     // the queue was reset.
+	EventResize                    // the queue was resized.
+	EventPressure                  // the queue's pressure changed; see SetWatermarks.
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventEnqueue:
+		return "enqueue"
+	case EventDequeue:
+		return "dequeue"
+	case EventDrop:
+		return "drop"
+	case EventReset:
+		return "reset"
+	case EventResize:
+		return "resize"
+	case EventPressure:
+		return "pressure"
+	}
+	return "unknown"
+}
+
+// Event describes something that happened to a queue.
+type Event struct {
+	Kind     EventKind
+	Time     time.Time
+	Item     interface{}       // the item enqueued, dequeued, or dropped, if any.
+	Pressure Pressure          // for EventPressure, the queue's new pressure.
+	Len      int               // the queue's length after the event.
+	Bytes    int               // the queue's size in bytes after the event; see SetSizer.
+	Queue    string            // the queue's name, if it has one.
+	Labels   map[string]string // the queue's labels; these must not be modified.
+}
+
+// subscriber is a Bus subscription.
+type subscriber struct {
+	id int
+	fn func(Event)
+}
+
+// Bus delivers the events of one or more queues to its subscribers. Events
+// are delivered synchronously, in the goroutine that caused them, but never
+// while a queue's lock is held: subscribers may use the queue. Subscribers
+// should be fast; a slow subscriber slows down the queue's callers.
+//
+// Publishing does not take a lock; subscribing and unsubscribing do.
+type Bus struct {
+	mu     sync.Mutex
+	nextID int
+	subs   atomic.Pointer[[]subscriber] // copy on write
+	panics atomic.Pointer[PanicHandler]
+}
+
+// NewBus returns a Bus with no subscribers.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe adds fn as a subscriber; fn is called with every event published
+// after Subscribe returns. The returned func removes the subscription.
+func (b *Bus) Subscribe(fn func(Event)) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextID++
+	id := b.nextID
+	var subs []subscriber
+	if p := b.subs.Load(); p != nil {
+		subs = append(subs, *p...)
+	}
+	subs = append(subs, subscriber{id: id, fn: fn})
+	b.subs.Store(&subs)
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		var subs []subscriber
+		for _, s := range *b.subs.Load() {
+			if s.id != id {
+				subs = append(subs, s)
+			}
+		}
+		b.subs.Store(&subs)
+	}
+}
+
+// SetPanicHandler sets the handler that subscriber panics are reported to;
+// with a handler, a panicking subscriber doesn't stop the event from being
+// delivered to the other subscribers or reach the queue's caller. A nil
+// handler, the default, doesn't recover panics.
+func (b *Bus) SetPanicHandler(h PanicHandler) {
+	if h == nil {
+		b.panics.Store(nil)
+		return
+	}
+	b.panics.Store(&h)
+}
+
+// Publish delivers e to every subscriber.
+func (b *Bus) Publish(e Event) {
+	p := b.subs.Load()
+	if p == nil {
+		return
+	}
+	h := b.panics.Load()
+	for _, s := range *p {
+		if h == nil {
+			s.fn(e)
+			continue
+		}
+		protect(*h, "subscriber", func() { s.fn(e) })
+	}
+}
+
+// SetBus sets the Bus the queue publishes its events to; a nil Bus stops
+// publishing.
+func (q *Queue) SetBus(b *Bus) {
+	q.bus.Store(b)
+}
+
+// emit counts an event, samples enqueued items for the queue's taps, calls
+// the queue's hooks, publishes the event to the queue's Bus, if it has one,
+// and then notifies any change in the queue's pressure that it caused. This
+// must not be called while holding the lock.
+func (q *Queue) emit(kind EventKind, item interface{}) {
+	q.stats.count(kind)
+	if kind == EventEnqueue {
+		q.sample(item)
+	}
+	q.callHooks(kind, item)
+	q.post(Event{Kind: kind, Item: item})
+	q.notifyPressure()
+}
+
+// post fills in e's time and the queue's length, size, name, and labels, and
+// publishes it to the queue's Bus, if it has one. This must not be called
+// while holding the lock.
+func (q *Queue) post(e Event) {
+	b := q.bus.Load()
+	if b == nil {
+		return
+	}
+	l, _ := unpack(q.state.Load())
+	e.Time, e.Len, e.Bytes = time.Now(), l, q.Bytes()
+	if id := q.id.Load(); id != nil {
+		e.Queue, e.Labels = id.name, id.labels
+	}
+	b.Publish(e)
+}
@@ -0,0 +1,87 @@
+package queue
+
+import (
+	"testing"
+)
+
+func TestBus(t *testing.T) {
+	b := NewBus()
+	c := NewCircular(1)
+	c.SetBus(b)
+	var got []Event
+	unsubscribe := b.Subscribe(func(e Event) {
+		// subscribers are called without the lock held; using the queue
+		// must not deadlock.
+		_ = c.Cap()
+		got = append(got, e)
+	})
+	var other int
+	b.Subscribe(func(Event) { other++ })
+
+	_ = c.Enqueue(1)
+	_ = c.Enqueue(2)
+	c.Dequeue()
+	c.Dequeue()
+	c.Reset()
+	expected := []struct {
+		kind EventKind
+		item interface{}
+		len  int
+	}{
+		{EventEnqueue, 1, 1},
+		{EventDrop, 2, 1},
+		{EventDequeue, 1, 0},
+		{EventReset, nil, 0},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d events, got %d: %v", len(expected), len(got), got)
+	}
+	for i, e := range expected {
+		if got[i].Kind != e.kind || got[i].Item != e.item || got[i].Len != e.len {
+			t.Errorf("%d: expected %s %v %d, got %s %v %d", i, e.kind, e.item, e.len, got[i].Kind, got[i].Item, got[i].Len)
+		}
+		if got[i].Time.IsZero() {
+			t.Errorf("%d: expected the event time to be set", i)
+		}
+	}
+	if other != len(expected) {
+		t.Errorf("expected the other subscriber to get %d events, got %d", len(expected), other)
+	}
+
+	unsubscribe()
+	_ = c.Enqueue(3)
+	if len(got) != len(expected) {
+		t.Errorf("expected no events after unsubscribing, got %d more", len(got)-len(expected))
+	}
+	if other != len(expected)+1 {
+		t.Errorf("expected the other subscriber to still get events, got %d", other)
+	}
+
+	c.SetBus(nil)
+	c.Dequeue()
+	if other != len(expected)+1 {
+		t.Errorf("expected no events after the bus was removed, got %d", other)
+	}
+}
+
+func TestQueueEvents(t *testing.T) {
+	b := NewBus()
+	q := NewQueue(2)
+	q.SetBus(b)
+	var kinds []EventKind
+	b.Subscribe(func(e Event) { kinds = append(kinds, e.Kind) })
+	_ = q.Enqueue(1)
+	q.Dequeue()
+	q.Dequeue()
+	q.Resize(4)
+	q.Reset()
+	expected := []EventKind{EventEnqueue, EventDequeue, EventResize, EventReset}
+	if len(kinds) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, kinds)
+	}
+	for i, k := range expected {
+		if kinds[i] != k {
+			t.Errorf("%d: expected %s, got %s", i, k, kinds[i])
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package queue
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// defaultBatch is the number of nodes the consumer accumulates before
+// returning them to the freelist.
+const defaultBatch = 32
+
+// envelope holds a non-intrusive item on an MPSC queue.
+type envelope struct {
+	Node
+	value interface{}
+}
+
+// FreelistStats reports on the node freelist of a linked queue.
+type FreelistStats struct {
+	Allocs   uint64 // nodes allocated because the freelist was empty.
+	Reuses   uint64 // nodes taken from the freelist.
+	Returned uint64 // nodes returned to the freelist.
+	Batches  uint64 // batched returns to the freelist.
+	Free     int    // nodes currently on the freelist.
+}
+
+// freelist is a lock protected stack of nodes. Producers take nodes from it
+// one at a time; the consumer returns them in batches so that it only takes
+// the lock once per batch.
+type freelist struct {
+	mu       sync.Mutex
+	nodes    []*envelope
+	allocs   atomic.Uint64
+	reuses   atomic.Uint64
+	returned atomic.Uint64
+	batches  atomic.Uint64
+}
+
+// get returns a node from the freelist; if the freelist is empty a new node
+// is allocated.
+func (f *freelist) get() *envelope {
+	f.mu.Lock()
+	if len(f.nodes) == 0 {
+		f.mu.Unlock()
+		f.allocs.Add(1)
+		return &envelope{}
+	}
+	n := f.nodes[len(f.nodes)-1]
+	f.nodes[len(f.nodes)-1] = nil
+	f.nodes = f.nodes[:len(f.nodes)-1]
+	f.mu.Unlock()
+	f.reuses.Add(1)
+	return n
+}
+
+// put returns a batch of nodes to the freelist.
+func (f *freelist) put(batch []*envelope) {
+	f.mu.Lock()
+	f.nodes = append(f.nodes, batch...)
+	f.mu.Unlock()
+	f.returned.Add(uint64(len(batch)))
+	f.batches.Add(1)
+}
+
+func (f *freelist) stats() FreelistStats {
+	f.mu.Lock()
+	free := len(f.nodes)
+	f.mu.Unlock()
+	return FreelistStats{
+		Allocs:   f.allocs.Load(),
+		Reuses:   f.reuses.Load(),
+		Returned: f.returned.Load(),
+		Batches:  f.batches.Load(),
+		Free:     free,
+	}
+}
+
+// LinkedMPSC is an unbounded multi-producer/single-consumer queue of
+// arbitrary items. It is built on MPSC; the nodes that hold the items are
+// recycled through a freelist so that, at steady state, neither Enqueue nor
+// Dequeue allocate.
+//
+// Dequeued nodes are held by the consumer until it has a full batch, they are
+// then returned to the freelist in one operation.
+type LinkedMPSC struct {
+	q     *MPSC
+	free  freelist
+	batch []*envelope // consumer only
+}
+
+// NewLinkedMPSC returns an empty LinkedMPSC whose consumer returns nodes to
+// the freelist in batches of the received size. A batch size <= 0 uses the
+// default batch size.
+func NewLinkedMPSC(batch int) *LinkedMPSC {
+	if batch <= 0 {
+		batch = defaultBatch
+	}
+	return &LinkedMPSC{q: NewMPSC(), batch: make([]*envelope, 0, batch)}
+}
+
+// Enqueue adds an item to the queue. This never fails; the error is for
+// consistency with the other queues.
+func (l *LinkedMPSC) Enqueue(item interface{}) error {
+	n := l.free.get()
+	n.value = item
+	l.q.Enqueue(n)
+	return nil
+}
+
+// Dequeue removes the oldest item from the queue and returns it. If the queue
+// is empty, a false will be returned.
+//
+// Dequeue must only be called by the consumer.
+func (l *LinkedMPSC) Dequeue() (interface{}, bool) {
+	v, ok := l.q.Dequeue()
+	if !ok {
+		return nil, false
+	}
+	n := v.(*envelope)
+	item := n.value
+	n.value = nil
+	l.batch = append(l.batch, n)
+	if len(l.batch) == cap(l.batch) {
+		l.free.put(l.batch)
+		for i := range l.batch {
+			l.batch[i] = nil
+		}
+		l.batch = l.batch[:0]
+	}
+	return item, true
+}
+
+// IsEmpty returns whether or not the queue is empty.
+func (l *LinkedMPSC) IsEmpty() bool {
+	return l.q.IsEmpty()
+}
+
+// Len returns the current number of items in the queue.
+func (l *LinkedMPSC) Len() int {
+	return l.q.Len()
+}
+
+// FreelistStats returns the current freelist stats.
+func (l *LinkedMPSC) FreelistStats() FreelistStats {
+	return l.free.stats()
+}
@@ -0,0 +1,53 @@
+package queue
+
+import (
+	"testing"
+)
+
+func TestLinkedMPSC(t *testing.T) {
+	q := NewLinkedMPSC(4)
+	for i := 0; i < 10; i++ {
+		_ = q.Enqueue(i)
+	}
+	if q.Len() != 10 {
+		t.Errorf("expected len to be 10, got %d", q.Len())
+	}
+	for i := 0; i < 10; i++ {
+		v, ok := q.Dequeue()
+		if !ok || v != i {
+			t.Errorf("%d: expected %d true, got %v %t", i, i, v, ok)
+		}
+	}
+	if _, ok := q.Dequeue(); ok {
+		t.Error("expected dequeue of an empty queue to return false")
+	}
+	stats := q.FreelistStats()
+	if stats.Allocs != 10 {
+		t.Errorf("expected 10 allocs, got %d", stats.Allocs)
+	}
+	if stats.Batches != 2 || stats.Returned != 8 || stats.Free != 8 {
+		t.Errorf("expected 2 batches, 8 returned and 8 free, got %d, %d, %d", stats.Batches, stats.Returned, stats.Free)
+	}
+
+	// the next 8 enqueues should use the returned nodes.
+	for i := 0; i < 9; i++ {
+		_ = q.Enqueue(i)
+	}
+	stats = q.FreelistStats()
+	if stats.Reuses != 8 || stats.Allocs != 11 || stats.Free != 0 {
+		t.Errorf("expected 8 reuses, 11 allocs and 0 free, got %d, %d, %d", stats.Reuses, stats.Allocs, stats.Free)
+	}
+}
+
+func TestLinkedMPSCSteadyStateAllocs(t *testing.T) {
+	q := NewLinkedMPSC(1)
+	_ = q.Enqueue(0)
+	q.Dequeue()
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = q.Enqueue(0)
+		q.Dequeue()
+	})
+	if allocs != 0 {
+		t.Errorf("expected steady state enqueue/dequeue to not allocate, got %v allocs", allocs)
+	}
+}
@@ -0,0 +1,77 @@
+package queue
+
+import (
+	"time"
+)
+
+// defaultFreezeTimeout is the safety timeout used by Freeze when one isn't
+// specified.
+const defaultFreezeTimeout = time.Second
+
+// frost is a Freeze that hasn't been thawed yet.
+type frost struct {
+	timer *time.Timer
+}
+
+// Freeze blocks all operations that take the queue's lock, i.e. everything
+// that modifies the queue, until Thaw is called or the timeout passes,
+// whichever happens first; there is no way to freeze a queue indefinitely. A
+// timeout <= 0 uses a default of 1s.
+//
+// While a queue is frozen, it can be inspected, e.g. by a dump tool, without
+// racing with the queue's users: Snapshot returns the frozen items, and the
+// lock-free accessors, like Len and Cap, still work. The other methods that
+// take the lock, including Peek, block until the queue is thawed, so they
+// must not be called by the goroutine that froze it.
+//
+// If the queue is already frozen, Freeze blocks until it is thawed.
+func (q *Queue) Freeze(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = defaultFreezeTimeout
+	}
+	q.Lock()
+	f := &frost{}
+	q.fmu.Lock()
+	q.frost = f
+	f.timer = time.AfterFunc(timeout, func() { q.thaw(f) })
+	q.fmu.Unlock()
+}
+
+// Thaw unfreezes a queue that was frozen by Freeze. A false is returned if
+// the queue wasn't frozen, e.g. because the freeze's timeout had passed.
+func (q *Queue) Thaw() bool {
+	q.fmu.Lock()
+	f := q.frost
+	q.fmu.Unlock()
+	if f == nil {
+		return false
+	}
+	return q.thaw(f)
+}
+
+// frozenView returns the result of calling view, and true, if the queue is
+// frozen; otherwise view isn't called and false is returned. The freezer
+// holds the lock, so nothing changes the queue while view runs; holding fmu
+// keeps the freeze from ending before view returns.
+func (q *Queue) frozenView(view func() []interface{}) ([]interface{}, bool) {
+	q.fmu.Lock()
+	defer q.fmu.Unlock()
+	if q.frost == nil {
+		return nil, false
+	}
+	return view(), true
+}
+
+// thaw ends the freeze f, if it is still the current freeze.
+func (q *Queue) thaw(f *frost) bool {
+	q.fmu.Lock()
+	if q.frost != f {
+		q.fmu.Unlock()
+		return false
+	}
+	q.frost = nil
+	f.timer.Stop()
+	q.fmu.Unlock()
+	q.Unlock()
+	return true
+}
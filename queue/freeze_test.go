@@ -0,0 +1,66 @@
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFreeze(t *testing.T) {
+	c := NewCircular(4)
+	_ = c.Enqueue(1)
+	c.Freeze(time.Minute)
+	done := make(chan struct{})
+	go func() {
+		_ = c.Enqueue(2)
+		close(done)
+	}()
+	// the freezer can inspect the queue.
+	if s := c.Snapshot(); c.Len() != 1 || len(s) != 1 || s[0] != 1 {
+		t.Errorf("expected the frozen queue to hold 1, got len %d, %v", c.Len(), s)
+	}
+	select {
+	case <-done:
+		t.Fatal("expected enqueue to block while the queue is frozen")
+	case <-time.After(10 * time.Millisecond):
+	}
+	if !c.Thaw() {
+		t.Error("expected thaw to return true")
+	}
+	<-done
+	if s := c.Snapshot(); c.Len() != 2 || len(s) != 2 {
+		t.Errorf("expected len to be 2, got %d, %v", c.Len(), s)
+	}
+	if c.Thaw() {
+		t.Error("expected thaw of a queue that isn't frozen to return false")
+	}
+}
+
+func TestFreezeTimeout(t *testing.T) {
+	q := NewQueue(4)
+	q.Freeze(10 * time.Millisecond)
+	done := make(chan struct{})
+	go func() {
+		_ = q.Enqueue(1)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the freeze to time out")
+	}
+	if q.Thaw() {
+		t.Error("expected thaw after the timeout to return false")
+	}
+}
+
+func TestFreezeSnapshot(t *testing.T) {
+	q := NewQueue(4)
+	_ = q.Enqueue(1)
+	_ = q.Enqueue(2)
+	q.Freeze(time.Minute)
+	s := q.Snapshot()
+	q.Thaw()
+	if len(s) != 2 || s[0] != 1 || s[1] != 2 {
+		t.Errorf("expected the frozen snapshot to be [1 2], got %v", s)
+	}
+}
@@ -0,0 +1,157 @@
+package queue
+
+import (
+	"sync"
+	"time"
+)
+
+// Dependent is implemented by items that can't be processed until a
+// prerequisite has happened, e.g. the upload a thumbnail job is for.
+type Dependent interface {
+	Requires() string
+}
+
+// Marker is implemented by items that mark that a prerequisite has happened.
+type Marker interface {
+	Marks() string
+}
+
+// held is an item a Gate is holding.
+type held struct {
+	item     interface{}
+	key      string
+	deadline time.Time
+}
+
+// Gate gates the items dequeued from a queue: a Dependent item is only
+// released once its prerequisite has been marked, either with Mark or by a
+// Marker item being enqueued on a watched queue; see Watch. Items that
+// aren't Dependents are released immediately. This joins two queues on a key,
+// so simple fork/join workflows can be built without a workflow engine.
+//
+// An item is held for at most the Gate's wait. When its wait passes, the
+// expire func, if there is one, is called with the item and the item is
+// dropped. Held items are released in the order they were dequeued.
+type Gate struct {
+	mu     sync.Mutex
+	q      Dequeuer
+	wait   time.Duration
+	expire func(item interface{})
+	panics PanicHandler
+	marked map[string]struct{}
+	held   []held
+}
+
+// NewGate returns a Gate for the items dequeued from q that holds items for
+// at most wait; a nil expire func is allowed.
+func NewGate(q Dequeuer, wait time.Duration, expire func(item interface{})) *Gate {
+	return &Gate{q: q, wait: wait, expire: expire, marked: make(map[string]struct{})}
+}
+
+// Mark marks the prerequisite key as having happened; the items that require
+// it are released. Marks are remembered until they are forgotten with Forget.
+func (g *Gate) Mark(key string) {
+	g.mu.Lock()
+	g.marked[key] = struct{}{}
+	g.mu.Unlock()
+}
+
+// Forget forgets that key was marked.
+func (g *Gate) Forget(key string) {
+	g.mu.Lock()
+	delete(g.marked, key)
+	g.mu.Unlock()
+}
+
+// Watch subscribes the Gate to b so that every Marker item enqueued on a
+// queue that publishes to b is marked. The returned func stops watching.
+func (g *Gate) Watch(b *Bus) (unsubscribe func()) {
+	return b.Subscribe(func(e Event) {
+		if e.Kind != EventEnqueue {
+			return
+		}
+		if m, ok := e.Item.(Marker); ok {
+			g.Mark(m.Marks())
+		}
+	})
+}
+
+// Held returns the number of items being held.
+func (g *Gate) Held() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.held)
+}
+
+// Dequeue returns the next released item: the first held item whose
+// prerequisite has been marked or, if there isn't one, the first item from
+// the queue that doesn't need to be held. Items that must wait are held. If
+// no item can be released, a false will be returned.
+func (g *Gate) Dequeue() (interface{}, bool) {
+	var expired []interface{}
+	g.mu.Lock()
+	item, ok := g.release(time.Now(), &expired)
+	h := g.panics
+	g.mu.Unlock()
+	if g.expire != nil {
+		for _, v := range expired {
+			protect(h, "expire", func() { g.expire(v) })
+		}
+	}
+	return item, ok
+}
+
+// SetPanicHandler sets the handler that panics in the expire func are
+// reported to; with a handler, a panicking expire func is recovered and the
+// rest of the expired items are still passed to it. A nil handler, the
+// default, doesn't recover panics.
+func (g *Gate) SetPanicHandler(h PanicHandler) {
+	g.mu.Lock()
+	g.panics = h
+	g.mu.Unlock()
+}
+
+// release is the unexported version of Dequeue; it appends the items whose
+// wait has passed to expired. The caller must hold the lock.
+func (g *Gate) release(now time.Time, expired *[]interface{}) (interface{}, bool) {
+	j := 0
+	var item interface{}
+	var found bool
+	for i, h := range g.held {
+		if found {
+			g.held[j] = g.held[i]
+			j++
+			continue
+		}
+		if _, ok := g.marked[h.key]; ok {
+			item, found = h.item, true
+			continue
+		}
+		if !now.Before(h.deadline) {
+			*expired = append(*expired, h.item)
+			continue
+		}
+		g.held[j] = h
+		j++
+	}
+	clear(g.held[j:])
+	g.held = g.held[:j]
+	if found {
+		return item, true
+	}
+	for {
+		v, ok := g.q.Dequeue()
+		if !ok {
+			return nil, false
+		}
+		d, ok := v.(Dependent)
+		if !ok {
+			return v, true
+		}
+		key := d.Requires()
+		if _, ok := g.marked[key]; ok {
+			return v, true
+		}
+		g.held = append(g.held, held{item: v, key: key, deadline: now.Add(g.wait)})
+	}
+}
@@ -0,0 +1,118 @@
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+// thumb is a job that requires an upload.
+type thumb string
+
+func (t thumb) Requires() string { return string(t) }
+
+// upload marks that an upload is done.
+type upload string
+
+func (u upload) Marks() string { return string(u) }
+
+func TestGate(t *testing.T) {
+	b := NewQueue(0)
+	g := NewGate(b, time.Hour, nil)
+	for _, v := range []interface{}{thumb("x"), "plain", thumb("y"), thumb("x2")} {
+		b.Enqueue(v)
+	}
+	// only the item without a prerequisite is released.
+	tests := []struct {
+		mark     string
+		expected []interface{}
+		held     int
+	}{
+		{"", []interface{}{"plain"}, 3},
+		{"y", []interface{}{thumb("y")}, 2},
+		{"z", nil, 2},
+		{"x", []interface{}{thumb("x")}, 1},
+		{"x2", []interface{}{thumb("x2")}, 0},
+	}
+	for _, test := range tests {
+		if test.mark != "" {
+			g.Mark(test.mark)
+		}
+		for _, expected := range test.expected {
+			if v, ok := g.Dequeue(); !ok || v != expected {
+				t.Errorf("%q: expected %v true, got %v %t", test.mark, expected, v, ok)
+			}
+		}
+		if v, ok := g.Dequeue(); ok {
+			t.Errorf("%q: expected nothing to be released, got %v", test.mark, v)
+		}
+		if g.Held() != test.held {
+			t.Errorf("%q: expected %d held, got %d", test.mark, test.held, g.Held())
+		}
+	}
+	// a marked key releases later items immediately, until it is forgotten.
+	b.Enqueue(thumb("x"))
+	if v, ok := g.Dequeue(); !ok || v != thumb("x") {
+		t.Errorf("expected x to be released, got %v %t", v, ok)
+	}
+	g.Forget("x")
+	b.Enqueue(thumb("x"))
+	if v, ok := g.Dequeue(); ok {
+		t.Errorf("expected x to be held after it was forgotten, got %v", v)
+	}
+}
+
+func TestGateOrder(t *testing.T) {
+	b := NewQueue(0)
+	g := NewGate(b, time.Hour, nil)
+	for i := 0; i < 3; i++ {
+		b.Enqueue(thumb("k"))
+	}
+	b.Enqueue(thumb("j"))
+	g.Dequeue()
+	g.Mark("j")
+	g.Mark("k")
+	// held items are released in the order they were dequeued.
+	for _, expected := range []thumb{"k", "k", "k", "j"} {
+		if v, ok := g.Dequeue(); !ok || v != expected {
+			t.Errorf("expected %s true, got %v %t", expected, v, ok)
+		}
+	}
+}
+
+func TestGateExpire(t *testing.T) {
+	b := NewQueue(0)
+	var expired []interface{}
+	g := NewGate(b, 10*time.Millisecond, func(item interface{}) { expired = append(expired, item) })
+	b.Enqueue(thumb("never"))
+	b.Enqueue(thumb("x"))
+	g.Dequeue()
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := g.Dequeue(); ok {
+		t.Error("expected nothing to be released")
+	}
+	if len(expired) != 2 || g.Held() != 0 {
+		t.Errorf("expected both items to expire, got %v with %d held", expired, g.Held())
+	}
+}
+
+func TestGateWatch(t *testing.T) {
+	a, b := NewQueue(0), NewQueue(0)
+	bus := NewBus()
+	a.SetBus(bus)
+	g := NewGate(b, time.Hour, nil)
+	stop := g.Watch(bus)
+	b.Enqueue(thumb("u1"))
+	b.Enqueue(thumb("u2"))
+	if _, ok := g.Dequeue(); ok {
+		t.Error("expected the items to be held")
+	}
+	a.Enqueue(upload("u2"))
+	if v, ok := g.Dequeue(); !ok || v != thumb("u2") {
+		t.Errorf("expected u2 true, got %v %t", v, ok)
+	}
+	stop()
+	a.Enqueue(upload("u1"))
+	if v, ok := g.Dequeue(); ok {
+		t.Errorf("expected nothing to be released after unsubscribing, got %v", v)
+	}
+}
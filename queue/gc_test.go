@@ -0,0 +1,84 @@
+package queue
+
+import (
+	"runtime"
+	"testing"
+)
+
+// The benchmarks in this file measure the allocation and GC cost of each
+// queue type at a high operation rate. Run them with:
+//
+//    go test -run=NONE -bench=GC ./queue
+//
+// In addition to allocs/op and B/op, each benchmark reports the GC pause time
+// per op and the number of GC cycles that occurred during the run.
+
+// payload is a pointer sized item; enqueueing it does not allocate.
+var payload = &struct{ n int }{42}
+
+// benchGC runs fn b.N times and reports GC metrics.
+func benchGC(b *testing.B, fn func()) {
+	var before, after runtime.MemStats
+	b.ReportAllocs()
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fn()
+	}
+	b.StopTimer()
+	runtime.ReadMemStats(&after)
+	b.ReportMetric(float64(after.PauseTotalNs-before.PauseTotalNs)/float64(b.N), "gc-pause-ns/op")
+	b.ReportMetric(float64(after.NumGC-before.NumGC), "gcs")
+}
+
+func BenchmarkGCQueue(b *testing.B) {
+	q := NewQueue(1024)
+	benchGC(b, func() {
+		_ = q.Enqueue(payload)
+		q.Dequeue()
+	})
+}
+
+func BenchmarkGCCircular(b *testing.B) {
+	q := NewCircular(1024)
+	benchGC(b, func() {
+		_ = q.Enqueue(payload)
+		q.Dequeue()
+	})
+}
+
+func BenchmarkGCHeapPriority(b *testing.B) {
+	q := NewHeapPriority(0)
+	benchGC(b, func() {
+		q.Push(&Item{value: payload})
+		q.Pop()
+	})
+}
+
+func BenchmarkGCMPSC(b *testing.B) {
+	q := NewMPSC()
+	it := &mpscItem{}
+	benchGC(b, func() {
+		q.Enqueue(it)
+		q.Dequeue()
+	})
+}
+
+func BenchmarkGCLinkedMPSC(b *testing.B) {
+	q := NewLinkedMPSC(0)
+	benchGC(b, func() {
+		_ = q.Enqueue(payload)
+		q.Dequeue()
+	})
+}
+
+// BenchmarkGCMPSCUnpooled is LinkedMPSC without the freelist: a new envelope
+// is allocated for every item. Compare with BenchmarkGCLinkedMPSC.
+func BenchmarkGCMPSCUnpooled(b *testing.B) {
+	q := NewMPSC()
+	benchGC(b, func() {
+		q.Enqueue(&envelope{value: payload})
+		q.Dequeue()
+	})
+}
@@ -0,0 +1,137 @@
+package queue
+
+import (
+	"sync"
+	"time"
+)
+
+// Group is a consumer group for a Sharded queue: the shards are divided
+// between the group's consumers so that every shard is assigned to exactly
+// one consumer. When a consumer joins or leaves, the shards are rebalanced.
+//
+// A consumer only dequeues from its own shards, so items from a shard are
+// dequeued in FIFO order. If there are more consumers than shards, the extra
+// consumers aren't assigned any shards until another consumer leaves.
+type Group struct {
+	s       *Sharded
+	mu      sync.RWMutex // held for writing while rebalancing.
+	members []*Consumer  // in the order they joined.
+}
+
+// Consumer is a member of a Group.
+type Consumer struct {
+	g      *Group
+	shards []int // the assigned shards; protected by the group's lock.
+	next   int   // the next of the assigned shards to dequeue from.
+	t      tracker
+}
+
+// NewGroup returns a consumer group for s with no consumers.
+func NewGroup(s *Sharded) *Group {
+	return &Group{s: s}
+}
+
+// Join adds a consumer to the group and rebalances the shards.
+func (g *Group) Join() *Consumer {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	c := &Consumer{g: g}
+	g.members = append(g.members, c)
+	g.rebalance()
+	return c
+}
+
+// Consumers returns the number of consumers in the group.
+func (g *Group) Consumers() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.members)
+}
+
+// Stats returns the delivery stats of each consumer in the group, in the
+// order they joined.
+func (g *Group) Stats() []ConsumerStats {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	stats := make([]ConsumerStats, len(g.members))
+	for i, c := range g.members {
+		stats[i] = c.Stats()
+	}
+	return stats
+}
+
+// rebalance assigns the shards round-robin to the members. The caller must
+// hold the lock for writing.
+func (g *Group) rebalance() {
+	for _, c := range g.members {
+		c.shards = c.shards[:0]
+		c.next = 0
+	}
+	if len(g.members) == 0 {
+		return
+	}
+	for i := range g.s.shards {
+		c := g.members[i%len(g.members)]
+		c.shards = append(c.shards, i)
+	}
+}
+
+// Leave removes the consumer from its group and rebalances the shards. Once
+// it has left, the consumer has no shards.
+func (c *Consumer) Leave() {
+	g := c.g
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for i, m := range g.members {
+		if m == c {
+			g.members = append(g.members[:i], g.members[i+1:]...)
+			c.shards = nil
+			g.rebalance()
+			return
+		}
+	}
+}
+
+// Shards returns the indexes of the shards assigned to the consumer.
+func (c *Consumer) Shards() []int {
+	c.g.mu.RLock()
+	defer c.g.mu.RUnlock()
+	return append([]int(nil), c.shards...)
+}
+
+// Dequeue removes an item from one of the consumer's shards; the shards are
+// tried round-robin. If all of the consumer's shards are empty, or it has
+// none, a false will be returned.
+//
+// A consumer is not safe for concurrent use; each consumer should be used by
+// one goroutine.
+func (c *Consumer) Dequeue() (interface{}, bool) {
+	v, ok := c.dequeue()
+	c.t.dequeued(ok)
+	return v, ok
+}
+
+// dequeue is the unexported version of Dequeue; it doesn't count the dequeue.
+func (c *Consumer) dequeue() (interface{}, bool) {
+	c.g.mu.RLock()
+	defer c.g.mu.RUnlock()
+	for i := 0; i < len(c.shards); i++ {
+		j := c.shards[c.next]
+		c.next = (c.next + 1) % len(c.shards)
+		if v, ok := c.g.s.shards[j].Dequeue(); ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// Done reports the handling of a dequeued item: how long it took and the
+// error, if there was one. It is counted in the consumer's Stats.
+func (c *Consumer) Done(latency time.Duration, err error) {
+	c.t.done(latency, err)
+}
+
+// Stats returns the consumer's delivery stats.
+func (c *Consumer) Stats() ConsumerStats {
+	return c.t.get()
+}
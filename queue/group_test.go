@@ -0,0 +1,69 @@
+package queue
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestGroup(t *testing.T) {
+	g := NewGroup(NewSharded(5, 4))
+	a := g.Join()
+	if got := fmt.Sprint(a.Shards()); got != "[0 1 2 3 4]" {
+		t.Errorf("expected the only consumer to have every shard, got %s", got)
+	}
+	b := g.Join()
+	c := g.Join()
+	tests := []struct {
+		c        *Consumer
+		expected string
+	}{
+		{a, "[0 3]"},
+		{b, "[1 4]"},
+		{c, "[2]"},
+	}
+	for i, test := range tests {
+		if got := fmt.Sprint(test.c.Shards()); got != test.expected {
+			t.Errorf("%d: expected %s, got %s", i, test.expected, got)
+		}
+	}
+	b.Leave()
+	if g.Consumers() != 2 {
+		t.Errorf("expected 2 consumers, got %d", g.Consumers())
+	}
+	if len(b.Shards()) != 0 {
+		t.Errorf("expected a consumer that left to have no shards, got %v", b.Shards())
+	}
+	if got := fmt.Sprint(a.Shards(), c.Shards()); got != "[0 2 4] [1 3]" {
+		t.Errorf("expected the shards to be rebalanced, got %s", got)
+	}
+}
+
+func TestGroupDequeue(t *testing.T) {
+	s := NewSharded(4, 8)
+	for i := 0; i < 16; i++ {
+		if err := s.Enqueue(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	g := NewGroup(s)
+	consumers := []*Consumer{g.Join(), g.Join(), g.Join()}
+	seen := make(map[interface{}]int)
+	for i, c := range consumers {
+		for {
+			v, ok := c.Dequeue()
+			if !ok {
+				break
+			}
+			if j, dup := seen[v]; dup {
+				t.Errorf("%v was dequeued by consumers %d and %d", v, j, i)
+			}
+			seen[v] = i
+		}
+	}
+	if len(seen) != 16 {
+		t.Errorf("expected the consumers to dequeue all 16 items, got %d", len(seen))
+	}
+	if _, ok := NewGroup(s).Join().Dequeue(); ok {
+		t.Error("expected dequeue from empty shards to return false")
+	}
+}
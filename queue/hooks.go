@@ -0,0 +1,118 @@
+package queue
+
+// hookKind is the kind of event a hook is called for.
+type hookKind int
+
+const (
+	hookEnqueue hookKind = iota
+	hookDequeue
+	hookDrop
+	hookFull
+	hookEmpty
+)
+
+// hook is a callback registered with OnEnqueue, OnDequeue, OnDrop, OnFull, or
+// OnEmpty.
+type hook struct {
+	kind hookKind
+	fn   func(item interface{})
+}
+
+// OnEnqueue registers fn to be called with each item enqueued on the queue.
+// The returned func removes the hook.
+//
+// Hooks are a lighter alternative to a Bus for per-queue callbacks, e.g.
+// tracing and metrics: like Bus subscribers, they are called synchronously,
+// in the goroutine that caused the event, but never while the queue's lock is
+// held, so they may use the queue. They should be fast. A hook's panics are
+// recovered if the queue has a PanicHandler; see SetPanicHandler.
+func (q *Queue) OnEnqueue(fn func(item interface{})) (remove func()) {
+	return q.addHook(hookEnqueue, fn)
+}
+
+// OnDequeue registers fn to be called with each item dequeued from the queue.
+// The returned func removes the hook.
+func (q *Queue) OnDequeue(fn func(item interface{})) (remove func()) {
+	return q.addHook(hookDequeue, fn)
+}
+
+// OnDrop registers fn to be called with each item the queue drops: items that
+// are rejected, or evicted, because the queue is full. The returned func
+// removes the hook.
+func (q *Queue) OnDrop(fn func(item interface{})) (remove func()) {
+	return q.addHook(hookDrop, fn)
+}
+
+// OnEmpty registers fn to be called with the item whose dequeue left the
+// queue empty. The returned func removes the hook. The queue is checked just
+// after the dequeue; with concurrent callers it may have changed by then.
+func (q *Queue) OnEmpty(fn func(item interface{})) (remove func()) {
+	return q.addHook(hookEmpty, fn)
+}
+
+// OnFull registers fn to be called with the item whose enqueue left the queue
+// full. The returned func removes the hook. The queue is checked just after
+// the enqueue; with concurrent callers it may have changed by then.
+func (c *Circular) OnFull(fn func(item interface{})) (remove func()) {
+	return c.addHook(hookFull, fn)
+}
+
+// addHook adds a hook; the queue's hooks are copy on write, so calling them
+// doesn't take a lock.
+func (q *Queue) addHook(kind hookKind, fn func(item interface{})) func() {
+	h := &hook{kind: kind, fn: fn}
+	q.fmu.Lock()
+	var hooks []*hook
+	if p := q.hooks.Load(); p != nil {
+		hooks = append(hooks, *p...)
+	}
+	hooks = append(hooks, h)
+	q.hooks.Store(&hooks)
+	q.fmu.Unlock()
+	return func() {
+		q.fmu.Lock()
+		defer q.fmu.Unlock()
+		var hooks []*hook
+		for _, hh := range *q.hooks.Load() {
+			if hh != h {
+				hooks = append(hooks, hh)
+			}
+		}
+		q.hooks.Store(&hooks)
+	}
+}
+
+// callHooks calls the queue's hooks for an event. This must not be called
+// while holding the lock.
+func (q *Queue) callHooks(kind EventKind, item interface{}) {
+	p := q.hooks.Load()
+	if p == nil {
+		return
+	}
+	l, cp := unpack(q.state.Load())
+	ph := q.panics.Load()
+	for _, h := range *p {
+		var call bool
+		switch h.kind {
+		case hookEnqueue:
+			call = kind == EventEnqueue
+		case hookDequeue:
+			call = kind == EventDequeue
+		case hookDrop:
+			call = kind == EventDrop
+		case hookFull:
+			// only a Circular registers these, so a full slice is a full queue.
+			call = kind == EventEnqueue && l == cp
+		case hookEmpty:
+			call = kind == EventDequeue && l == 0
+		}
+		if !call {
+			continue
+		}
+		if ph == nil {
+			h.fn(item)
+			continue
+		}
+		protect(*ph, "hook", func() { h.fn(item) })
+	}
+}
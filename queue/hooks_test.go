@@ -0,0 +1,87 @@
+package queue
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	c := NewCircular(2)
+	got := make(map[string][]interface{})
+	record := func(name string) func(interface{}) {
+		return func(item interface{}) {
+			got[name] = append(got[name], item)
+			// hooks may use the queue.
+			c.Len()
+		}
+	}
+	removers := []func(){
+		c.OnEnqueue(record("enqueue")),
+		c.OnDequeue(record("dequeue")),
+		c.OnDrop(record("drop")),
+		c.OnFull(record("full")),
+		c.OnEmpty(record("empty")),
+	}
+	c.Enqueue(1)
+	c.Enqueue(2)
+	c.Enqueue(3)
+	c.Dequeue()
+	c.Dequeue()
+	c.Dequeue()
+	expected := map[string][]interface{}{
+		"enqueue": {1, 2},
+		"dequeue": {1, 2},
+		"drop":    {3},
+		"full":    {2},
+		"empty":   {2},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	for _, remove := range removers {
+		remove()
+	}
+	c.Enqueue(4)
+	c.Dequeue()
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected removed hooks not to be called, got %v", got)
+	}
+}
+
+func TestQueueHooks(t *testing.T) {
+	q := NewQueue(1)
+	var enqueued, emptied []interface{}
+	q.OnEnqueue(func(item interface{}) { enqueued = append(enqueued, item) })
+	q.OnEmpty(func(item interface{}) { emptied = append(emptied, item) })
+	q.Enqueue("a")
+	q.Enqueue("b")
+	q.Dequeue()
+	q.Dequeue()
+	if !reflect.DeepEqual(enqueued, []interface{}{"a", "b"}) {
+		t.Errorf("expected a and b to be enqueued, got %v", enqueued)
+	}
+	if !reflect.DeepEqual(emptied, []interface{}{"b"}) {
+		t.Errorf("expected b to empty the queue, got %v", emptied)
+	}
+}
+
+func TestHookPanicHandler(t *testing.T) {
+	var p panics
+	q := NewQueue(2)
+	q.SetPanicHandler(p.handle)
+	var n int
+	q.OnEnqueue(func(interface{}) { panic("boom") })
+	q.OnEnqueue(func(interface{}) { n++ })
+	if err := q.Enqueue(1); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if n != 1 {
+		t.Errorf("expected the second hook to be called, got %d", n)
+	}
+	if got := p.get(); len(got) != 1 || got[0] != "hook" {
+		t.Errorf("expected one panic in a hook, got %v", got)
+	}
+	if q.Len() != 1 {
+		t.Errorf("expected the item to be enqueued, got a len of %d", q.Len())
+	}
+}
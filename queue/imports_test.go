@@ -0,0 +1,31 @@
+package queue
+
+import (
+	"go/build"
+	"strings"
+	"testing"
+)
+
+// TestImports keeps the queue package small: it only imports the standard
+// library, and none of the I/O, networking, storage, encoding, or logging
+// parts of it. Features that need those belong in their own packages, e.g.
+// stream, qio, qlog, and httpqueue, so that users of the in-memory queues
+// don't pay for them.
+func TestImports(t *testing.T) {
+	pkg, err := build.ImportDir(".", 0)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	heavy := []string{"database", "encoding", "log", "net", "os", "plugin", "syscall"}
+	for _, path := range pkg.Imports {
+		if first, _, _ := strings.Cut(path, "/"); strings.Contains(first, ".") {
+			t.Errorf("expected only standard library imports, got %s", path)
+			continue
+		}
+		for _, prefix := range heavy {
+			if path == prefix || strings.HasPrefix(path, prefix+"/") {
+				t.Errorf("expected %s to be imported by a subpackage, not by queue", path)
+			}
+		}
+	}
+}
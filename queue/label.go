@@ -0,0 +1,46 @@
+package queue
+
+// identity is a queue's name and labels.
+type identity struct {
+	name   string
+	labels map[string]string
+}
+
+// SetName sets the queue's name and labels; both are optional. They identify
+// the queue in its events, and so in anything built on them, e.g. logs and
+// metrics, which lets the queues of a multi-queue system be told apart. The
+// labels are copied.
+//
+// The name should be set before the queue is used; events emitted before then
+// are unnamed.
+func (q *Queue) SetName(name string, labels map[string]string) {
+	id := &identity{name: name}
+	if len(labels) > 0 {
+		id.labels = make(map[string]string, len(labels))
+		for k, v := range labels {
+			id.labels[k] = v
+		}
+	}
+	q.id.Store(id)
+}
+
+// Name returns the queue's name.
+func (q *Queue) Name() string {
+	if id := q.id.Load(); id != nil {
+		return id.name
+	}
+	return ""
+}
+
+// Labels returns a copy of the queue's labels.
+func (q *Queue) Labels() map[string]string {
+	id := q.id.Load()
+	if id == nil || id.labels == nil {
+		return nil
+	}
+	labels := make(map[string]string, len(id.labels))
+	for k, v := range id.labels {
+		labels[k] = v
+	}
+	return labels
+}
@@ -0,0 +1,32 @@
+package queue
+
+import (
+	"testing"
+)
+
+func TestSetName(t *testing.T) {
+	q := NewQueue(1)
+	if q.Name() != "" || q.Labels() != nil {
+		t.Errorf("expected a new queue to be unnamed, got %q %v", q.Name(), q.Labels())
+	}
+	labels := map[string]string{"tier": "db"}
+	q.SetName("jobs", labels)
+	labels["tier"] = "web"
+	if q.Name() != "jobs" {
+		t.Errorf("expected the name to be jobs, got %q", q.Name())
+	}
+	if got := q.Labels(); got["tier"] != "db" {
+		t.Errorf("expected the labels to have been copied, got %v", got)
+	}
+
+	var got []Event
+	b := NewBus()
+	b.Subscribe(func(e Event) { got = append(got, e) })
+	c := NewCircular(1)
+	c.SetName("retries", map[string]string{"tier": "web"})
+	c.SetBus(b)
+	_ = c.Enqueue(1)
+	if len(got) != 1 || got[0].Queue != "retries" || got[0].Labels["tier"] != "web" {
+		t.Errorf("expected the event to carry the queue's name and labels, got %+v", got)
+	}
+}
@@ -0,0 +1,101 @@
+package queue
+
+import (
+	"math"
+)
+
+// Lanes is a bounded queue with two lanes, express and standard: express
+// items are always dequeued before standard ones. To keep the express lane
+// from crowding out standard traffic, it is strictly capped at a fraction of
+// the queue's capacity: an express enqueue fails when the express lane is
+// full, even if the standard lane has room.
+//
+// Each lane is FIFO. For more than two classes of traffic, or to share the
+// consumers in proportion instead of strictly, see StrictPriority and
+// Weighted.
+type Lanes struct {
+	express  *Circular
+	standard *Circular
+}
+
+// NewLanes returns Lanes with a total capacity of size, of which the received
+// fraction, rounded up, is the express lane's capacity. A fraction that isn't
+// between 0 and 1 is set to 0.1. Each lane has a capacity of at least 1.
+func NewLanes(size int, express float64) *Lanes {
+	if express <= 0 || express >= 1 {
+		express = 0.1
+	}
+	if size < 2 {
+		size = 2
+	}
+	n := int(math.Ceil(float64(size) * express))
+	if n >= size {
+		n = size - 1
+	}
+	return &Lanes{express: NewCircular(n), standard: NewCircular(size - n)}
+}
+
+// Enqueue adds an item to the standard lane. If the standard lane is full, an
+// error is returned.
+func (l *Lanes) Enqueue(item interface{}) error {
+	return l.standard.Enqueue(item)
+}
+
+// EnqueueExpress adds an item to the express lane. If the express lane is
+// full, an error is returned.
+func (l *Lanes) EnqueueExpress(item interface{}) error {
+	return l.express.Enqueue(item)
+}
+
+// Dequeue removes the next express item from the queue or, if there are
+// none, the next standard item. If the queue is empty, a false will be
+// returned.
+func (l *Lanes) Dequeue() (interface{}, bool) {
+	if v, ok := l.express.Dequeue(); ok {
+		return v, true
+	}
+	return l.standard.Dequeue()
+}
+
+// Peek returns the item that Dequeue would return, without removing it.
+func (l *Lanes) Peek() (interface{}, bool) {
+	if v, ok := l.express.Peek(); ok {
+		return v, true
+	}
+	return l.standard.Peek()
+}
+
+// Express returns the express lane.
+func (l *Lanes) Express() *Circular {
+	return l.express
+}
+
+// Standard returns the standard lane.
+func (l *Lanes) Standard() *Circular {
+	return l.standard
+}
+
+// Len returns the number of items in both lanes.
+func (l *Lanes) Len() int {
+	return l.express.Len() + l.standard.Len()
+}
+
+// Cap returns the total capacity of both lanes.
+func (l *Lanes) Cap() int {
+	return l.express.Cap() + l.standard.Cap()
+}
+
+// IsEmpty returns whether or not both lanes are empty.
+func (l *Lanes) IsEmpty() bool {
+	return l.express.IsEmpty() && l.standard.IsEmpty()
+}
+
+// IsFull returns whether or not both lanes are full.
+func (l *Lanes) IsFull() bool {
+	return l.express.IsFull() && l.standard.IsFull()
+}
+
+// Capabilities returns the queue's Capabilities.
+func (l *Lanes) Capabilities() Capabilities {
+	return Capabilities{Bounded: true, Priority: true}
+}
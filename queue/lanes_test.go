@@ -0,0 +1,62 @@
+package queue
+
+import (
+	"testing"
+)
+
+func TestNewLanes(t *testing.T) {
+	tests := []struct {
+		size               int
+		express            float64
+		expressCap, stdCap int
+	}{
+		{100, 0.1, 10, 90},
+		{10, 0.25, 3, 7},
+		{10, 0, 1, 9},
+		{10, 1, 1, 9},
+		{1, 0.5, 1, 1},
+		{2, 0.9, 1, 1},
+	}
+	for _, test := range tests {
+		l := NewLanes(test.size, test.express)
+		if l.Express().Cap() != test.expressCap || l.Standard().Cap() != test.stdCap {
+			t.Errorf("%d %g: expected caps %d and %d, got %d and %d", test.size, test.express, test.expressCap, test.stdCap, l.Express().Cap(), l.Standard().Cap())
+		}
+		if l.Cap() != test.expressCap+test.stdCap {
+			t.Errorf("%d %g: expected cap %d, got %d", test.size, test.express, test.expressCap+test.stdCap, l.Cap())
+		}
+	}
+}
+
+func TestLanes(t *testing.T) {
+	l := NewLanes(10, 0.2)
+	for i := 0; i < 3; i++ {
+		if err := l.Enqueue(i); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	for _, v := range []string{"a", "b"} {
+		if err := l.EnqueueExpress(v); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	// the express lane is strictly capped.
+	if err := l.EnqueueExpress("c"); err == nil {
+		t.Error("expected an error enqueueing to a full express lane")
+	}
+	if l.Len() != 5 || l.IsFull() || l.IsEmpty() {
+		t.Errorf("expected 5 items, got %d", l.Len())
+	}
+	// express items are served first.
+	for _, expected := range []interface{}{"a", "b", 0, 1, 2} {
+		if v, ok := l.Peek(); !ok || v != expected {
+			t.Errorf("peek: expected %v true, got %v %t", expected, v, ok)
+		}
+		if v, ok := l.Dequeue(); !ok || v != expected {
+			t.Errorf("expected %v true, got %v %t", expected, v, ok)
+		}
+	}
+	if _, ok := l.Dequeue(); ok || !l.IsEmpty() {
+		t.Error("expected the lanes to be empty")
+	}
+}
@@ -0,0 +1,111 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter limits the rate at which items are dequeued from a queue, e.g. a
+// queue of calls to a rate limited API, so that the queue's consumers don't
+// each need their own rate limiter. It is a token bucket: at most n items are
+// released per interval, and up to n can be released at once after the
+// limiter has been idle. Unlike a Pacer, the rate is fixed.
+type Limiter struct {
+	q      Dequeuer
+	mu     sync.Mutex
+	burst  float64   // the most tokens the bucket holds.
+	rate   float64   // tokens per second.
+	tokens float64   // releases available.
+	last   time.Time // when tokens was last refilled.
+}
+
+// NewLimiter returns a Limiter that releases at most n items from q per
+// interval. The bucket starts full. An n < 1 is set to 1 and an interval <=
+// 0 is set to 1s.
+func NewLimiter(q Dequeuer, n int, per time.Duration) *Limiter {
+	if n < 1 {
+		n = 1
+	}
+	if per <= 0 {
+		per = time.Second
+	}
+	return &Limiter{q: q, burst: float64(n), rate: float64(n) / per.Seconds(), tokens: float64(n), last: time.Now()}
+}
+
+// refill adds the releases earned since the last refill and returns how long
+// until the next release is available. The caller must hold the lock.
+func (l *Limiter) refill(now time.Time) time.Duration {
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	if l.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+// Dequeue dequeues an item from the queue if the rate allows a release now.
+// If it doesn't, or the queue is empty, a false will be returned.
+func (l *Limiter) Dequeue() (interface{}, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.refill(time.Now()) > 0 {
+		return nil, false
+	}
+	v, ok := l.q.Dequeue()
+	if ok {
+		l.tokens--
+	}
+	return v, ok
+}
+
+// DequeueCtx dequeues an item from the queue, waiting for the rate to allow a
+// release and for the queue to have an item, until ctx is done. If ctx is
+// done first, ctx's error is returned.
+func (l *Limiter) DequeueCtx(ctx context.Context) (interface{}, error) {
+	for {
+		l.mu.Lock()
+		wait := l.refill(time.Now())
+		if wait == 0 {
+			if v, ok := l.q.Dequeue(); ok {
+				l.tokens--
+				l.mu.Unlock()
+				return v, nil
+			}
+			// the queue is empty: poll it like Select does.
+			wait = minPoll
+		}
+		l.mu.Unlock()
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// DequeueWait dequeues an item from the queue, waiting up to timeout for the
+// rate to allow a release and for the queue to have an item. If the timeout
+// passes first, a false will be returned; a timeout <= 0 doesn't wait.
+func (l *Limiter) DequeueWait(timeout time.Duration) (interface{}, bool) {
+	if timeout <= 0 {
+		return l.Dequeue()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	v, err := l.DequeueCtx(ctx)
+	return v, err == nil
+}
+
+// Tokens returns the number of items that can be released now.
+func (l *Limiter) Tokens() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill(time.Now())
+	return int(l.tokens)
+}
@@ -0,0 +1,66 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLimiterDequeue(t *testing.T) {
+	q := NewQueue(0)
+	for i := 0; i < 10; i++ {
+		q.Enqueue(i)
+	}
+	l := NewLimiter(q, 3, 30*time.Millisecond)
+	if l.Tokens() != 3 {
+		t.Errorf("expected the bucket to start full, got %d tokens", l.Tokens())
+	}
+	// the burst is released at once; the next release is 10ms later.
+	for i := 0; i < 3; i++ {
+		if v, ok := l.Dequeue(); !ok || v != i {
+			t.Errorf("expected %d true, got %v %t", i, v, ok)
+		}
+	}
+	if v, ok := l.Dequeue(); ok {
+		t.Errorf("expected the rate to hold back the next item, got %v", v)
+	}
+	if v, ok := l.DequeueWait(0); ok {
+		t.Errorf("expected a DequeueWait that doesn't wait to be held back, got %v", v)
+	}
+	if v, ok := l.DequeueWait(time.Second); !ok || v != 3 {
+		t.Errorf("expected 3 true, got %v %t", v, ok)
+	}
+	if q.Len() != 6 {
+		t.Errorf("expected the held back items to stay queued, got %d", q.Len())
+	}
+}
+
+func TestLimiterDequeueCtx(t *testing.T) {
+	q := NewQueue(0)
+	l := NewLimiter(q, 1, 20*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.DequeueCtx(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected %v from an empty queue, got %v", context.DeadlineExceeded, err)
+	}
+	for i := 0; i < 4; i++ {
+		q.Enqueue(i)
+	}
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if v, err := l.DequeueCtx(context.Background()); err != nil || v != i {
+			t.Errorf("expected %d, got %v %v", i, v, err)
+		}
+	}
+	// the first release is available at once, the other three are paced.
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Errorf("expected 4 items at 1 per 20ms to take at least 50ms, took %s", d)
+	}
+}
+
+func TestNewLimiterDefaults(t *testing.T) {
+	l := NewLimiter(NewQueue(0), 0, 0)
+	if l.burst != 1 || l.rate != 1 {
+		t.Errorf("expected 1 item per second, got a burst of %g at %g/s", l.burst, l.rate)
+	}
+}
@@ -0,0 +1,76 @@
+package queue
+
+import (
+	"fmt"
+)
+
+// ToSlice returns the items in the queue, in FIFO order; it is the same as
+// Snapshot. Load restores a queue from it.
+func (q *Queue) ToSlice() []interface{} {
+	return q.Snapshot()
+}
+
+// Load replaces the contents of the queue with the received items, in FIFO
+// order, e.g. to restore a queue from a ToSlice taken before a restart. The
+// items are copied. The queue publishes a reset event followed by an enqueue
+// event for each item.
+func (q *Queue) Load(items []interface{}) error {
+	q.Lock()
+	q.load(items)
+	q.Unlock()
+	q.emitLoad(items)
+	return nil
+}
+
+// load is the unexported version of Load; the caller must hold the lock.
+func (q *Queue) load(items []interface{}) {
+	q.reset()
+	clear(q.items[:cap(q.items)])
+	q.items = append(q.items, items...)
+	for _, item := range items {
+		q.account(item, 1)
+	}
+	q.publish()
+}
+
+// emitLoad emits the events for a Load.
+func (q *Queue) emitLoad(items []interface{}) {
+	q.emit(EventReset, nil)
+	for _, item := range items {
+		q.emit(EventEnqueue, item)
+	}
+}
+
+// ToSlice returns the items in the queue, in FIFO order; it is the same as
+// Snapshot. Load restores a queue from it.
+func (c *Circular) ToSlice() []interface{} {
+	return c.Snapshot()
+}
+
+// Load replaces the contents of the queue with the received items; see
+// Queue.Load. If there are more items than the queue's capacity, an error is
+// returned and the queue is not changed.
+func (c *Circular) Load(items []interface{}) error {
+	c.Lock()
+	if len(items) > cap(c.items)-1 {
+		c.Unlock()
+		return fmt.Errorf("queue full: cannot load %d items into a queue with a cap of %d", len(items), cap(c.items)-1)
+	}
+	c.load(items)
+	c.Unlock()
+	c.emitLoad(items)
+	return nil
+}
+
+// load is the unexported version of Load; the caller must hold the lock and
+// the items must fit in the queue.
+func (c *Circular) load(items []interface{}) {
+	c.reset()
+	c.items = c.items[:cap(c.items)]
+	clear(c.items[copy(c.items, items):])
+	for _, item := range items {
+		c.account(item, 1)
+	}
+	c.tail = len(items)
+	c.publish()
+}
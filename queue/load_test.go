@@ -0,0 +1,95 @@
+package queue
+
+import (
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	items := []interface{}{1, 2, 3}
+	tests := []struct {
+		name string
+		q    interface {
+			Enqueue(interface{}) error
+			Dequeue() (interface{}, bool)
+			ToSlice() []interface{}
+			Load([]interface{}) error
+			Len() int
+		}
+		err bool
+	}{
+		{"queue", NewQueue(1), false},
+		{"circular", NewCircular(4), false},
+		{"circular too small", NewCircular(2), true},
+	}
+	for _, test := range tests {
+		q := test.q
+		q.Enqueue("old")
+		q.Enqueue("old")
+		q.Dequeue()
+		err := q.Load(items)
+		if (err != nil) != test.err {
+			t.Errorf("%s: expected error %t, got %v", test.name, test.err, err)
+		}
+		if test.err {
+			if got := q.ToSlice(); len(got) != 1 || got[0] != "old" {
+				t.Errorf("%s: expected a failed load not to change the queue, got %v", test.name, got)
+			}
+			continue
+		}
+		got := q.ToSlice()
+		if len(got) != len(items) || q.Len() != len(items) {
+			t.Fatalf("%s: expected %v, got %v", test.name, items, got)
+		}
+		for i := range items {
+			if got[i] != items[i] {
+				t.Errorf("%s: expected %v, got %v", test.name, items, got)
+				break
+			}
+		}
+		// the items were copied and the queue works as usual.
+		items[0] = 100
+		q.Enqueue(4)
+		for _, expected := range []interface{}{1, 2, 3, 4} {
+			if v, ok := q.Dequeue(); !ok || v != expected {
+				t.Errorf("%s: expected %v true, got %v %t", test.name, expected, v, ok)
+			}
+		}
+		items[0] = 1
+	}
+}
+
+func TestLoadRoundTrip(t *testing.T) {
+	// a wrapped circular queue restores in logical order.
+	c := NewCircular(4)
+	for i := 0; i < 3; i++ {
+		c.Enqueue(-1)
+	}
+	c.DequeueN(3)
+	for i := 0; i < 4; i++ {
+		c.Enqueue(i)
+	}
+	b := NewBus()
+	var kinds []EventKind
+	b.Subscribe(func(e Event) { kinds = append(kinds, e.Kind) })
+	restored := NewCircular(4)
+	restored.SetBus(b)
+	restored.SetSizer(func(interface{}) int { return 2 })
+	if err := restored.Load(c.ToSlice()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if h, tl := restored.Positions(); h != 0 || tl != 4 {
+		t.Errorf("expected positions 0 4, got %d %d", h, tl)
+	}
+	if !restored.IsFull() || restored.Bytes() != 8 {
+		t.Errorf("expected a full queue of 8 bytes, got len %d, %d bytes", restored.Len(), restored.Bytes())
+	}
+	expected := []EventKind{EventReset, EventEnqueue, EventEnqueue, EventEnqueue, EventEnqueue}
+	if len(kinds) != len(expected) {
+		t.Fatalf("expected events %v, got %v", expected, kinds)
+	}
+	for i := 0; i < 4; i++ {
+		if v, _ := restored.Dequeue(); v != i {
+			t.Errorf("expected %d, got %v", i, v)
+		}
+	}
+}
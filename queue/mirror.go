@@ -0,0 +1,159 @@
+package queue
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Mismatch describes an operation whose result on a Mirror's secondary queue
+// differed from its result on the primary.
+type Mismatch struct {
+	Op        string      // the operation: "enqueue", "dequeue", or "peek".
+	Item      interface{} // the item being enqueued, if Op is "enqueue".
+	Primary   Result      // the primary's result.
+	Secondary Result      // the secondary's result.
+}
+
+// Result is the result of an operation on one of a Mirror's queues. For
+// enqueues, OK is whether or not the enqueue succeeded.
+type Result struct {
+	Item interface{}
+	OK   bool
+}
+
+// Mirror applies every operation to two queues: the primary and the
+// secondary. The primary's results are always the ones returned; the
+// secondary is a warm standby copy of the primary, e.g. for failover, or a
+// new queue implementation being validated against an existing one.
+//
+// If the results of an enqueue, dequeue, or peek differ between the two
+// queues, the mismatch func, if there is one, is called. It is called while
+// the Mirror's lock is held, so it must not call the Mirror. Items are
+// compared with reflect.DeepEqual, so items that aren't comparable, e.g.
+// []byte, can be mirrored; SetEqual changes how items are compared.
+type Mirror struct {
+	mu        sync.Mutex
+	primary   Queuer
+	secondary Queuer
+	mismatch  func(Mismatch)
+	equal     func(a, b interface{}) bool // nil uses reflect.DeepEqual; see SetEqual.
+	panics    PanicHandler
+}
+
+// NewMirror returns a Mirror of the two queues. Both queues should start out
+// with the same contents and should only be used through the Mirror; a nil
+// mismatch func is allowed.
+func NewMirror(primary, secondary Queuer, mismatch func(Mismatch)) *Mirror {
+	return &Mirror{primary: primary, secondary: secondary, mismatch: mismatch}
+}
+
+// Primary returns the primary queue.
+func (m *Mirror) Primary() Queuer {
+	return m.primary
+}
+
+// Secondary returns the secondary queue.
+func (m *Mirror) Secondary() Queuer {
+	return m.secondary
+}
+
+// SetEqual sets the func used to compare the items returned by the two
+// queues. A nil func, the default, uses reflect.DeepEqual.
+func (m *Mirror) SetEqual(fn func(a, b interface{}) bool) {
+	m.mu.Lock()
+	m.equal = fn
+	m.mu.Unlock()
+}
+
+// compare calls the mismatch func if p and s differ. The lock must be held.
+func (m *Mirror) compare(op string, item interface{}, p, s Result) {
+	if m.mismatch == nil {
+		return
+	}
+	equal := m.equal
+	if equal == nil {
+		equal = reflect.DeepEqual
+	}
+	if p.OK == s.OK && equal(p.Item, s.Item) {
+		return
+	}
+	protect(m.panics, "mismatch", func() {
+		m.mismatch(Mismatch{Op: op, Item: item, Primary: p, Secondary: s})
+	})
+}
+
+// SetPanicHandler sets the handler that panics in the mismatch func are
+// reported to; with a handler, a panicking mismatch func is recovered and
+// the operation's result is returned as usual. A nil handler, the default,
+// doesn't recover panics.
+func (m *Mirror) SetPanicHandler(h PanicHandler) {
+	m.mu.Lock()
+	m.panics = h
+	m.mu.Unlock()
+}
+
+// Enqueue enqueues the item on both queues and returns the primary's error.
+func (m *Mirror) Enqueue(item interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	err := m.primary.Enqueue(item)
+	serr := m.secondary.Enqueue(item)
+	m.compare("enqueue", item, Result{OK: err == nil}, Result{OK: serr == nil})
+	return err
+}
+
+// Dequeue dequeues an item from both queues and returns the primary's item.
+func (m *Mirror) Dequeue() (interface{}, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.primary.Dequeue()
+	sv, sok := m.secondary.Dequeue()
+	m.compare("dequeue", nil, Result{v, ok}, Result{sv, sok})
+	return v, ok
+}
+
+// Peek peeks at both queues and returns the primary's next item.
+func (m *Mirror) Peek() (interface{}, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.primary.Peek()
+	sv, sok := m.secondary.Peek()
+	m.compare("peek", nil, Result{v, ok}, Result{sv, sok})
+	return v, ok
+}
+
+// IsEmpty returns whether or not the primary is empty.
+func (m *Mirror) IsEmpty() bool {
+	return m.primary.IsEmpty()
+}
+
+// IsFull returns whether or not the primary is full.
+func (m *Mirror) IsFull() bool {
+	return m.primary.IsFull()
+}
+
+// Len returns the number of items in the primary.
+func (m *Mirror) Len() int {
+	return m.primary.Len()
+}
+
+// Cap returns the capacity of the primary.
+func (m *Mirror) Cap() int {
+	return m.primary.Cap()
+}
+
+// Reset resets both queues.
+func (m *Mirror) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.primary.Reset()
+	m.secondary.Reset()
+}
+
+// Resize resizes both queues and returns the primary's new capacity.
+func (m *Mirror) Resize(size int) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.secondary.Resize(size)
+	return m.primary.Resize(size)
+}
@@ -0,0 +1,77 @@
+package queue
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMirror(t *testing.T) {
+	var mismatches []Mismatch
+	m := NewMirror(NewQueue(2), NewCircular(2), func(mm Mismatch) {
+		mismatches = append(mismatches, mm)
+	})
+	var _ Queuer = m
+	for i := 0; i < 3; i++ {
+		if err := m.Enqueue(i); err != nil {
+			t.Errorf("%d: expected the primary's enqueue to succeed, got %s", i, err)
+		}
+	}
+	// the secondary is bounded, the third enqueue should have mismatched.
+	if len(mismatches) != 1 {
+		t.Fatalf("expected 1 mismatch, got %d", len(mismatches))
+	}
+	expected := Mismatch{Op: "enqueue", Item: 2, Primary: Result{OK: true}, Secondary: Result{OK: false}}
+	if mismatches[0] != expected {
+		t.Errorf("expected %+v, got %+v", expected, mismatches[0])
+	}
+	for i := 0; i < 2; i++ {
+		v, ok := m.Dequeue()
+		if !ok || v != i {
+			t.Errorf("%d: expected %d true, got %v %t", i, i, v, ok)
+		}
+	}
+	if len(mismatches) != 1 {
+		t.Errorf("expected 1 mismatch, got %d", len(mismatches))
+	}
+	v, ok := m.Peek()
+	if !ok || v != 2 {
+		t.Errorf("expected 2 true, got %v %t", v, ok)
+	}
+	expected = Mismatch{Op: "peek", Primary: Result{2, true}, Secondary: Result{nil, false}}
+	if len(mismatches) != 2 || mismatches[1] != expected {
+		t.Errorf("expected the second mismatch to be %+v, got %+v", expected, mismatches)
+	}
+	m.Reset()
+	if !m.Primary().IsEmpty() || !m.Secondary().IsEmpty() {
+		t.Error("expected reset to empty both queues")
+	}
+}
+
+func TestMirrorBytes(t *testing.T) {
+	var mismatches []Mismatch
+	m := NewMirror(NewQueue(2), NewQueue(2), func(mm Mismatch) {
+		mismatches = append(mismatches, mm)
+	})
+	_ = m.Enqueue([]byte("a"))
+	_ = m.Enqueue(map[string]int{"b": 1})
+	_ = m.Secondary().Enqueue([]byte("c"))
+	_ = m.Primary().Enqueue([]byte("d"))
+	for i := 0; i < 3; i++ {
+		m.Dequeue()
+	}
+	if len(mismatches) != 1 {
+		t.Fatalf("expected 1 mismatch, got %d: %+v", len(mismatches), mismatches)
+	}
+	if mismatches[0].Op != "dequeue" || !bytes.Equal(mismatches[0].Primary.Item.([]byte), []byte("d")) {
+		t.Errorf("expected the third dequeue to mismatch, got %+v", mismatches[0])
+	}
+
+	mismatches = nil
+	m.SetEqual(func(a, b interface{}) bool { return true })
+	_ = m.Primary().Enqueue([]byte("e"))
+	_ = m.Secondary().Enqueue([]byte("f"))
+	m.Dequeue()
+	if len(mismatches) != 0 {
+		t.Errorf("expected the equal func to be used, got %d mismatches", len(mismatches))
+	}
+}
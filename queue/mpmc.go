@@ -0,0 +1,135 @@
+package queue
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// cell is a slot in an MPMC queue. seq tells producers and consumers whose
+// turn it is: a producer may fill the cell when seq equals its position, a
+// consumer may empty it when seq is the position + 1.
+type cell struct {
+	seq  atomic.Uint64
+	item interface{}
+}
+
+// cacheLinePad keeps the fields on either side of it on separate cache lines
+// so that producers and consumers don't contend on the same line.
+type cacheLinePad [64]byte
+
+// MPMC is a bounded, lock-free, multi-producer/multi-consumer queue. Unlike
+// Circular, producers and consumers don't share a lock: each claims a slot
+// with a compare-and-swap, so throughput holds up with many goroutines
+// enqueueing and dequeueing at once.
+//
+// The capacity is rounded up to a power of two.
+//
+// This is an implementation of Dmitry Vyukov's bounded MPMC queue. As with
+// MPSC, all of its atomic operations are sequentially consistent; everything
+// a producer writes to an item before enqueueing it happens before the Dequeue
+// of that item returns.
+type MPMC struct {
+	_     cacheLinePad
+	tail  atomic.Uint64 // the next position to enqueue at.
+	_     cacheLinePad
+	head  atomic.Uint64 // the next position to dequeue from.
+	_     cacheLinePad
+	mask  uint64
+	cells []cell
+}
+
+// NewMPMC returns an empty MPMC queue that holds at least size items; the
+// capacity is size rounded up to a power of two. A size < 2 is set to 2.
+func NewMPMC(size int) *MPMC {
+	n := uint64(2)
+	for n < uint64(size) {
+		n <<= 1
+	}
+	q := &MPMC{mask: n - 1, cells: make([]cell, n)}
+	for i := range q.cells {
+		q.cells[i].seq.Store(uint64(i))
+	}
+	return q
+}
+
+// Enqueue adds an item to the queue. If the queue is full, an error is
+// returned. This never blocks.
+func (q *MPMC) Enqueue(item interface{}) error {
+	pos := q.tail.Load()
+	for {
+		c := &q.cells[pos&q.mask]
+		seq := c.seq.Load()
+		switch d := int64(seq - pos); {
+		case d == 0:
+			if q.tail.CompareAndSwap(pos, pos+1) {
+				c.item = item
+				c.seq.Store(pos + 1)
+				return nil
+			}
+			pos = q.tail.Load()
+		case d < 0:
+			// the cell still holds the item from a lap ago.
+			return fmt.Errorf("queue full: cannot enqueue %v", item)
+		default:
+			// another producer took pos.
+			pos = q.tail.Load()
+		}
+	}
+}
+
+// Dequeue removes the oldest item from the queue and returns it. If the queue
+// is empty, a false will be returned. This never blocks.
+func (q *MPMC) Dequeue() (interface{}, bool) {
+	pos := q.head.Load()
+	for {
+		c := &q.cells[pos&q.mask]
+		seq := c.seq.Load()
+		switch d := int64(seq - (pos + 1)); {
+		case d == 0:
+			if q.head.CompareAndSwap(pos, pos+1) {
+				item := c.item
+				c.item = nil
+				c.seq.Store(pos + q.mask + 1)
+				return item, true
+			}
+			pos = q.head.Load()
+		case d < 0:
+			// the cell hasn't been filled yet.
+			return nil, false
+		default:
+			// another consumer took pos.
+			pos = q.head.Load()
+		}
+	}
+}
+
+// Len returns the number of items in the queue. While items are being
+// enqueued and dequeued this is approximate: it may include items whose
+// enqueue hasn't finished, or exclude items whose dequeue hasn't finished.
+func (q *MPMC) Len() int {
+	for {
+		head := q.head.Load()
+		tail := q.tail.Load()
+		if q.head.Load() == head {
+			if tail < head {
+				return 0
+			}
+			return int(tail - head)
+		}
+	}
+}
+
+// Cap returns the capacity of the queue.
+func (q *MPMC) Cap() int {
+	return len(q.cells)
+}
+
+// IsEmpty returns whether or not the queue is empty.
+func (q *MPMC) IsEmpty() bool {
+	return q.Len() == 0
+}
+
+// IsFull returns whether or not the queue is full.
+func (q *MPMC) IsFull() bool {
+	return q.Len() >= len(q.cells)
+}
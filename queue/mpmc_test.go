@@ -0,0 +1,142 @@
+package queue
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMPMC(t *testing.T) {
+	tests := []struct {
+		size, cap int
+	}{
+		{0, 2},
+		{1, 2},
+		{2, 2},
+		{3, 4},
+		{100, 128},
+	}
+	for _, test := range tests {
+		q := NewMPMC(test.size)
+		if q.Cap() != test.cap {
+			t.Errorf("%d: expected cap %d, got %d", test.size, test.cap, q.Cap())
+		}
+		if !q.IsEmpty() {
+			t.Errorf("%d: expected a new queue to be empty", test.size)
+		}
+		// go around the ring a few times.
+		var next, expected int
+		for lap := 0; lap < 3; lap++ {
+			for i := 0; i < test.cap; i++ {
+				if err := q.Enqueue(next); err != nil {
+					t.Fatalf("%d: unexpected error: %s", test.size, err)
+				}
+				next++
+			}
+			if !q.IsFull() || q.Len() != test.cap {
+				t.Errorf("%d: expected the queue to be full, got len %d", test.size, q.Len())
+			}
+			if err := q.Enqueue(next); err == nil {
+				t.Errorf("%d: expected enqueue to a full queue to fail", test.size)
+			}
+			for i := 0; i < test.cap; i++ {
+				v, ok := q.Dequeue()
+				if !ok || v != expected {
+					t.Fatalf("%d: expected %d true, got %v %t", test.size, expected, v, ok)
+				}
+				expected++
+			}
+			if v, ok := q.Dequeue(); ok {
+				t.Errorf("%d: expected dequeue of an empty queue to fail, got %v", test.size, v)
+			}
+		}
+	}
+}
+
+func TestMPMCConcurrent(t *testing.T) {
+	const producers, consumers, n = 8, 8, 2000
+	q := NewMPMC(64)
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				for q.Enqueue([2]int{p, i}) != nil {
+					runtime.Gosched()
+				}
+			}
+		}(p)
+	}
+	results := make(chan [][2]int, consumers)
+	var done sync.WaitGroup
+	var remaining atomic.Int64
+	remaining.Store(producers * n)
+	for c := 0; c < consumers; c++ {
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			var got [][2]int
+			for remaining.Load() > 0 {
+				v, ok := q.Dequeue()
+				if !ok {
+					runtime.Gosched()
+					continue
+				}
+				remaining.Add(-1)
+				got = append(got, v.([2]int))
+			}
+			results <- got
+		}()
+	}
+	wg.Wait()
+	done.Wait()
+	close(results)
+	// every item is dequeued exactly once, and each consumer sees each
+	// producer's items in order.
+	seen := make(map[[2]int]bool)
+	for got := range results {
+		last := make(map[int]int)
+		for _, v := range got {
+			if seen[v] {
+				t.Fatalf("%v dequeued twice", v)
+			}
+			seen[v] = true
+			if l, ok := last[v[0]]; ok && v[1] <= l {
+				t.Fatalf("producer %d: %d dequeued after %d", v[0], v[1], l)
+			}
+			last[v[0]] = v[1]
+		}
+	}
+	if len(seen) != producers*n {
+		t.Errorf("expected %d items, got %d", producers*n, len(seen))
+	}
+	if !q.IsEmpty() {
+		t.Errorf("expected the queue to be empty, got %d", q.Len())
+	}
+}
+
+func benchmarkContended(b *testing.B, q interface {
+	Enqueue(interface{}) error
+	Dequeue() (interface{}, bool)
+}) {
+	b.ReportAllocs()
+	b.SetParallelism(4)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			for q.Enqueue(payload) != nil {
+				q.Dequeue()
+			}
+			q.Dequeue()
+		}
+	})
+}
+
+func BenchmarkMPMCContended(b *testing.B) {
+	benchmarkContended(b, NewMPMC(1024))
+}
+
+func BenchmarkCircularContended(b *testing.B) {
+	benchmarkContended(b, NewCircular(1024))
+}
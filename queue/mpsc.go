@@ -0,0 +1,123 @@
+package queue
+
+import "sync/atomic"
+
+// Node is the link used by the intrusive MPSC queue. Types that are to be
+// enqueued on an MPSC embed a Node; any pointer to a type embedding a Node
+// satisfies Noder. A Node can only be on one queue at a time and must not be
+// re-enqueued until it has been dequeued.
+type Node struct {
+	next atomic.Pointer[Node]
+	item Noder
+}
+
+// mpscNode returns the Node itself; this is what lets types embedding a
+// Node satisfy Noder.
+func (n *Node) mpscNode() *Node {
+	return n
+}
+
+// Noder is implemented by anything that embeds a Node.
+type Noder interface {
+	mpscNode() *Node
+}
+
+// MPSC is an unbounded, intrusive, multi-producer/single-consumer queue. The
+// queue's links live in the Node embedded in each item so nothing is
+// allocated on Enqueue.
+//
+// Enqueue is safe for concurrent use by any number of goroutines. Dequeue
+// must only be called by one goroutine at a time, the consumer.
+//
+// This is an implementation of Dmitry Vyukov's intrusive MPSC node-based
+// queue.
+//
+// Memory ordering: all of the queue's atomic operations use sync/atomic,
+// which the Go memory model defines as sequentially consistent; Go has no
+// relaxed or acquire/release atomics, so there is no weaker mode to select.
+// Everything a producer writes to an item before enqueueing it happens before
+// the consumer's Dequeue of that item returns.
+type MPSC struct {
+	head atomic.Pointer[Node] // the most recently enqueued node; producer side.
+	tail *Node                // the next node to dequeue; consumer side.
+	stub Node
+	len  atomic.Int64
+}
+
+// NewMPSC returns an empty MPSC queue.
+func NewMPSC() *MPSC {
+	q := &MPSC{}
+	q.head.Store(&q.stub)
+	q.tail = &q.stub
+	return q
+}
+
+// Enqueue adds an item to the queue. This never blocks and never fails.
+func (q *MPSC) Enqueue(item Noder) {
+	n := item.mpscNode()
+	n.item = item
+	// Len may briefly over-report an item that is still being linked in,
+	// but it will never under-report.
+	q.len.Add(1)
+	q.push(n)
+}
+
+// push links n in as the newest node.
+func (q *MPSC) push(n *Node) {
+	n.next.Store(nil)
+	prev := q.head.Swap(n)
+	prev.next.Store(n)
+}
+
+// Dequeue removes the oldest item from the queue and returns it. If the queue
+// is empty, a false will be returned. A false may also be returned if the only
+// item in the queue is still being linked in by its producer; getting a false
+// does not mean that Len is 0.
+//
+// Dequeue must only be called by the consumer.
+func (q *MPSC) Dequeue() (Noder, bool) {
+	tail := q.tail
+	next := tail.next.Load()
+	if tail == &q.stub {
+		if next == nil {
+			return nil, false
+		}
+		q.tail = next
+		tail = next
+		next = next.next.Load()
+	}
+	if next != nil {
+		q.tail = next
+		return q.release(tail), true
+	}
+	// A producer has swapped head but not linked it in yet.
+	if tail != q.head.Load() {
+		return nil, false
+	}
+	// tail is the last node; put the stub behind it so tail can be released.
+	q.push(&q.stub)
+	next = tail.next.Load()
+	if next != nil {
+		q.tail = next
+		return q.release(tail), true
+	}
+	return nil, false
+}
+
+// release clears n's reference to its item and returns the item.
+func (q *MPSC) release(n *Node) Noder {
+	item := n.item
+	n.item = nil
+	q.len.Add(-1)
+	return item
+}
+
+// IsEmpty returns whether or not the queue is empty.
+func (q *MPSC) IsEmpty() bool {
+	return q.len.Load() == 0
+}
+
+// Len returns the current number of items in the queue.
+func (q *MPSC) Len() int {
+	return int(q.len.Load())
+}
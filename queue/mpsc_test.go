@@ -0,0 +1,93 @@
+package queue
+
+import (
+	"sync"
+	"testing"
+)
+
+type mpscItem struct {
+	Node
+	producer int
+	seq      int
+}
+
+func TestMPSC(t *testing.T) {
+	q := NewMPSC()
+	if !q.IsEmpty() {
+		t.Error("expected new queue to be empty")
+	}
+	if _, ok := q.Dequeue(); ok {
+		t.Error("expected dequeue of an empty queue to return false")
+	}
+	items := make([]mpscItem, 5)
+	for i := range items {
+		items[i].seq = i
+		q.Enqueue(&items[i])
+	}
+	if q.Len() != 5 {
+		t.Errorf("expected len to be 5, got %d", q.Len())
+	}
+	for i := 0; i < 5; i++ {
+		v, ok := q.Dequeue()
+		if !ok {
+			t.Errorf("%d: expected dequeue to return true, got false", i)
+			continue
+		}
+		if v.(*mpscItem).seq != i {
+			t.Errorf("%d: expected %d, got %d", i, i, v.(*mpscItem).seq)
+		}
+	}
+	if !q.IsEmpty() {
+		t.Errorf("expected queue to be empty, len was %d", q.Len())
+	}
+	// nodes can be reused once they've been dequeued.
+	q.Enqueue(&items[0])
+	v, ok := q.Dequeue()
+	if !ok || v.(*mpscItem) != &items[0] {
+		t.Errorf("expected the requeued item to be dequeued, got %v %t", v, ok)
+	}
+}
+
+func TestMPSCConcurrent(t *testing.T) {
+	const producers, n = 8, 1000
+	q := NewMPSC()
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				q.Enqueue(&mpscItem{producer: p, seq: i})
+			}
+		}(p)
+	}
+	next := make([]int, producers)
+	for got := 0; got < producers*n; {
+		v, ok := q.Dequeue()
+		if !ok {
+			continue
+		}
+		it := v.(*mpscItem)
+		if it.seq != next[it.producer] {
+			t.Fatalf("producer %d: expected seq %d, got %d", it.producer, next[it.producer], it.seq)
+		}
+		next[it.producer]++
+		got++
+	}
+	wg.Wait()
+	if q.Len() != 0 {
+		t.Errorf("expected len to be 0, got %d", q.Len())
+	}
+}
+
+func TestMPSCAllocs(t *testing.T) {
+	q := NewMPSC()
+	it := &mpscItem{}
+	allocs := testing.AllocsPerRun(100, func() {
+		q.Enqueue(it)
+		q.Dequeue()
+	})
+	if allocs != 0 {
+		t.Errorf("expected enqueue/dequeue to not allocate, got %v allocs", allocs)
+	}
+}
@@ -0,0 +1,231 @@
+package queue
+
+import (
+	"sync"
+)
+
+// tenant is one of a MultiQueue's sub-queues.
+type tenant struct {
+	key string
+	q   interface {
+		Queuer
+		Stats() Stats
+		SetStats(bool)
+	}
+	weight  int
+	current int // the smooth weighted round-robin's running weight.
+	skip    bool
+}
+
+// MultiQueue is a queue made up of named sub-queues, e.g. one per tenant, that
+// dequeues across them fairly, so that one noisy tenant can't starve the
+// others: each non-empty sub-queue gets a share of the dequeues in proportion
+// to its weight. Every sub-queue starts with a weight of 1, i.e. round-robin;
+// SetWeight changes it. Items are FIFO within a sub-queue. Sub-queues are
+// created the first time their key is used.
+//
+// Like Weighted, MultiQueue uses smooth weighted round-robin, so a sub-queue's
+// turns are spread out instead of being served in a burst.
+type MultiQueue struct {
+	mu      sync.Mutex
+	size    int
+	key     func(item interface{}) string
+	tenants []*tenant
+	index   map[string]*tenant
+}
+
+// NewMultiQueue returns an empty MultiQueue whose sub-queues are circular
+// queues that hold size items; a size <= 0 makes them unbounded. key returns
+// the key of the sub-queue an item passed to Enqueue goes to; a nil key puts
+// every item in the "" sub-queue.
+func NewMultiQueue(size int, key func(item interface{}) string) *MultiQueue {
+	return &MultiQueue{size: size, key: key, index: make(map[string]*tenant)}
+}
+
+// tenant returns the sub-queue for key, creating it if it doesn't exist. The
+// caller must hold the lock.
+func (m *MultiQueue) tenant(key string) *tenant {
+	if t, ok := m.index[key]; ok {
+		return t
+	}
+	t := &tenant{key: key, weight: 1}
+	if m.size > 0 {
+		t.q = NewCircular(m.size)
+	} else {
+		t.q = NewQueue(0)
+	}
+	t.q.SetStats(true)
+	m.tenants = append(m.tenants, t)
+	m.index[key] = t
+	return t
+}
+
+// Enqueue adds an item to the sub-queue that the queue's key func selects. If
+// the sub-queue is full, an error is returned.
+func (m *MultiQueue) Enqueue(item interface{}) error {
+	var key string
+	if m.key != nil {
+		key = m.key(item)
+	}
+	return m.EnqueueKey(key, item)
+}
+
+// EnqueueKey adds an item to the key sub-queue. If the sub-queue is full, an
+// error is returned.
+func (m *MultiQueue) EnqueueKey(key string, item interface{}) error {
+	m.mu.Lock()
+	t := m.tenant(key)
+	m.mu.Unlock()
+	return t.q.Enqueue(item)
+}
+
+// SetWeight sets the weight of the key sub-queue, creating it if it doesn't
+// exist. A weight less than 1 is set to 1.
+func (m *MultiQueue) SetWeight(key string, weight int) {
+	if weight < 1 {
+		weight = 1
+	}
+	m.mu.Lock()
+	m.tenant(key).weight = weight
+	m.mu.Unlock()
+}
+
+// Dequeue removes an item from the sub-queue whose turn it is. If that
+// sub-queue is empty, the next sub-queue in turn is tried. If every sub-queue
+// is empty, a false will be returned.
+func (m *MultiQueue) Dequeue() (interface{}, bool) {
+	v, _, ok := m.DequeueKey()
+	return v, ok
+}
+
+// DequeueKey is Dequeue, but it also returns the key of the sub-queue the
+// item came from.
+func (m *MultiQueue) DequeueKey() (item interface{}, key string, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, t := range m.tenants {
+		t.skip = false
+	}
+	for range m.tenants {
+		t := m.turn()
+		if v, ok := t.q.Dequeue(); ok {
+			return v, t.key, true
+		}
+		t.skip = true
+	}
+	return nil, "", false
+}
+
+// turn advances the smooth weighted round-robin and returns the sub-queue,
+// not skipped, whose turn it is. The caller must hold the lock and at least
+// one sub-queue must not be skipped.
+func (m *MultiQueue) turn() *tenant {
+	var best *tenant
+	var total int
+	for _, t := range m.tenants {
+		if t.skip {
+			continue
+		}
+		t.current += t.weight
+		total += t.weight
+		if best == nil || t.current > best.current {
+			best = t
+		}
+	}
+	best.current -= total
+	return best
+}
+
+// Peek returns the item the next Dequeue would most likely return without
+// removing it; another goroutine may dequeue it first. If every sub-queue is
+// empty, a false will be returned.
+func (m *MultiQueue) Peek() (interface{}, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// take the turns on a copy of the running weights, so the schedule
+	// isn't advanced.
+	current := make([]int, len(m.tenants))
+	for i, t := range m.tenants {
+		current[i] = t.current
+		t.skip = false
+	}
+	defer func() {
+		for i, t := range m.tenants {
+			t.current = current[i]
+		}
+	}()
+	for range m.tenants {
+		t := m.turn()
+		if v, ok := t.q.Peek(); ok {
+			return v, true
+		}
+		t.skip = true
+	}
+	return nil, false
+}
+
+// Len returns the number of items in all of the sub-queues.
+func (m *MultiQueue) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int
+	for _, t := range m.tenants {
+		n += t.q.Len()
+	}
+	return n
+}
+
+// Cap returns the sum of the sub-queues' capacities.
+func (m *MultiQueue) Cap() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int
+	for _, t := range m.tenants {
+		n += t.q.Cap()
+	}
+	return n
+}
+
+// IsEmpty returns whether or not every sub-queue is empty.
+func (m *MultiQueue) IsEmpty() bool {
+	return m.Len() == 0
+}
+
+// IsFull returns false: there is always room for an item with a new key,
+// even when some of the sub-queues are full.
+func (m *MultiQueue) IsFull() bool {
+	return false
+}
+
+// Reset removes all of the items from every sub-queue. The sub-queues, and
+// their weights, are kept.
+func (m *MultiQueue) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, t := range m.tenants {
+		t.q.Reset()
+		t.current = 0
+	}
+}
+
+// Keys returns the keys of the sub-queues, in the order they were created.
+func (m *MultiQueue) Keys() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]string, len(m.tenants))
+	for i, t := range m.tenants {
+		keys[i] = t.key
+	}
+	return keys
+}
+
+// Stats returns the stats of each sub-queue, by key.
+func (m *MultiQueue) Stats() map[string]Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := make(map[string]Stats, len(m.tenants))
+	for _, t := range m.tenants {
+		stats[t.key] = t.q.Stats()
+	}
+	return stats
+}
@@ -0,0 +1,89 @@
+package queue
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func byTenant(item interface{}) string {
+	tenant, _, _ := strings.Cut(item.(string), "/")
+	return tenant
+}
+
+func TestMultiQueue(t *testing.T) {
+	tests := []struct {
+		name     string
+		weights  map[string]int
+		expected []string
+	}{
+		{"round-robin", nil, []string{"noisy/1", "quiet/1", "noisy/2", "quiet/2", "noisy/3", "noisy/4"}},
+		{"weighted", map[string]int{"noisy": 2}, []string{"noisy/1", "quiet/1", "noisy/2", "noisy/3", "quiet/2", "noisy/4"}},
+	}
+	for _, test := range tests {
+		m := NewMultiQueue(0, byTenant)
+		for key, w := range test.weights {
+			m.SetWeight(key, w)
+		}
+		for _, v := range []string{"noisy/1", "noisy/2", "noisy/3", "noisy/4", "quiet/1", "quiet/2"} {
+			m.Enqueue(v)
+		}
+		if m.Len() != 6 {
+			t.Errorf("%s: expected len 6, got %d", test.name, m.Len())
+		}
+		var got []string
+		for {
+			peeked, _ := m.Peek()
+			v, key, ok := m.DequeueKey()
+			if !ok {
+				break
+			}
+			if peeked != v {
+				t.Errorf("%s: expected peek to return %v, got %v", test.name, v, peeked)
+			}
+			if key != byTenant(v) {
+				t.Errorf("%s: expected %v to come from %s, got %s", test.name, v, byTenant(v), key)
+			}
+			got = append(got, v.(string))
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, got)
+		}
+		if !m.IsEmpty() {
+			t.Errorf("%s: expected the queue to be empty, got %d", test.name, m.Len())
+		}
+	}
+}
+
+func TestMultiQueueBounded(t *testing.T) {
+	m := NewMultiQueue(2, nil)
+	if m.Cap() != 0 || m.IsFull() {
+		t.Errorf("expected a queue without sub-queues to have a cap of 0, got %d", m.Cap())
+	}
+	m.EnqueueKey("a", 1)
+	m.EnqueueKey("a", 2)
+	if err := m.EnqueueKey("a", 3); err == nil {
+		t.Error("expected a full sub-queue to return an error")
+	}
+	m.EnqueueKey("b", 4)
+	m.Enqueue(5)
+	if keys := m.Keys(); !reflect.DeepEqual(keys, []string{"a", "b", ""}) {
+		t.Errorf("expected keys a, b, and \"\", got %q", keys)
+	}
+	if m.Cap() != 6 || m.IsFull() {
+		t.Errorf("expected a cap of 6 that's never full, got %d %t", m.Cap(), m.IsFull())
+	}
+	m.Dequeue()
+	stats := m.Stats()
+	expected := map[string][3]uint64{"a": {2, 1, 1}, "b": {1, 0, 0}, "": {1, 0, 0}}
+	for key, e := range expected {
+		s := stats[key]
+		if [3]uint64{s.Enqueued, s.Dequeued, s.Dropped} != e {
+			t.Errorf("%q: expected enqueued, dequeued, dropped of %v, got %d %d %d", key, e, s.Enqueued, s.Dequeued, s.Dropped)
+		}
+	}
+	m.Reset()
+	if !m.IsEmpty() || len(m.Keys()) != 3 {
+		t.Errorf("expected a reset to empty the sub-queues and keep them, got %d %v", m.Len(), m.Keys())
+	}
+}
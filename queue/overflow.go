@@ -0,0 +1,48 @@
+package queue
+
+// OverflowPolicy is what a Circular queue's Enqueue does when the queue is
+// full.
+type OverflowPolicy int
+
+// The overflow policies.
+const (
+	// OverflowError rejects the new item with an error. This is the
+	// default.
+	OverflowError OverflowPolicy = iota
+	// OverflowDropOldest evicts the oldest item to make room for the new
+	// one, i.e. ring buffer semantics.
+	OverflowDropOldest
+	// OverflowDropNewest silently drops the new item.
+	OverflowDropNewest
+	// OverflowBlock blocks until there is room for the new item.
+	OverflowBlock
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowError:
+		return "error"
+	case OverflowDropOldest:
+		return "drop oldest"
+	case OverflowDropNewest:
+		return "drop newest"
+	case OverflowBlock:
+		return "block"
+	}
+	return "unknown"
+}
+
+// NewCircularWithPolicy returns an initialized circular queue whose Enqueue
+// follows the received overflow policy when the queue is full. Dropped
+// items, whether the new item or an evicted one, are published as
+// EventDrop events.
+func NewCircularWithPolicy(size int, policy OverflowPolicy) *Circular {
+	c := NewCircular(size)
+	c.policy = policy
+	return c
+}
+
+// Policy returns the queue's overflow policy.
+func (c *Circular) Policy() OverflowPolicy {
+	return c.policy
+}
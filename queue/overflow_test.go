@@ -0,0 +1,73 @@
+package queue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestOverflowPolicy(t *testing.T) {
+	tests := []struct {
+		policy   OverflowPolicy
+		err      string
+		expected string // the queue's contents after enqueueing 1-4.
+		drops    string // the dropped items.
+	}{
+		{OverflowError, "queue full: cannot enqueue 3", "[1 2]", "[3 4]"},
+		{OverflowDropOldest, "", "[3 4]", "[1 2]"},
+		{OverflowDropNewest, "", "[1 2]", "[3 4]"},
+	}
+	for _, test := range tests {
+		var drops []interface{}
+		b := NewBus()
+		b.Subscribe(func(e Event) {
+			if e.Kind == EventDrop {
+				drops = append(drops, e.Item)
+			}
+		})
+		c := NewCircularWithPolicy(2, test.policy)
+		c.SetBus(b)
+		if c.Policy() != test.policy {
+			t.Errorf("%s: expected the policy to be %s, got %s", test.policy, test.policy, c.Policy())
+		}
+		var err error
+		for i := 1; i <= 4; i++ {
+			if e := c.Enqueue(i); e != nil && err == nil {
+				err = e
+			}
+		}
+		if (err == nil && test.err != "") || (err != nil && err.Error() != test.err) {
+			t.Errorf("%s: expected error %q, got %v", test.policy, test.err, err)
+		}
+		if got := fmt.Sprint(c.Snapshot()); got != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.policy, test.expected, got)
+		}
+		if got := fmt.Sprint(drops); got != test.drops {
+			t.Errorf("%s: expected %s to be dropped, got %s", test.policy, test.drops, got)
+		}
+		if c.Len() != 2 {
+			t.Errorf("%s: expected len to be 2, got %d", test.policy, c.Len())
+		}
+	}
+}
+
+func TestOverflowBlock(t *testing.T) {
+	c := NewCircularWithPolicy(1, OverflowBlock)
+	_ = c.Enqueue(1)
+	done := make(chan error)
+	go func() { done <- c.Enqueue(2) }()
+	select {
+	case err := <-done:
+		t.Fatalf("expected enqueue on a full queue to block, got %v", err)
+	case <-time.After(5 * time.Millisecond):
+	}
+	if v, _ := c.Dequeue(); v != 1 {
+		t.Errorf("expected 1, got %v", v)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if v, _ := c.Dequeue(); v != 2 {
+		t.Errorf("expected 2, got %v", v)
+	}
+}
@@ -0,0 +1,185 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// PacerConfig configures a Pacer. The zero value of each field, other than
+// Target, uses the default.
+type PacerConfig struct {
+	Target       time.Duration // the downstream latency above which the rate is decreased; required.
+	MaxErrorRate float64       // the downstream error rate above which the rate is decreased; the default is 0.05.
+	MinRate      float64       // the minimum rate, in items per second; the default is 1.
+	MaxRate      float64       // the maximum rate, in items per second; if < MinRate it is set to MinRate.
+	Rate         float64       // the starting rate; the default is MaxRate.
+	Increase     float64       // the amount the rate is increased by at each healthy step; the default is 1% of MaxRate, but at least 1.
+	Decrease     float64       // the factor the rate is multiplied by at each unhealthy step; the default is 0.5.
+}
+
+// Pacer paces the dequeues from a queue to protect a fragile downstream:
+// items are released at a rate, in items per second, that is adjusted, AIMD
+// style, based on how the downstream is doing. The consumer reports the
+// latency and error of each item's handling with Done. At each step, if the
+// mean latency since the last step is above the target, or the error rate is
+// above the maximum, the rate is cut by the decrease factor; otherwise it is
+// increased by the increase amount. Steps without any reports leave the rate
+// as it is.
+//
+// The rate is adjusted each time Step is called, or periodically by Run.
+type Pacer struct {
+	q       Dequeuer
+	cfg     PacerConfig
+	mu      sync.Mutex
+	rate    float64
+	tokens  float64   // releases available; at most 1, so there are no bursts.
+	last    time.Time // when tokens was last refilled.
+	n       int       // reports since the last step.
+	errs    int
+	latency time.Duration // the total latency reported since the last step.
+}
+
+// NewPacer returns a Pacer for the items dequeued from q.
+func NewPacer(q Dequeuer, cfg PacerConfig) *Pacer {
+	if cfg.MaxErrorRate <= 0 {
+		cfg.MaxErrorRate = 0.05
+	}
+	if cfg.MinRate <= 0 {
+		cfg.MinRate = 1
+	}
+	if cfg.MaxRate < cfg.MinRate {
+		cfg.MaxRate = cfg.MinRate
+	}
+	if cfg.Rate <= 0 || cfg.Rate > cfg.MaxRate {
+		cfg.Rate = cfg.MaxRate
+	}
+	if cfg.Rate < cfg.MinRate {
+		cfg.Rate = cfg.MinRate
+	}
+	if cfg.Increase <= 0 {
+		cfg.Increase = cfg.MaxRate / 100
+		if cfg.Increase < 1 {
+			cfg.Increase = 1
+		}
+	}
+	if cfg.Decrease <= 0 || cfg.Decrease >= 1 {
+		cfg.Decrease = 0.5
+	}
+	return &Pacer{q: q, cfg: cfg, rate: cfg.Rate, tokens: 1, last: time.Now()}
+}
+
+// Rate returns the current rate, in items per second.
+func (p *Pacer) Rate() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.rate
+}
+
+// refill adds the releases earned since the last refill and returns how long
+// until the next release is available. The caller must hold the lock.
+func (p *Pacer) refill(now time.Time) time.Duration {
+	p.tokens += now.Sub(p.last).Seconds() * p.rate
+	if p.tokens > 1 {
+		p.tokens = 1
+	}
+	p.last = now
+	if p.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - p.tokens) / p.rate * float64(time.Second))
+}
+
+// Dequeue dequeues an item from the queue if the rate allows a release now.
+// If it doesn't, or the queue is empty, a false will be returned.
+func (p *Pacer) Dequeue() (interface{}, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.refill(time.Now()) > 0 {
+		return nil, false
+	}
+	v, ok := p.q.Dequeue()
+	if ok {
+		p.tokens--
+	}
+	return v, ok
+}
+
+// DequeueCtx dequeues an item from the queue, waiting for the rate to allow a
+// release and for the queue to have an item, until ctx is done. If ctx is
+// done first, ctx's error is returned.
+func (p *Pacer) DequeueCtx(ctx context.Context) (interface{}, error) {
+	for {
+		p.mu.Lock()
+		wait := p.refill(time.Now())
+		if wait == 0 {
+			if v, ok := p.q.Dequeue(); ok {
+				p.tokens--
+				p.mu.Unlock()
+				return v, nil
+			}
+			// the queue is empty: poll it like Select does.
+			wait = minPoll
+		}
+		p.mu.Unlock()
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// Done reports how long the downstream took to handle an item and whether
+// it failed.
+func (p *Pacer) Done(latency time.Duration, err error) {
+	p.mu.Lock()
+	p.n++
+	p.latency += latency
+	if err != nil {
+		p.errs++
+	}
+	p.mu.Unlock()
+}
+
+// Step looks at the reports since the last step and adjusts the rate; the new
+// rate is returned.
+func (p *Pacer) Step() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n, errs, latency := p.n, p.errs, p.latency
+	p.n, p.errs, p.latency = 0, 0, 0
+	if n == 0 {
+		return p.rate
+	}
+	// releases earned at the old rate are kept.
+	p.refill(time.Now())
+	if latency/time.Duration(n) > p.cfg.Target || float64(errs)/float64(n) > p.cfg.MaxErrorRate {
+		p.rate *= p.cfg.Decrease
+	} else {
+		p.rate += p.cfg.Increase
+	}
+	if p.rate < p.cfg.MinRate {
+		p.rate = p.cfg.MinRate
+	}
+	if p.rate > p.cfg.MaxRate {
+		p.rate = p.cfg.MaxRate
+	}
+	return p.rate
+}
+
+// Run calls Step every interval until ctx is done; ctx's error is returned.
+func (p *Pacer) Run(ctx context.Context, interval time.Duration) error {
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick.C:
+			p.Step()
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPacerStep(t *testing.T) {
+	p := NewPacer(NewQueue(0), PacerConfig{Target: 10 * time.Millisecond, MinRate: 10, MaxRate: 100, Rate: 50, Increase: 5})
+	errDown := errors.New("down")
+	tests := []struct {
+		name     string
+		latency  time.Duration
+		errs     int
+		expected float64
+	}{
+		{"healthy", time.Millisecond, 0, 55},
+		{"slow", 20 * time.Millisecond, 0, 27.5},
+		{"errors", time.Millisecond, 1, 13.75},
+		{"min", time.Second, 0, 10},
+		{"recovering", time.Millisecond, 0, 15},
+		{"no reports", -1, 0, 15},
+	}
+	for _, test := range tests {
+		if test.latency >= 0 {
+			for i := 0; i < 10; i++ {
+				var err error
+				if i < test.errs {
+					err = errDown
+				}
+				p.Done(test.latency, err)
+			}
+		}
+		if r := p.Step(); r != test.expected || p.Rate() != test.expected {
+			t.Errorf("%s: expected a rate of %g, got %g", test.name, test.expected, r)
+		}
+	}
+	// the rate is capped at the max.
+	for i := 0; i < 20; i++ {
+		p.Done(0, nil)
+		p.Step()
+	}
+	if p.Rate() != 100 {
+		t.Errorf("expected the rate to be capped at 100, got %g", p.Rate())
+	}
+}
+
+func TestPacerDequeue(t *testing.T) {
+	q := NewQueue(0)
+	for i := 0; i < 10; i++ {
+		q.Enqueue(i)
+	}
+	p := NewPacer(q, PacerConfig{Target: time.Second, MaxRate: 50})
+	// one release is available at the start; the next one is 20ms later.
+	if v, ok := p.Dequeue(); !ok || v != 0 {
+		t.Errorf("expected 0 true, got %v %t", v, ok)
+	}
+	if v, ok := p.Dequeue(); ok {
+		t.Errorf("expected the rate to hold back the next item, got %v", v)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	for i := 1; i <= 3; i++ {
+		if v, err := p.DequeueCtx(ctx); err != nil || v != i {
+			t.Fatalf("expected %d nil, got %v %v", i, v, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("expected 3 items at 50/s to take at least 50ms, took %s", elapsed)
+	}
+	p2 := NewPacer(NewQueue(0), PacerConfig{Target: time.Second, MaxRate: 1000})
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := p2.DequeueCtx(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected %s from an empty queue, got %v", context.DeadlineExceeded, err)
+	}
+}
@@ -0,0 +1,28 @@
+package queue
+
+// PanicHandler is called with the value recovered from a panicking callback,
+// e.g. a Bus subscriber or a Dispatcher's func, and the kind of callback it
+// was: "subscriber", "sizer", "hook", "dispatch", "mismatch", or "expire".
+//
+// By default a panicking callback isn't recovered; the panic propagates as it
+// would from any other func. Once a PanicHandler is set, panics are recovered
+// and reported to it instead, so that one bad callback can't kill a
+// goroutine, e.g. a Dispatcher's, or leave a queue's lock held.
+type PanicHandler func(callback string, recovered interface{})
+
+// protect calls fn. If h isn't nil, a panic in fn is recovered and reported
+// to h; the return value is false if fn panicked.
+func protect(h PanicHandler, callback string, fn func()) (ok bool) {
+	if h == nil {
+		fn()
+		return true
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			ok = false
+			h(callback, r)
+		}
+	}()
+	fn()
+	return true
+}
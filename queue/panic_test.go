@@ -0,0 +1,174 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// panics records the panics reported to a PanicHandler.
+type panics struct {
+	mu        sync.Mutex
+	callbacks []string
+}
+
+func (p *panics) handle(callback string, recovered interface{}) {
+	p.mu.Lock()
+	p.callbacks = append(p.callbacks, callback)
+	p.mu.Unlock()
+}
+
+func (p *panics) get() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.callbacks...)
+}
+
+func TestProtect(t *testing.T) {
+	var p panics
+	if protect(p.handle, "x", func() { panic("boom") }) {
+		t.Error("expected a panicking func to return false")
+	}
+	if !protect(p.handle, "x", func() {}) {
+		t.Error("expected a func that returned to return true")
+	}
+	if got := p.get(); len(got) != 1 || got[0] != "x" {
+		t.Errorf("expected one panic in x, got %v", got)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a nil handler not to recover")
+		}
+	}()
+	protect(nil, "x", func() { panic("boom") })
+}
+
+func TestBusPanicHandler(t *testing.T) {
+	var p panics
+	b := NewBus()
+	b.SetPanicHandler(p.handle)
+	var n int
+	b.Subscribe(func(Event) { panic("boom") })
+	b.Subscribe(func(Event) { n++ })
+	q := NewQueue(2)
+	q.SetBus(b)
+	q.Enqueue(1)
+	if n != 1 {
+		t.Errorf("expected the second subscriber to get the event, got %d", n)
+	}
+	if got := p.get(); len(got) != 1 || got[0] != "subscriber" {
+		t.Errorf("expected a subscriber panic, got %v", got)
+	}
+}
+
+func TestSizerPanicHandler(t *testing.T) {
+	var p panics
+	tests := []struct {
+		name string
+		q    interface {
+			Enqueue(interface{}) error
+			Dequeue() (interface{}, bool)
+			SetSizer(Sizer)
+			SetPanicHandler(PanicHandler)
+			Bytes() int
+			Len() int
+		}
+	}{
+		{"queue", NewQueue(2)},
+		{"circular", NewCircular(2)},
+	}
+	for _, test := range tests {
+		q := test.q
+		q.SetPanicHandler(p.handle)
+		q.SetSizer(func(item interface{}) int {
+			if item == "bad" {
+				panic("boom")
+			}
+			return 1
+		})
+		q.Enqueue("good")
+		q.Enqueue("bad")
+		if q.Len() != 2 || q.Bytes() != 1 {
+			t.Errorf("%s: expected 2 items of 1 byte, got %d items of %d", test.name, q.Len(), q.Bytes())
+		}
+		// the lock was released: the queue is still usable.
+		if v, ok := q.Dequeue(); !ok || v != "good" {
+			t.Errorf("%s: expected good true, got %v %t", test.name, v, ok)
+		}
+	}
+}
+
+func TestDispatcherPanicHandler(t *testing.T) {
+	var p panics
+	q := NewQueue(4)
+	for i := 0; i < 4; i++ {
+		q.Enqueue(i)
+	}
+	var mu sync.Mutex
+	var done []interface{}
+	d := NewDispatcher(q, 2, func(item interface{}) {
+		if item == 1 {
+			panic("boom")
+		}
+		mu.Lock()
+		done = append(done, item)
+		mu.Unlock()
+	})
+	d.SetPanicHandler(p.handle)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for q.Len() > 0 {
+			time.Sleep(time.Millisecond)
+		}
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	d.Run(ctx)
+	if len(done) != 3 || d.InFlight() != 0 {
+		t.Errorf("expected 3 items to be done and none in flight, got %v %d", done, d.InFlight())
+	}
+	if got := p.get(); len(got) != 1 || got[0] != "dispatch" {
+		t.Errorf("expected a dispatch panic, got %v", got)
+	}
+}
+
+func TestMirrorPanicHandler(t *testing.T) {
+	var p panics
+	m := NewMirror(NewQueue(2), NewCircular(1), func(Mismatch) { panic("boom") })
+	m.SetPanicHandler(p.handle)
+	m.Enqueue(1)
+	if err := m.Enqueue(2); err != nil {
+		t.Errorf("expected the primary's result, got %v", err)
+	}
+	// the lock was released.
+	if v, ok := m.Dequeue(); !ok || v != 1 {
+		t.Errorf("expected 1 true, got %v %t", v, ok)
+	}
+	if got := p.get(); len(got) == 0 || got[0] != "mismatch" {
+		t.Errorf("expected a mismatch panic, got %v", got)
+	}
+}
+
+func TestGatePanicHandler(t *testing.T) {
+	var p panics
+	q := NewQueue(2)
+	var expired int
+	g := NewGate(q, 0, func(item interface{}) {
+		if item == thumb("a") {
+			panic("boom")
+		}
+		expired++
+	})
+	g.SetPanicHandler(p.handle)
+	q.Enqueue(thumb("a"))
+	q.Enqueue(thumb("b"))
+	g.Dequeue()
+	g.Dequeue()
+	if expired != 1 {
+		t.Errorf("expected the other item to expire, got %d", expired)
+	}
+	if got := p.get(); len(got) != 1 || got[0] != "expire" {
+		t.Errorf("expected an expire panic, got %v", got)
+	}
+}
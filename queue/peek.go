@@ -0,0 +1,79 @@
+package queue
+
+// PeekAt returns the item i places from the head of the queue, the head
+// being 0, without removing it. If there is no such item, a false will be
+// returned.
+func (q *Queue) PeekAt(i int) (interface{}, bool) {
+	q.Lock()
+	defer q.Unlock()
+	if i < 0 || i >= len(q.items)-q.head {
+		return nil, false
+	}
+	return q.items[q.head+i], true
+}
+
+// PeekN returns a copy of the first n items in the queue, in FIFO order,
+// without removing them. If the queue has fewer than n items, all of them
+// are returned.
+func (q *Queue) PeekN(n int) []interface{} {
+	q.Lock()
+	defer q.Unlock()
+	if l := len(q.items) - q.head; n > l {
+		n = l
+	}
+	if n <= 0 {
+		return nil
+	}
+	return append([]interface{}(nil), q.items[q.head:q.head+n]...)
+}
+
+// PeekAt returns the item i places from the head of the queue, the head
+// being 0, without removing it; see Queue.PeekAt.
+func (c *Circular) PeekAt(i int) (interface{}, bool) {
+	c.Lock()
+	defer c.Unlock()
+	if i < 0 || i >= c.plen() {
+		return nil, false
+	}
+	return c.items[c.at(i)], true
+}
+
+// PeekN returns a copy of the first n items in the queue, in FIFO order,
+// without removing them; see Queue.PeekN.
+func (c *Circular) PeekN(n int) []interface{} {
+	c.Lock()
+	defer c.Unlock()
+	if l := c.plen(); n > l {
+		n = l
+	}
+	if n <= 0 {
+		return nil
+	}
+	items := make([]interface{}, n)
+	for i := range items {
+		items[i] = c.items[c.at(i)]
+	}
+	return items
+}
+
+// at returns the position, in the underlying slice, of the item i places
+// from the head; i must be less than the queue's length. The caller must
+// hold the lock.
+func (c *Circular) at(i int) int {
+	if i += c.head; i >= cap(c.items) {
+		return i - cap(c.items)
+	}
+	return i
+}
+
+// At returns the i-th item in the queue, in FIFO order; it is the same as
+// PeekAt.
+func (q *Queue) At(i int) (interface{}, bool) {
+	return q.PeekAt(i)
+}
+
+// At returns the i-th item in the queue, in FIFO order; it is the same as
+// PeekAt.
+func (c *Circular) At(i int) (interface{}, bool) {
+	return c.PeekAt(i)
+}
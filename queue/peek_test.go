@@ -0,0 +1,60 @@
+package queue
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPeekAt(t *testing.T) {
+	tests := []struct {
+		name string
+		q    interface {
+			Queuer
+			PeekAt(int) (interface{}, bool)
+			PeekN(int) []interface{}
+			At(int) (interface{}, bool)
+		}
+	}{
+		{"queue", NewQueue(2)},
+		{"circular", NewCircular(4)},
+	}
+	for _, test := range tests {
+		q := test.q
+		if v := q.PeekN(2); v != nil {
+			t.Errorf("%s: expected an empty queue to peek nil, got %v", test.name, v)
+		}
+		// move the head so that the circular queue's items wrap around.
+		for i := 0; i < 3; i++ {
+			q.Enqueue(-1)
+			q.Dequeue()
+		}
+		for i := 0; i < 4; i++ {
+			q.Enqueue(i)
+		}
+		for _, expected := range []struct {
+			i  int
+			v  interface{}
+			ok bool
+		}{{-1, nil, false}, {0, 0, true}, {3, 3, true}, {4, nil, false}} {
+			if v, ok := q.PeekAt(expected.i); v != expected.v || ok != expected.ok {
+				t.Errorf("%s: PeekAt(%d): expected %v %t, got %v %t", test.name, expected.i, expected.v, expected.ok, v, ok)
+			}
+		}
+		if v, ok := q.At(2); !ok || v != 2 {
+			t.Errorf("%s: At(2): expected 2 true, got %v %t", test.name, v, ok)
+		}
+		q.Dequeue()
+		q.Enqueue(4)
+		for _, n := range []struct {
+			n        int
+			expected []interface{}
+		}{{-1, nil}, {0, nil}, {2, []interface{}{1, 2}}, {9, []interface{}{1, 2, 3, 4}}} {
+			if v := q.PeekN(n.n); !reflect.DeepEqual(v, n.expected) {
+				t.Errorf("%s: PeekN(%d): expected %v, got %v", test.name, n.n, n.expected, v)
+			}
+		}
+		if q.Len() != 4 {
+			t.Errorf("%s: expected peeking to leave 4 items, got %d", test.name, q.Len())
+		}
+	}
+}
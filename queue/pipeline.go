@@ -0,0 +1,114 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Pipeline detects when a pipeline built from queues and workers is
+// quiescent: every queue is empty and no worker has an item in flight, so a
+// batch job fed through streaming stages knows it has finished. Queues are
+// watched with Queue; workers that report their in flight work, e.g. a
+// Dispatcher, with Workers; and hand-rolled workers report theirs with Begin
+// and End.
+//
+// Quiescence is checked by polling. A pipeline is only quiescent once it has
+// looked idle on two consecutive checks with no enqueues or dequeues on its
+// queues in between, so an item that is between stages, e.g. dequeued but not
+// yet counted as in flight, can't make an active pipeline look done.
+type Pipeline struct {
+	mu      sync.Mutex
+	queues  []interface{ Len() int }
+	workers []interface{ InFlight() int }
+	active  atomic.Int64 // items between Begin and End.
+}
+
+// NewPipeline returns a Pipeline that isn't watching anything.
+func NewPipeline() *Pipeline {
+	return &Pipeline{}
+}
+
+// Queue adds a queue to the stages that must be empty. If the queue has
+// Stats, e.g. a Queue or Circular, its enqueues and dequeues count as
+// activity between checks; its counting is turned on with SetStats.
+func (p *Pipeline) Queue(q interface{ Len() int }) *Pipeline {
+	if s, ok := q.(interface{ SetStats(bool) }); ok {
+		s.SetStats(true)
+	}
+	p.mu.Lock()
+	p.queues = append(p.queues, q)
+	p.mu.Unlock()
+	return p
+}
+
+// Workers adds a stage whose in flight work must be done, e.g. a Dispatcher.
+func (p *Pipeline) Workers(w interface{ InFlight() int }) *Pipeline {
+	p.mu.Lock()
+	p.workers = append(p.workers, w)
+	p.mu.Unlock()
+	return p
+}
+
+// Begin reports that a worker has started on an item; it should be called as
+// soon as the item is dequeued. End must be called once the worker has
+// finished with the item, including enqueueing anything the item produced.
+func (p *Pipeline) Begin() {
+	p.active.Add(1)
+}
+
+// End reports that a worker has finished with an item; see Begin.
+func (p *Pipeline) End() {
+	p.active.Add(-1)
+}
+
+// Idle returns whether every stage currently looks idle. A single Idle is a
+// snapshot, not proof of quiescence; see Wait.
+func (p *Pipeline) Idle() bool {
+	idle, _ := p.check()
+	return idle
+}
+
+// check returns whether every stage looks idle and the pipeline's activity
+// count, the sum of its queues' enqueues and dequeues.
+func (p *Pipeline) check() (idle bool, activity uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	idle = p.active.Load() == 0
+	for _, q := range p.queues {
+		if s, ok := q.(interface{ Stats() Stats }); ok {
+			st := s.Stats()
+			activity += st.Enqueued + st.Dequeued
+		}
+		if q.Len() != 0 {
+			idle = false
+		}
+	}
+	for _, w := range p.workers {
+		if w.InFlight() != 0 {
+			idle = false
+		}
+	}
+	return idle, activity
+}
+
+// Wait blocks until the pipeline is quiescent, checking every interval, or
+// until ctx is done, in which case ctx's error is returned.
+func (p *Pipeline) Wait(ctx context.Context, interval time.Duration) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	wasIdle, last := p.check()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+		idle, activity := p.check()
+		if idle && wasIdle && activity == last {
+			return nil
+		}
+		wasIdle, last = idle, activity
+	}
+}
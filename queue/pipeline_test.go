@@ -0,0 +1,72 @@
+package queue
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPipeline(t *testing.T) {
+	in, mid := NewQueue(8), NewCircular(4)
+	var out atomic.Int64
+	// stage 1: a Dispatcher moves the items from in to mid, slowly.
+	d := NewDispatcher(in, 2, func(item interface{}) {
+		time.Sleep(time.Millisecond)
+		mid.EnqueueCtx(context.Background(), item)
+	})
+	p := NewPipeline().Queue(in).Workers(d).Queue(mid)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d.Run(ctx)
+	// stage 2: a hand-rolled worker.
+	go func() {
+		for {
+			if _, err := mid.DequeueCtx(ctx); err != nil {
+				return
+			}
+			p.Begin()
+			time.Sleep(time.Millisecond)
+			out.Add(1)
+			p.End()
+		}
+	}()
+
+	for i := 0; i < 50; i++ {
+		in.Enqueue(i)
+	}
+	wctx, wcancel := context.WithTimeout(ctx, 5*time.Second)
+	defer wcancel()
+	if err := p.Wait(wctx, 5*time.Millisecond); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if n := out.Load(); n != 50 {
+		t.Errorf("expected all 50 items to be through the pipeline, got %d", n)
+	}
+}
+
+func TestPipelineIdle(t *testing.T) {
+	q := NewQueue(1)
+	p := NewPipeline().Queue(q)
+	if !p.Idle() {
+		t.Error("expected an empty pipeline to be idle")
+	}
+	q.Enqueue(1)
+	if p.Idle() {
+		t.Error("expected a pipeline with a queued item not to be idle")
+	}
+	q.Dequeue()
+	p.Begin()
+	if p.Idle() {
+		t.Error("expected a pipeline with an item in flight not to be idle")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := p.Wait(ctx, time.Millisecond); err != context.DeadlineExceeded {
+		t.Errorf("expected Wait to time out, got %v", err)
+	}
+	p.End()
+	if err := p.Wait(context.Background(), time.Millisecond); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
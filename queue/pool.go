@@ -0,0 +1,149 @@
+package queue
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Pool is a work-stealing pool of workers' queues: each worker has its own
+// Circular queue, which it dequeues from in FIFO order, and a worker whose
+// queue is empty steals from the back of the busiest other worker's queue.
+// Workers mostly touch only their own queue's lock, and work still spreads
+// to idle workers when it's unevenly distributed, e.g. because a worker
+// enqueues follow-up work to its own queue.
+type Pool struct {
+	workers []*Worker
+	next    atomic.Uint64 // the next worker to enqueue to.
+}
+
+// Worker is one of a Pool's workers. A worker's Dequeue should only be called
+// by the goroutine running the worker; anyone may enqueue to it.
+type Worker struct {
+	p      *Pool
+	id     int
+	q      *Circular
+	stolen atomic.Uint64
+}
+
+// NewPool returns a pool of n workers, each with a queue that holds size
+// items. If n < 1, the pool will have 1 worker.
+func NewPool(n, size int) *Pool {
+	if n < 1 {
+		n = 1
+	}
+	p := &Pool{workers: make([]*Worker, n)}
+	for i := range p.workers {
+		p.workers[i] = &Worker{p: p, id: i, q: NewCircular(size)}
+	}
+	return p
+}
+
+// Enqueue adds an item to one of the workers' queues; the workers are picked
+// round-robin, skipping full queues. An error is returned if every worker's
+// queue is full.
+func (p *Pool) Enqueue(item interface{}) error {
+	start := p.next.Add(1) - 1
+	for i := 0; i < len(p.workers); i++ {
+		w := p.workers[(start+uint64(i))%uint64(len(p.workers))]
+		if w.q.IsFull() {
+			continue
+		}
+		if err := w.q.Enqueue(item); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("pool full: cannot enqueue %v", item)
+}
+
+// Worker returns the i-th worker, or nil if there isn't one.
+func (p *Pool) Worker(i int) *Worker {
+	if i < 0 || i >= len(p.workers) {
+		return nil
+	}
+	return p.workers[i]
+}
+
+// Workers returns the number of workers.
+func (p *Pool) Workers() int {
+	return len(p.workers)
+}
+
+// Len returns the number of items in all of the workers' queues. This does
+// not take any locks, so it is approximate while the pool is in use.
+func (p *Pool) Len() int {
+	var n int
+	for _, w := range p.workers {
+		n += w.q.Len()
+	}
+	return n
+}
+
+// ID returns the worker's index in its pool.
+func (w *Worker) ID() int {
+	return w.id
+}
+
+// Enqueue adds an item to the worker's own queue, e.g. work spawned by the
+// item the worker is processing. An error is returned if the queue is full.
+func (w *Worker) Enqueue(item interface{}) error {
+	return w.q.Enqueue(item)
+}
+
+// Dequeue removes the next item from the worker's queue. If the queue is
+// empty, an item is stolen from the back of the queue of the worker with the
+// most items. If every queue is empty, a false will be returned.
+func (w *Worker) Dequeue() (interface{}, bool) {
+	if v, ok := w.q.Dequeue(); ok {
+		return v, true
+	}
+	// the lengths are read without locking; if the victim empties before the
+	// steal, try the next busiest.
+	for tried := 1; tried < len(w.p.workers); tried++ {
+		var victim *Worker
+		for _, o := range w.p.workers {
+			if o != w && o.q.Len() > 0 && (victim == nil || o.q.Len() > victim.q.Len()) {
+				victim = o
+			}
+		}
+		if victim == nil {
+			return nil, false
+		}
+		if v, ok := victim.q.steal(); ok {
+			w.stolen.Add(1)
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// Len returns the number of items in the worker's queue.
+func (w *Worker) Len() int {
+	return w.q.Len()
+}
+
+// Stolen returns the number of items the worker has stolen from other
+// workers.
+func (w *Worker) Stolen() uint64 {
+	return w.stolen.Load()
+}
+
+// steal removes the newest item from the queue, i.e. from the back, and
+// returns it. A claimed head item can't be stolen. If there is no item to
+// steal, a false will be returned.
+func (c *Circular) steal() (interface{}, bool) {
+	c.Lock()
+	if c.isEmpty() || (c.claimed && c.plen() == 1) {
+		c.Unlock()
+		return nil, false
+	}
+	if c.tail--; c.tail < 0 {
+		c.tail = cap(c.items) - 1
+	}
+	item := c.items[c.tail]
+	c.items[c.tail] = nil
+	c.account(item, -1)
+	c.publish()
+	c.Unlock()
+	c.emit(EventDequeue, item)
+	return item, true
+}
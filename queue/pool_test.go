@@ -0,0 +1,94 @@
+package queue
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPool(t *testing.T) {
+	p := NewPool(3, 2)
+	for i := 0; i < 6; i++ {
+		if err := p.Enqueue(i); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	if err := p.Enqueue(6); err == nil {
+		t.Error("expected an error when every worker's queue is full")
+	}
+	if p.Len() != 6 || p.Workers() != 3 || p.Worker(3) != nil {
+		t.Errorf("expected 3 workers with 6 items, got %d %d", p.Workers(), p.Len())
+	}
+
+	w0, w1 := p.Worker(0), p.Worker(1)
+	// w0 has 0 and 3; it dequeues its own items in order.
+	for _, expected := range []int{0, 3} {
+		if v, _ := w0.Dequeue(); v != expected {
+			t.Errorf("expected %d, got %v", expected, v)
+		}
+	}
+	// w1's queue gets more work; w0 steals from its back.
+	w1.Dequeue()
+	w1.Enqueue(7)
+	w1.Enqueue(8) // rejected, full
+	if v, ok := w0.Dequeue(); !ok || v != 7 {
+		t.Errorf("expected w0 to steal 7, got %v %t", v, ok)
+	}
+	if w0.Stolen() != 1 {
+		t.Errorf("expected 1 stolen item, got %d", w0.Stolen())
+	}
+	if v, _ := w1.Dequeue(); v != 4 {
+		t.Errorf("expected w1's own item 4, got %v", v)
+	}
+	// the rest are stolen, busiest first, until there are none.
+	var got int
+	for {
+		if _, ok := w0.Dequeue(); !ok {
+			break
+		}
+		got++
+	}
+	if got != 2 || p.Len() != 0 {
+		t.Errorf("expected to steal the last 2 items, got %d, %d left", got, p.Len())
+	}
+}
+
+func TestPoolStealClaimed(t *testing.T) {
+	p := NewPool(2, 2)
+	w0, w1 := p.Worker(0), p.Worker(1)
+	w1.Enqueue(1)
+	w1.q.Claim()
+	if v, ok := w0.Dequeue(); ok {
+		t.Errorf("expected a claimed item not to be stolen, got %v", v)
+	}
+	w1.Enqueue(2)
+	if v, ok := w0.Dequeue(); !ok || v != 2 {
+		t.Errorf("expected to steal 2 from behind the claimed item, got %v %t", v, ok)
+	}
+}
+
+func TestPoolConcurrent(t *testing.T) {
+	p := NewPool(4, 1000)
+	// all of the work starts on one worker.
+	for i := 0; i < 1000; i++ {
+		p.Worker(0).Enqueue(i)
+	}
+	var done atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < p.Workers(); i++ {
+		wg.Add(1)
+		go func(w *Worker) {
+			defer wg.Done()
+			for {
+				if _, ok := w.Dequeue(); !ok {
+					return
+				}
+				done.Add(1)
+			}
+		}(p.Worker(i))
+	}
+	wg.Wait()
+	if done.Load() != 1000 {
+		t.Errorf("expected 1000 items processed, got %d", done.Load())
+	}
+}
@@ -15,6 +15,7 @@ package queue
 import (
 	"container/heap"
 	"sync"
+	"time"
 )
 
 // An Item is something we manage in a priority queue.
@@ -22,7 +23,9 @@ type Item struct {
 	value    interface{} // The value of the item; arbitrary.
 	priority int         // The priority of the item in the queue.
 	// The index is needed by update and is maintained by the heap.Interface methods.
-	index int // The index of the item in the heap.
+	index    int       // The index of the item in the heap.
+	seq      uint64    // The order the item was added in; orders items of equal priority.
+	enqueued time.Time // When the item was added; only set if the queue ages its items.
 }
 
 // A HeapPriority implements heap.Interface and holds Items.
@@ -37,6 +40,9 @@ type PQueue []*Item
 func (pq PQueue) Len() int { return len(pq) }
 
 func (pq PQueue) Less(i, j int) bool {
+	if pq[i].priority == pq[j].priority {
+		return pq[i].seq < pq[j].seq
+	}
 	return pq[i].priority > pq[j].priority
 }
 
@@ -0,0 +1,57 @@
+package queue
+
+import (
+	"context"
+)
+
+// PreloadConfig configures a Preload. The zero value of each field uses the
+// default.
+type PreloadConfig struct {
+	Target   int              // the queue length to fill the queue to; the default is the Cap of a Bounded queue, see Capabilities, and no limit otherwise.
+	Every    int              // the number of items between progress reports; the default is 100.
+	Progress func(loaded int) // called with the number of items loaded so far; may be nil.
+}
+
+// Preload fills q from src, e.g. a generator or a store of the work that was
+// pending when a process stopped, so that consumers have work as soon as they
+// start. Items are taken from src, and enqueued, until q's length reaches the
+// target, src returns false, or ctx is done. Progress is reported every Every
+// items and once more when Preload stops.
+//
+// The number of items loaded is returned. If ctx is done first, ctx's error
+// is returned; if an enqueue fails, its error is returned and the item that
+// failed isn't counted.
+func Preload(ctx context.Context, q Queuer, src func() (interface{}, bool), cfg PreloadConfig) (int, error) {
+	target := cfg.Target
+	if target <= 0 && CapabilitiesOf(q).Bounded {
+		target = q.Cap()
+	}
+	every := cfg.Every
+	if every <= 0 {
+		every = 100
+	}
+	var n, reported int
+	report := func() {
+		if cfg.Progress != nil && (n != reported || n == 0) {
+			cfg.Progress(n)
+		}
+		reported = n
+	}
+	defer report()
+	for target <= 0 || q.Len() < target {
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+		item, ok := src()
+		if !ok {
+			break
+		}
+		if err := q.Enqueue(item); err != nil {
+			return n, err
+		}
+		if n++; n%every == 0 {
+			report()
+		}
+	}
+	return n, nil
+}
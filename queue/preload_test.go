@@ -0,0 +1,90 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// counter returns a source of the ints from 0 to n-1.
+func counter(n int) func() (interface{}, bool) {
+	var i int
+	return func() (interface{}, bool) {
+		if i == n {
+			return nil, false
+		}
+		i++
+		return i - 1, true
+	}
+}
+
+func TestPreload(t *testing.T) {
+	tests := []struct {
+		name     string
+		q        Queuer
+		src      int
+		cfg      PreloadConfig
+		loaded   int
+		progress []int
+	}{
+		{"circular fills to cap", NewCircular(5), 10, PreloadConfig{Every: 2}, 5, []int{2, 4, 5}},
+		{"target", NewCircular(5), 10, PreloadConfig{Target: 3, Every: 3}, 3, []int{3}},
+		{"source runs out", NewCircular(5), 2, PreloadConfig{}, 2, []int{2}},
+		{"unbounded", NewQueue(1), 250, PreloadConfig{}, 250, []int{100, 200, 250}},
+		{"empty source", NewQueue(1), 0, PreloadConfig{}, 0, []int{0}},
+	}
+	for _, test := range tests {
+		var progress []int
+		test.cfg.Progress = func(n int) { progress = append(progress, n) }
+		n, err := Preload(context.Background(), test.q, counter(test.src), test.cfg)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+		}
+		if n != test.loaded || test.q.Len() != test.loaded {
+			t.Errorf("%s: expected %d loaded, got %d, len %d", test.name, test.loaded, n, test.q.Len())
+		}
+		if len(progress) != len(test.progress) {
+			t.Errorf("%s: expected progress %v, got %v", test.name, test.progress, progress)
+			continue
+		}
+		for i := range progress {
+			if progress[i] != test.progress[i] {
+				t.Errorf("%s: expected progress %v, got %v", test.name, test.progress, progress)
+				break
+			}
+		}
+	}
+}
+
+func TestPreloadStops(t *testing.T) {
+	// a queue that already has items is only topped up.
+	c := NewCircular(4)
+	c.Enqueue(-1)
+	if n, _ := Preload(context.Background(), c, counter(10), PreloadConfig{}); n != 3 {
+		t.Errorf("expected 3 loaded, got %d", n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	src := counter(10)
+	n, err := Preload(ctx, NewQueue(1), func() (interface{}, bool) {
+		v, ok := src()
+		if v == 4 {
+			cancel()
+		}
+		return v, ok
+	}, PreloadConfig{})
+	if !errors.Is(err, context.Canceled) || n != 5 {
+		t.Errorf("expected 5 loaded and context canceled, got %d %v", n, err)
+	}
+
+	// a drop-newest circular never returns an error, so fill to its cap.
+	d := NewCircularWithPolicy(2, OverflowDropNewest)
+	if n, err := Preload(context.Background(), d, counter(10), PreloadConfig{}); n != 2 || err != nil {
+		t.Errorf("expected 2 loaded, got %d %v", n, err)
+	}
+
+	// a target past a circular's cap ends with its queue full error.
+	if n, err := Preload(context.Background(), NewCircular(2), counter(10), PreloadConfig{Target: 10}); n != 2 || err == nil {
+		t.Errorf("expected 2 loaded and an error, got %d %v", n, err)
+	}
+}
@@ -0,0 +1,164 @@
+package queue
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Pressure is how close a queue is to being full, based on its watermarks;
+// see SetWatermarks.
+type Pressure int32
+
+// The pressure levels.
+const (
+	// PressureOK is below the high watermark; producers can go at full
+	// speed.
+	PressureOK Pressure = iota
+	// PressureWarning is at, or above, the high watermark, and stays until
+	// the queue drains to the low watermark; producers should slow down.
+	PressureWarning
+	// PressureFull is a full bounded queue; enqueues are being dropped,
+	// rejected, or blocked, depending on the queue's overflow policy.
+	PressureFull
+)
+
+func (p Pressure) String() string {
+	switch p {
+	case PressureOK:
+		return "ok"
+	case PressureWarning:
+		return "warning"
+	case PressureFull:
+		return "full"
+	}
+	return "unknown"
+}
+
+// watermarks is a queue's watermark configuration and its current pressure.
+type watermarks struct {
+	low, high int
+	changed   func(from, to Pressure)
+	level     atomic.Int32  // the current Pressure; set while holding the queue's lock.
+	notified  atomic.Int32  // the last Pressure changed was called with.
+	ch        chan struct{} // closed when the pressure changes, if anyone is waiting; see PressureChanged.
+}
+
+// SetWatermarks sets the queue's low and high watermarks: once the queue's
+// length reaches high, its Pressure is PressureWarning until it drains to
+// low, so that producers can slow down before the queue is full and don't
+// flap around a single threshold. A full bounded queue's pressure is
+// PressureFull.
+//
+// If changed isn't nil, it is called with the old and the new pressure after
+// an operation that changes the queue's pressure, outside of the queue's
+// lock; the change is also published to the queue's Bus as an EventPressure. With concurrent callers, changes that quickly undo each other may be
+// reported as one change, or not at all. A high of 0 removes the watermarks.
+// low must be at least 0 and less than high; otherwise an error is returned.
+func (q *Queue) SetWatermarks(low, high int, changed func(from, to Pressure)) error {
+	m, err := newWatermarks(low, high, changed)
+	if err != nil {
+		return err
+	}
+	q.Lock()
+	q.marks.Store(m)
+	q.gauge(len(q.items)-q.head, cap(q.items), false)
+	q.Unlock()
+	q.notifyPressure()
+	return nil
+}
+
+// SetWatermarks sets the queue's low and high watermarks; see
+// Queue.SetWatermarks.
+func (c *Circular) SetWatermarks(low, high int, changed func(from, to Pressure)) error {
+	m, err := newWatermarks(low, high, changed)
+	if err != nil {
+		return err
+	}
+	c.Lock()
+	c.marks.Store(m)
+	c.gauge(c.plen(), cap(c.items)-1, true)
+	c.Unlock()
+	c.notifyPressure()
+	return nil
+}
+
+// newWatermarks validates the watermarks; a high of 0 returns nil.
+func newWatermarks(low, high int, changed func(from, to Pressure)) (*watermarks, error) {
+	if high <= 0 {
+		return nil, nil
+	}
+	if low < 0 || low >= high {
+		return nil, fmt.Errorf("cannot set watermarks: low, %d, must be at least 0 and less than high, %d", low, high)
+	}
+	return &watermarks{low: low, high: high, changed: changed}, nil
+}
+
+// Pressure returns the queue's current pressure; a queue without watermarks
+// is always PressureOK. This does not take the lock.
+func (q *Queue) Pressure() Pressure {
+	m := q.marks.Load()
+	if m == nil {
+		return PressureOK
+	}
+	return Pressure(m.level.Load())
+}
+
+// PressureChanged returns a channel that is closed the next time the queue's
+// pressure changes, so producers can wait for it instead of polling. A queue
+// without watermarks returns nil; receiving from it blocks forever.
+func (q *Queue) PressureChanged() <-chan struct{} {
+	q.Lock()
+	defer q.Unlock()
+	m := q.marks.Load()
+	if m == nil {
+		return nil
+	}
+	if m.ch == nil {
+		m.ch = make(chan struct{})
+	}
+	return m.ch
+}
+
+// gauge updates the queue's pressure for a length of l; bounded queues are
+// full at capacity. The caller must hold the lock.
+func (q *Queue) gauge(l, capacity int, bounded bool) {
+	m := q.marks.Load()
+	if m == nil {
+		return
+	}
+	cur := Pressure(m.level.Load())
+	level := PressureOK
+	switch {
+	case bounded && l >= capacity:
+		level = PressureFull
+	case l >= m.high, cur != PressureOK && l > m.low:
+		level = PressureWarning
+	}
+	if level == cur {
+		return
+	}
+	m.level.Store(int32(level))
+	if m.ch != nil {
+		close(m.ch)
+		m.ch = nil
+	}
+}
+
+// notifyPressure calls the watermarks' changed func, and publishes an
+// EventPressure to the queue's Bus, if the pressure has changed since it was
+// last notified. This must not be called while holding the lock.
+func (q *Queue) notifyPressure() {
+	m := q.marks.Load()
+	if m == nil {
+		return
+	}
+	level := m.level.Load()
+	from := m.notified.Swap(level)
+	if from == level {
+		return
+	}
+	if m.changed != nil {
+		m.changed(Pressure(from), Pressure(level))
+	}
+	q.post(Event{Kind: EventPressure, Pressure: Pressure(level)})
+}
@@ -0,0 +1,125 @@
+package queue
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPressure(t *testing.T) {
+	c := NewCircular(4)
+	var changes []Pressure
+	if err := c.SetWatermarks(1, 3, func(from, to Pressure) {
+		if len(changes) > 0 && changes[len(changes)-1] != from {
+			t.Errorf("expected the change to be from %s, got %s", changes[len(changes)-1], from)
+		}
+		changes = append(changes, to)
+	}); err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	// the changes are also published to the queue's Bus.
+	b := NewBus()
+	c.SetBus(b)
+	var published []Pressure
+	b.Subscribe(func(e Event) {
+		if e.Kind == EventPressure {
+			published = append(published, e.Pressure)
+		}
+	})
+	tests := []struct {
+		name     string
+		op       func()
+		expected Pressure
+	}{
+		{"1", func() { c.Enqueue(1) }, PressureOK},
+		{"2", func() { c.Enqueue(2) }, PressureOK},
+		{"high", func() { c.Enqueue(3) }, PressureWarning},
+		{"full", func() { c.Enqueue(4) }, PressureFull},
+		{"rejected", func() { c.Enqueue(5) }, PressureFull},
+		{"3", func() { c.Dequeue() }, PressureWarning},
+		{"above low", func() { c.Dequeue() }, PressureWarning},
+		{"low", func() { c.Dequeue() }, PressureOK},
+		{"refill", func() { c.Enqueue(6) }, PressureOK},
+		{"cancel", func() { c.Enqueue(job{"a", 0}); c.CancelTag("a") }, PressureWarning},
+		{"full again", func() { c.EnqueueAll([]interface{}{7, 8}) }, PressureFull},
+		{"remove", func() { c.RemoveWhere(func(interface{}) bool { return true }) }, PressureOK},
+	}
+	for _, test := range tests {
+		test.op()
+		if p := c.Pressure(); p != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, p)
+		}
+	}
+	expected := []Pressure{PressureWarning, PressureFull, PressureWarning, PressureOK, PressureWarning, PressureFull, PressureOK}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected the changes to be %v, got %v", expected, changes)
+	}
+	if !reflect.DeepEqual(published, expected) {
+		t.Errorf("expected the published changes to be %v, got %v", expected, published)
+	}
+}
+
+func TestPressureChanged(t *testing.T) {
+	q := NewQueue(2)
+	if q.PressureChanged() != nil {
+		t.Error("expected a queue without watermarks to return a nil channel")
+	}
+	for i := 0; i < 3; i++ {
+		q.Enqueue(i)
+	}
+	// the queue is already at its high watermark.
+	q.SetWatermarks(0, 2, nil)
+	if q.Pressure() != PressureWarning {
+		t.Errorf("expected %s, got %s", PressureWarning, q.Pressure())
+	}
+	changed := q.PressureChanged()
+	q.Dequeue()
+	q.Dequeue()
+	select {
+	case <-changed:
+		t.Error("expected the channel to stay open while the pressure is the same")
+	default:
+	}
+	// an unbounded queue is never full.
+	if q.Pressure() != PressureWarning {
+		t.Errorf("expected %s, got %s", PressureWarning, q.Pressure())
+	}
+	q.Dequeue()
+	select {
+	case <-changed:
+	default:
+		t.Error("expected the channel to be closed once the pressure changed")
+	}
+	if q.Pressure() != PressureOK {
+		t.Errorf("expected %s, got %s", PressureOK, q.Pressure())
+	}
+	q.SetWatermarks(0, 0, nil)
+	if q.PressureChanged() != nil {
+		t.Error("expected removing the watermarks to return a nil channel")
+	}
+}
+
+func TestSetWatermarksErrors(t *testing.T) {
+	tests := []struct {
+		low, high int
+		expected  string
+	}{
+		{-1, 2, "cannot set watermarks: low, -1, must be at least 0 and less than high, 2"},
+		{2, 2, "cannot set watermarks: low, 2, must be at least 0 and less than high, 2"},
+	}
+	for _, test := range tests {
+		if err := NewQueue(1).SetWatermarks(test.low, test.high, nil); err == nil || err.Error() != test.expected {
+			t.Errorf("%d %d: expected %q, got %v", test.low, test.high, test.expected, err)
+		}
+	}
+}
+
+func TestPressureString(t *testing.T) {
+	for _, test := range []struct {
+		p        Pressure
+		expected string
+	}{{PressureOK, "ok"}, {PressureWarning, "warning"}, {PressureFull, "full"}, {Pressure(9), "unknown"}} {
+		if s := test.p.String(); s != test.expected {
+			t.Errorf("expected %s, got %s", test.expected, s)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package queue
+
+import (
+	"container/heap"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Priority is an unbounded priority queue: Dequeue returns the item with the
+// highest priority. Items with the same priority are dequeued in the order
+// they were enqueued.
+//
+// A bounded priority queue, see NewBoundedPriority, holds at most its cap
+// items. When it is full, an item is only admitted if it has a higher
+// priority than the lowest priority item in the queue, which is evicted to
+// make room, so that low priority work can't crowd out important work.
+type Priority struct {
+	mu      sync.Mutex
+	items   PQueue
+	seq     uint64 // the seq of the next item enqueued.
+	cap     int    // the most items the queue holds; 0 is unbounded.
+	evicted func(item interface{}, priority int)
+	aging   func(priority int, waited time.Duration) int // see SetAging.
+}
+
+// NewPriority returns an empty priority queue with an initial capacity equal
+// to the received size.
+func NewPriority(size int) *Priority {
+	if size < 0 {
+		size = 0
+	}
+	return &Priority{items: make(PQueue, 0, size)}
+}
+
+// NewBoundedPriority returns an empty priority queue that holds at most size
+// items; a size less than 1 is set to 1. When the queue is full, enqueueing
+// an item with a higher priority than the lowest priority queued item evicts
+// that item, which is passed to the evicted func, if there is one. The
+// evicted func is called after the queue's lock is released.
+func NewBoundedPriority(size int, evicted func(item interface{}, priority int)) *Priority {
+	if size < 1 {
+		size = 1
+	}
+	p := NewPriority(size)
+	p.cap = size
+	p.evicted = evicted
+	return p
+}
+
+// Enqueue adds an item to the queue with the received priority; the higher
+// the priority, the sooner the item is dequeued. If the queue is bounded and
+// full, and the item's priority isn't higher than that of the lowest priority
+// queued item, an error is returned; finding the lowest priority item is
+// O(n).
+func (p *Priority) Enqueue(item interface{}, priority int) error {
+	p.mu.Lock()
+	var lowest *Item
+	if p.cap > 0 && len(p.items) >= p.cap {
+		lowest = p.lowest()
+		if priority <= lowest.priority {
+			p.mu.Unlock()
+			return fmt.Errorf("queue full: cannot enqueue %v", item)
+		}
+		heap.Remove(&p.items, lowest.index)
+	}
+	it := &Item{value: item, priority: priority, seq: p.seq}
+	if p.aging != nil {
+		it.enqueued = time.Now()
+	}
+	heap.Push(&p.items, it)
+	p.seq++
+	p.mu.Unlock()
+	if lowest != nil && p.evicted != nil {
+		p.evicted(lowest.value, lowest.priority)
+	}
+	return nil
+}
+
+// lowest returns the item that would be dequeued last: the newest of the
+// items with the lowest priority. It's one of the heap's leaves. The caller
+// must hold the lock and the queue must not be empty.
+func (p *Priority) lowest() *Item {
+	lowest := p.items[len(p.items)-1]
+	for _, it := range p.items[len(p.items)/2:] {
+		if p.items.Less(lowest.index, it.index) {
+			lowest = it
+		}
+	}
+	return lowest
+}
+
+// Cap returns the most items the queue holds, or 0 if it is unbounded.
+func (p *Priority) Cap() int {
+	return p.cap
+}
+
+// IsFull returns whether or not the queue is bounded and full.
+func (p *Priority) IsFull() bool {
+	return p.cap > 0 && p.Len() >= p.cap
+}
+
+// Dequeue removes the item with the highest priority from the queue and
+// returns it. If the queue is empty, a false will be returned.
+func (p *Priority) Dequeue() (interface{}, bool) {
+	item, _, ok := p.DequeuePriority()
+	return item, ok
+}
+
+// DequeuePriority is Dequeue, but it also returns the item's priority, as it
+// was enqueued.
+func (p *Priority) DequeuePriority() (item interface{}, priority int, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	it := p.next()
+	if it == nil {
+		return nil, 0, false
+	}
+	heap.Remove(&p.items, it.index)
+	return it.value, it.priority, true
+}
+
+// Peek returns the item with the highest priority without removing it from
+// the queue. If the queue is empty, a false will be returned.
+func (p *Priority) Peek() (interface{}, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	it := p.next()
+	if it == nil {
+		return nil, false
+	}
+	return it.value, true
+}
+
+// IsEmpty returns whether or not the queue is empty.
+func (p *Priority) IsEmpty() bool {
+	return p.Len() == 0
+}
+
+// Len returns the number of items in the queue.
+func (p *Priority) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.items)
+}
+
+// Reset removes all of the items from the queue.
+func (p *Priority) Reset() {
+	p.mu.Lock()
+	clear(p.items)
+	p.items = p.items[:0]
+	p.mu.Unlock()
+}
@@ -0,0 +1,155 @@
+package queue
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestPriority(t *testing.T) {
+	tests := []struct {
+		name     string
+		items    []string
+		pris     []int
+		expected []string
+	}{
+		{"empty", nil, nil, nil},
+		{"priority order", []string{"a", "b", "c"}, []int{1, 3, 2}, []string{"b", "c", "a"}},
+		{"fifo among equals", []string{"a", "b", "c", "d"}, []int{1, 1, 1, 1}, []string{"a", "b", "c", "d"}},
+		{"mixed", []string{"a", "b", "c", "d", "e", "f"}, []int{2, 1, 2, 3, 1, 2}, []string{"d", "a", "c", "f", "b", "e"}},
+		{"negative", []string{"a", "b", "c"}, []int{-1, 0, -5}, []string{"b", "a", "c"}},
+	}
+	for _, test := range tests {
+		p := NewPriority(2)
+		for i, item := range test.items {
+			p.Enqueue(item, test.pris[i])
+		}
+		if p.Len() != len(test.items) {
+			t.Errorf("%s: expected len %d, got %d", test.name, len(test.items), p.Len())
+		}
+		for i, expected := range test.expected {
+			if v, ok := p.Peek(); !ok || v != expected {
+				t.Errorf("%s: %d: peek: expected %s true, got %v %t", test.name, i, expected, v, ok)
+			}
+			if v, ok := p.Dequeue(); !ok || v != expected {
+				t.Errorf("%s: %d: expected %s true, got %v %t", test.name, i, expected, v, ok)
+			}
+		}
+		if !p.IsEmpty() {
+			t.Errorf("%s: expected the queue to be empty", test.name)
+		}
+		if v, ok := p.Dequeue(); ok {
+			t.Errorf("%s: expected dequeue of an empty queue to fail, got %v", test.name, v)
+		}
+	}
+}
+
+func TestPriorityStable(t *testing.T) {
+	// interleave enqueues and dequeues: equal priorities must stay FIFO as
+	// the heap is rearranged.
+	p := NewPriority(0)
+	next := map[int]int{}
+	var n int
+	for round := 0; round < 50; round++ {
+		for i := 0; i < 7; i++ {
+			p.Enqueue([2]int{n % 3, n}, n%3)
+			n++
+		}
+		for i := 0; i < 5; i++ {
+			v, pri, ok := p.DequeuePriority()
+			if !ok {
+				t.Fatal("unexpected empty queue")
+			}
+			item := v.([2]int)
+			if item[0] != pri {
+				t.Fatalf("expected priority %d, got %d", item[0], pri)
+			}
+			if item[1] < next[pri] {
+				t.Fatalf("priority %d: item %d dequeued after %d", pri, item[1], next[pri])
+			}
+			next[pri] = item[1]
+		}
+	}
+	p.Reset()
+	if p.Len() != 0 {
+		t.Errorf("expected reset to empty the queue, got %d", p.Len())
+	}
+}
+
+func TestPriorityConcurrent(t *testing.T) {
+	p := NewPriority(0)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 250; j++ {
+				p.Enqueue(j, j%10)
+			}
+		}(i)
+	}
+	wg.Wait()
+	prev := 10
+	for {
+		_, pri, ok := p.DequeuePriority()
+		if !ok {
+			break
+		}
+		if pri > prev {
+			t.Fatalf("expected priorities in decreasing order, got %d after %d", pri, prev)
+		}
+		prev = pri
+	}
+}
+
+func TestBoundedPriority(t *testing.T) {
+	type eviction struct {
+		item     interface{}
+		priority int
+	}
+	var evicted []eviction
+	p := NewBoundedPriority(3, func(item interface{}, priority int) {
+		evicted = append(evicted, eviction{item, priority})
+	})
+	tests := []struct {
+		item     string
+		priority int
+		err      bool
+		evicted  interface{}
+	}{
+		{"a", 1, false, nil},
+		{"b", 5, false, nil},
+		{"c", 1, false, nil},
+		{"d", 1, true, nil},  // not higher than the lowest
+		{"e", 0, true, nil},  // lower
+		{"f", 2, false, "c"}, // evicts the newest of the lowest
+		{"g", 9, false, "a"},
+		{"h", 3, false, "f"},
+	}
+	for _, test := range tests {
+		n := len(evicted)
+		err := p.Enqueue(test.item, test.priority)
+		if (err != nil) != test.err {
+			t.Errorf("%s: expected error %t, got %v", test.item, test.err, err)
+		}
+		if test.evicted == nil {
+			if len(evicted) != n {
+				t.Errorf("%s: expected no eviction, got %v", test.item, evicted[n:])
+			}
+			continue
+		}
+		if len(evicted) != n+1 || evicted[n].item != test.evicted {
+			t.Errorf("%s: expected %v to be evicted, got %v", test.item, test.evicted, evicted[n:])
+		}
+	}
+	if !p.IsFull() || p.Cap() != 3 {
+		t.Errorf("expected a full queue with a cap of 3, got len %d cap %d", p.Len(), p.Cap())
+	}
+	for _, expected := range []string{"g", "b", "h"} {
+		if v, _ := p.Dequeue(); v != expected {
+			t.Errorf("expected %s, got %v", expected, v)
+		}
+	}
+	if NewPriority(0).IsFull() {
+		t.Error("expected an unbounded queue to never be full")
+	}
+}
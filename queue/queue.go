@@ -6,6 +6,7 @@ package queue
 import (
 	"math"
 	"sync"
+	"sync/atomic"
 )
 
 // Queuer interface
@@ -33,10 +34,40 @@ var shiftPercent = 50
 // or its alias, NewQueue().
 type Queue struct {
 	sync.Mutex
-	InitCap      int
-	Items        []interface{}
-	Head         int // current item in queue
-	shiftPercent int // the % of items that need to be removed before shifting occurs
+	initCap       int // the initial cap of items; see Resize.
+	items         []interface{}
+	head          int           // current item in queue
+	shiftPercent  int           // the % of items that need to be removed before shifting occurs
+	shrinkPercent int           // the occupancy %, of cap, below which the queue shrinks; 0 is never.
+	state         atomic.Uint64 // len and cap packed into one word; see pack.
+	bus           atomic.Pointer[Bus]
+	id            atomic.Pointer[identity]     // the queue's name and labels; see SetName.
+	sizer         Sizer                        // sizes items for bytes; see SetSizer.
+	bytes         atomic.Int64                 // the total size of the items in the queue.
+	fmu           sync.Mutex                   // protects frost, and changes to taps and hooks.
+	frost         *frost                       // the current Freeze, if the queue is frozen.
+	cancelled     map[string]struct{}          // cancelled tags; see CancelTag.
+	changed       chan struct{}                // closed when the queue changes, if anyone is waiting; see wait.
+	claimed       bool                         // whether the head item is claimed; see Claim.
+	panics        atomic.Pointer[PanicHandler] // recovers sizer and hook panics; see SetPanicHandler.
+	stats         counters                     // see Stats.
+	taps          atomic.Pointer[[]*tap]       // copy on write; see Tap.
+	hooks         atomic.Pointer[[]*hook]      // copy on write; see OnEnqueue.
+	marks         atomic.Pointer[watermarks]   // set while holding the lock; see SetWatermarks.
+	reserved      map[Token]reservation        // see Reserve.
+	tokens        uint64                       // the last Token issued.
+}
+
+// pack packs a queue's len and cap into a single word so that both can be
+// read atomically, without taking the lock. The len is in the high 32 bits,
+// the cap in the low 32 bits.
+func pack(len, cap int) uint64 {
+	return uint64(uint32(len))<<32 | uint64(uint32(cap))
+}
+
+// unpack returns the len and cap from a packed word.
+func unpack(state uint64) (len, cap int) {
+	return int(state >> 32), int(uint32(state))
 }
 
 // NewQ is a convenience wrapper to NewQ().
@@ -47,7 +78,20 @@ func NewQ(size int) *Queue {
 // NewQueue returns an empty queue with an initial capacity equal to the
 // recieved size.
 func NewQueue(size int) *Queue {
-	return &Queue{InitCap: size, Items: make([]interface{}, 0, size), shiftPercent: shiftPercent}
+	q := &Queue{initCap: size, items: make([]interface{}, 0, size), shiftPercent: shiftPercent}
+	q.publish()
+	return q
+}
+
+// publish updates the queue's packed len and cap, and wakes any goroutines
+// waiting for the queue to change. This must be called, while holding the
+// lock, after any operation that changes either.
+func (q *Queue) publish() {
+	l := len(q.items) - q.head
+	q.state.Store(pack(l, cap(q.items)))
+	q.stats.mark(l)
+	q.gauge(l, cap(q.items), false)
+	q.broadcast()
 }
 
 // SetShiftPercent sets the queue's shiftPercent: the percentage of the queue
@@ -70,18 +114,49 @@ func (q *Queue) SetShiftPercent(i int) {
 	q.shiftPercent = i
 }
 
+// SetShrinkThreshold sets the queue's shrink threshold: when a dequeue leaves
+// the queue with fewer items than the received percentage of its capacity,
+// the queue is shrunk to twice its length, or its initial capacity, whichever
+// is larger, so that the memory used during a burst is reclaimed. A threshold
+// of 0, the default, never shrinks the queue.
+//
+// Valid range of values are 0-25, inclusive; values < 0 are set to 0 and
+// values > 25 are set to 25. Capping the threshold at 25 keeps dequeues
+// amortized O(1): a shrunk queue must lose half of its items before it is
+// shrunk again.
+func (q *Queue) SetShrinkThreshold(i int) {
+	q.Lock()
+	defer q.Unlock()
+	if i < 0 {
+		i = 0
+	}
+	if i > 25 {
+		i = 25
+	}
+	q.shrinkPercent = i
+}
+
 // Enqueue adds an item to the queue. If adding the item requires growing
 // the queue, the queue will either be shifted, to make room at the end of
 // the queue, or it will grow.
 func (q *Queue) Enqueue(item interface{}) error {
 	q.Lock()
-	defer q.Unlock()
+	q.enqueue(item)
+	q.Unlock()
+	q.emit(EventEnqueue, item)
+	return nil
+}
+
+// enqueue is the unexported version of Enqueue; the caller must hold the
+// lock.
+func (q *Queue) enqueue(item interface{}) {
 	// See if it needs to grow
-	if len(q.Items) == cap(q.Items) {
+	if len(q.items) == cap(q.items) {
 		_ = q.shift()
 	}
-	q.Items = append(q.Items, item)
-	return nil
+	q.items = append(q.items, item)
+	q.account(item, 1)
+	q.publish()
 }
 
 // Dequeue removes an item from the queue. If the removal of the item empties
@@ -89,37 +164,80 @@ func (q *Queue) Enqueue(item interface{}) error {
 // false will be returned, else true.
 func (q *Queue) Dequeue() (interface{}, bool) {
 	q.Lock()
-	defer q.Unlock()
-	if q.isEmpty() {
+	item, shrunk, ok := q.dequeue()
+	q.Unlock()
+	if !ok {
 		return nil, false
 	}
-	q.Head++
-	return q.Items[q.Head-1], true
+	q.emitDequeue(item, shrunk)
+	return item, true
+}
+
+// dequeue is the unexported version of Dequeue; it also returns whether or
+// not the dequeue shrunk the queue. The caller must hold the lock.
+func (q *Queue) dequeue() (item interface{}, shrunk, ok bool) {
+	if q.isEmpty() || q.claimed {
+		return nil, false, false
+	}
+	item = q.items[q.head]
+	// release the slot's reference so the item can be collected.
+	q.items[q.head] = nil
+	q.head++
+	q.account(item, -1)
+	shrunk = q.shrink()
+	q.publish()
+	return item, shrunk, true
+}
+
+// emitDequeue emits the events for a dequeue.
+func (q *Queue) emitDequeue(item interface{}, shrunk bool) {
+	q.emit(EventDequeue, item)
+	if shrunk {
+		q.emit(EventResize, nil)
+	}
+}
+
+// shrink shrinks the queue if it is below its shrink threshold. Returns
+// whether or not the queue was shrunk. The caller must hold the lock.
+func (q *Queue) shrink() bool {
+	l := len(q.items) - q.head
+	if q.shrinkPercent == 0 || cap(q.items) <= q.initCap || l*100 >= cap(q.items)*q.shrinkPercent {
+		return false
+	}
+	n := 2 * l
+	if n < q.initCap {
+		n = q.initCap
+	}
+	q.items = append(make([]interface{}, 0, n), q.items[q.head:]...)
+	q.head = 0
+	return true
 }
 
 // Peek returns the next item in the queue. Post-peek, the queue remains the
-// same.
+// same. If the queue is empty, Peek returns without taking the lock.
 func (q *Queue) Peek() (interface{}, bool) {
+	if q.IsEmpty() {
+		return nil, false
+	}
 	q.Lock()
 	defer q.Unlock()
 	if q.isEmpty() {
 		return nil, false
 	}
-	return q.Items[q.Head], true
+	return q.items[q.head], true
 }
 
-// IsEmpty returns whether or not the queue is empty
+// IsEmpty returns whether or not the queue is empty. This does not take the
+// lock.
 func (q *Queue) IsEmpty() bool {
-	q.Lock()
-	defer q.Unlock()
-	return q.isEmpty()
+	return q.Len() == 0
 }
 
 // isEmpty is an unexported version that doesn't lock because the caller
 // will have handled that. Reduces multiple locks/unlocks during operations
 // that need to check for emptiness and have already obtained a lock
 func (q *Queue) isEmpty() bool {
-	if q.Head == len(q.Items) {
+	if q.head == len(q.items) {
 		return true
 	}
 	return false
@@ -131,41 +249,72 @@ func (q *Queue) IsFull() bool {
 	return false
 }
 
-// Len returns the current number of items in the queue
+// Len returns the current number of items in the queue. This does not take
+// the lock.
 func (q *Queue) Len() int {
-	q.Lock()
-	defer q.Unlock()
-	return len(q.Items) - q.Head
+	l, _ := unpack(q.state.Load())
+	return l
 }
 
-// Cap returns the current size of the queue
+// Cap returns the current capacity of the queue. This does not take the
+// lock.
 func (q *Queue) Cap() int {
+	_, cp := unpack(q.state.Load())
+	return cp
+}
+
+// Snapshot returns a copy of the items in the queue, in FIFO order. The queue
+// is not modified. Snapshot doesn't block on a frozen queue; see Freeze.
+func (q *Queue) Snapshot() []interface{} {
+	if items, ok := q.frozenView(q.contents); ok {
+		return items
+	}
 	q.Lock()
 	defer q.Unlock()
-	return cap(q.Items)
+	return q.contents()
 }
 
 // shift: if shiftPercent Items have been removed from the queue,, the
 // remaining items in the queue will be shifted to the beginning of the
 // queue. Returns whether or not a shift occurred.
 func (q *Queue) shift() bool {
-	if q.Head < (cap(q.Items)*q.shiftPercent)/100 {
+	if q.head < (cap(q.items)*q.shiftPercent)/100 {
 		return false
 	}
-	q.Items = append(q.Items[:0], q.Items[q.Head:]...)
+	n := len(q.items)
+	q.items = append(q.items[:0], q.items[q.head:]...)
+	clear(q.items[len(q.items):n])
 	// set the pointers to the correct position
-	q.Head = 0
+	q.head = 0
 	return true
 }
 
-// Reset resets the queue; Head and tail point to element 0. This does not
+// Reset resets the queue; head points to element 0. This does not
 // shrink the queue; for that use Resize(). Any items in the queue will be
 // lost.
 func (q *Queue) Reset() {
 	q.Lock()
-	q.Head = 0
-	q.Items = q.Items[:0]
+	q.reset()
+	q.publish()
 	q.Unlock()
+	q.emit(EventReset, nil)
+}
+
+// Clear removes all of the items from the queue and releases the queue's
+// references to them, so that they can be collected even while the queue is
+// kept around. It is the same as Reset.
+func (q *Queue) Clear() {
+	q.Reset()
+}
+
+// reset is the unexported version of Reset; the caller must hold the lock.
+// The items' slots are zeroed so the queue doesn't keep them alive.
+func (q *Queue) reset() {
+	q.claimed = false
+	q.head = 0
+	clear(q.items)
+	q.items = q.items[:0]
+	q.bytes.Store(0)
 }
 
 // Resize resizes the queue to the received size, or, either its original
@@ -175,20 +324,28 @@ func (q *Queue) Reset() {
 // Queues with space at the front are shifted to the front.
 func (q *Queue) Resize(size int) int {
 	q.Lock()
-	i := int(math.Mod(float64(len(q.Items)), float64(cap(q.Items)))*1.25) - q.Head
-	if i < q.InitCap {
-		i = q.InitCap
+	i := q.resize(size)
+	q.publish()
+	q.Unlock()
+	q.emit(EventResize, nil)
+	return i
+}
+
+// resize is the unexported version of Resize; the caller must hold the lock.
+func (q *Queue) resize(size int) int {
+	i := int(math.Mod(float64(len(q.items)), float64(cap(q.items)))*1.25) - q.head
+	if i < q.initCap {
+		i = q.initCap
 	}
 	if size > i {
 		i = size
 	}
 	tmp := make([]interface{}, 0, i)
 	// if necessary, shift Items to front.
-	if q.Head > 0 || len(q.Items) > 0 {
-		tmp = append(tmp, q.Items[q.Head:]...)
-		q.Head = 0
+	if q.head > 0 || len(q.items) > 0 {
+		tmp = append(tmp, q.items[q.head:]...)
+		q.head = 0
 	}
-	q.Items = tmp
-	q.Unlock()
+	q.items = tmp
 	return i
 }
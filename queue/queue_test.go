@@ -7,11 +7,11 @@ import (
 func TestNew(t *testing.T) {
 	q := NewQ(10)
 	if q.Cap() != 10 {
-		t.Errorf("expected 10, got %d", cap(q.Items))
+		t.Errorf("expected 10, got %d", cap(q.items))
 	}
 	q = NewQueue(100)
 	if q.Cap() != 100 {
-		t.Errorf("expected 100, got %d", cap(q.Items))
+		t.Errorf("expected 100, got %d", cap(q.items))
 	}
 }
 
@@ -36,17 +36,17 @@ func TestQueueing(t *testing.T) {
 		}
 		// check that the items are as expected:
 		if q.Len() != test.expectedLen {
-			t.Errorf("%d: expected %d items in queue, got %d", i, test.expectedLen, len(q.Items))
+			t.Errorf("%d: expected %d items in queue, got %d", i, test.expectedLen, len(q.items))
 		}
 		if q.Cap() != test.expectedCap {
-			t.Errorf("%d: expected queue cap to be %d, got %d", i, test.expectedCap, cap(q.Items))
+			t.Errorf("%d: expected queue cap to be %d, got %d", i, test.expectedCap, cap(q.items))
 		}
-		if q.Head != test.headPos {
-			t.Errorf("%d: expected head to be at pos %d, got %d", i, test.headPos, q.Head)
+		if q.head != test.headPos {
+			t.Errorf("%d: expected head to be at pos %d, got %d", i, test.headPos, q.head)
 		}
-		for j := 0; j < len(q.Items); j++ {
-			if q.Items[j] != test.items[j] {
-				t.Errorf("%d: expected value of index %d to be %d, got %d", i, j, test.items[j], q.Items[j])
+		for j := 0; j < len(q.items); j++ {
+			if q.items[j] != test.items[j] {
+				t.Errorf("%d: expected value of index %d to be %d, got %d", i, j, test.items[j], q.items[j])
 			}
 		}
 
@@ -56,8 +56,8 @@ func TestQueueing(t *testing.T) {
 			t.Errorf("%d: expected %d, got %d", i, test.items[0], next)
 			continue
 		}
-		if q.Head != 1 {
-			t.Errorf("%d: expected head to point to 1, got %d", i, q.Head)
+		if q.head != 1 {
+			t.Errorf("%d: expected head to point to 1, got %d", i, q.head)
 		}
 	}
 }
@@ -92,8 +92,8 @@ func TestQDequeueEnqueue(t *testing.T) {
 		for _, v := range test.items {
 			_ = q.Enqueue(v)
 		}
-		if q.Head != test.headPos {
-			t.Errorf("%d: post queue population, expected head pos to be %d, got %d", i, test.headPos, q.Head)
+		if q.head != test.headPos {
+			t.Errorf("%d: post queue population, expected head pos to be %d, got %d", i, test.headPos, q.head)
 		}
 		if q.Len() != test.expectedLen {
 			t.Errorf("%d: post queue population, expected len to be %d got %d", i, test.expectedLen, q.Len())
@@ -108,23 +108,23 @@ func TestQDequeueEnqueue(t *testing.T) {
 				t.Errorf("%d: dequeue: expected %v, got %v", i, test.dequeueVals[i], v)
 			}
 		}
-		if q.Head != test.dequeueCnt {
-			t.Errorf("%d: post deuque: expected head to point to %d, got %d", i, test.dequeueCnt, q.Head)
+		if q.head != test.dequeueCnt {
+			t.Errorf("%d: post deuque: expected head to point to %d, got %d", i, test.dequeueCnt, q.head)
 		}
 		// peek stuff
 		v, _ := q.Peek()
 		if v.(int) != test.expectedPeek {
 			t.Errorf("%d: post peek: expected peek to return %d, got %d", i, test.expectedPeek, v.(int))
 		}
-		if q.Head != test.postPeekHeadPos {
-			t.Errorf("%d: post peek: expected head to be at pos %d, got %d", i, test.postPeekHeadPos, q.Head)
+		if q.head != test.postPeekHeadPos {
+			t.Errorf("%d: post peek: expected head to be at pos %d, got %d", i, test.postPeekHeadPos, q.head)
 		}
 		// enqueue the next items; should not grow, should just shift the items
 		for _, v := range test.enqueueItems {
 			q.Enqueue(v)
 		}
-		if q.Head != 0 {
-			t.Errorf("%d post enqueue: expected head to be at pos 0, got %d", i, q.Head)
+		if q.head != 0 {
+			t.Errorf("%d post enqueue: expected head to be at pos 0, got %d", i, q.head)
 		}
 		if q.Len() != test.postEnqueueLen {
 			t.Errorf("%d post enqueue: expected tail to be at %d, got %d", i, test.postEnqueueLen, q.Len())
@@ -272,8 +272,8 @@ func TestQueueResetResize(t *testing.T) {
 		if q.Len() != 0 {
 			t.Errorf("%d: after Reset(), expected queue len to be 0, got %d", i, q.Len())
 		}
-		if q.Head != 0 {
-			t.Errorf("%d: after Reset(), expected queue head to be at pos 0, was at pos %d", i, q.Head)
+		if q.head != 0 {
+			t.Errorf("%d: after Reset(), expected queue head to be at pos 0, was at pos %d", i, q.head)
 		}
 		if q.Cap() != test.cap {
 			t.Errorf("%d: after Reset(), expected queue cap to be %d, got %d", i, test.cap, q.Cap())
@@ -298,11 +298,187 @@ func TestQueueResetResize(t *testing.T) {
 		if q.Len() != test.expectedLen {
 			t.Errorf("%d: after Resize(), expected queue len to be %d, got %d", i, test.expectedLen, q.Len())
 		}
-		if q.Head != 0 {
-			t.Errorf("%d: after Resize(), expected queue head to be at pos 0, was at pos %d", i, q.Head)
+		if q.head != 0 {
+			t.Errorf("%d: after Resize(), expected queue head to be at pos 0, was at pos %d", i, q.head)
 		}
 		if q.Cap() != test.expectedCap {
 			t.Errorf("%d: after Resize(), expected queue cap to be %d, got %d", i, test.expectedCap, q.Cap())
 		}
 	}
 }
+
+// Len, IsEmpty, IsFull, and Peek on an empty queue must not take the lock.
+func TestQueueLockFreeAccessors(t *testing.T) {
+	q := NewQ(2)
+	q.Lock()
+	if !q.IsEmpty() || q.IsFull() || q.Len() != 0 {
+		t.Errorf("expected empty queue, got IsEmpty %t, IsFull %t, Len %d", q.IsEmpty(), q.IsFull(), q.Len())
+	}
+	if _, ok := q.Peek(); ok {
+		t.Error("expected peek of an empty queue to return false")
+	}
+	q.Unlock()
+	for i := 0; i < 3; i++ {
+		_ = q.Enqueue(i)
+	}
+	q.Dequeue()
+	q.Lock()
+	if q.IsEmpty() || q.Len() != 2 {
+		t.Errorf("expected 2 items, got IsEmpty %t, Len %d", q.IsEmpty(), q.Len())
+	}
+	q.Unlock()
+}
+
+// FuzzQueue interprets its input as a sequence of operations on a Queue and
+// checks the queue against a slice based model after each one. The first
+// byte is the queue's initial size.
+func FuzzQueue(f *testing.F) {
+	f.Add([]byte{2, 0, 0, 0, 1, 0, 1, 1, 1})
+	f.Add([]byte{1, 0, 0, 0, 1, 1, 0, 0, 0, 4, 1, 3, 0})
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		if len(ops) == 0 {
+			return
+		}
+		q := NewQueue(int(ops[0]%16) + 1)
+		q.SetShiftPercent(int(ops[0] % 101))
+		var model []int
+		for i, op := range ops[1:] {
+			switch op % 5 {
+			case 0:
+				if err := q.Enqueue(i); err != nil {
+					t.Fatalf("op %d: unexpected error: %s", i, err)
+				}
+				model = append(model, i)
+			case 1:
+				v, ok := q.Dequeue()
+				if ok != (len(model) > 0) {
+					t.Fatalf("op %d: expected dequeue to return %t, got %t", i, len(model) > 0, ok)
+				}
+				if ok {
+					if v != model[0] {
+						t.Fatalf("op %d: expected %d, got %v", i, model[0], v)
+					}
+					model = model[1:]
+				}
+			case 2:
+				v, ok := q.Peek()
+				if ok != (len(model) > 0) {
+					t.Fatalf("op %d: expected peek to return %t, got %t", i, len(model) > 0, ok)
+				}
+				if ok && v != model[0] {
+					t.Fatalf("op %d: expected %d, got %v", i, model[0], v)
+				}
+			case 3:
+				q.Reset()
+				model = model[:0]
+			case 4:
+				q.Resize(int(op / 5))
+			}
+			if q.Len() != len(model) {
+				t.Fatalf("op %d: expected len to be %d, got %d", i, len(model), q.Len())
+			}
+			if q.IsEmpty() != (len(model) == 0) {
+				t.Fatalf("op %d: expected IsEmpty to be %t, got %t", i, len(model) == 0, q.IsEmpty())
+			}
+		}
+	})
+}
+
+func TestQueueShrinkThreshold(t *testing.T) {
+	tests := []struct {
+		threshold int
+		dequeue   int
+		shrinks   bool
+		cap       int // the expected cap, if it is known.
+	}{
+		{0, 63, false, 0},  // never shrinks.
+		{25, 40, false, 0}, // 24 items, more than 25% of cap.
+		{25, 50, true, 0},  // 14 items, less than 25% of cap.
+		{25, 64, true, 4},  // empty; shrinks to the initial cap.
+		{90, 40, false, 0}, // the threshold is capped at 25.
+		{-1, 63, false, 0}, // the threshold is raised to 0.
+	}
+	for i, test := range tests {
+		q, control := NewQueue(4), NewQueue(4)
+		q.SetShrinkThreshold(test.threshold)
+		for j := 0; j < 64; j++ {
+			_ = q.Enqueue(j)
+			_ = control.Enqueue(j)
+		}
+		for j := 0; j < test.dequeue; j++ {
+			q.Dequeue()
+			control.Dequeue()
+		}
+		switch {
+		case test.cap > 0 && q.Cap() != test.cap:
+			t.Errorf("%d: expected cap to be %d, got %d", i, test.cap, q.Cap())
+		case test.shrinks && q.Cap() >= control.Cap():
+			t.Errorf("%d: expected the queue to shrink from %d, got %d", i, control.Cap(), q.Cap())
+		case !test.shrinks && q.Cap() != control.Cap():
+			t.Errorf("%d: expected cap to be %d, got %d", i, control.Cap(), q.Cap())
+		}
+		// the remaining items must be kept, in order.
+		for j := test.dequeue; j < 64; j++ {
+			if v, ok := q.Dequeue(); !ok || v != j {
+				t.Errorf("%d: expected %d true, got %v %t", i, j, v, ok)
+				break
+			}
+		}
+	}
+}
+
+func TestReleasesReferences(t *testing.T) {
+	q := NewQueue(4)
+	c := NewCircular(4)
+	fill := func(q Queuer) {
+		q.Reset()
+		for i := 0; i < 4; i++ {
+			q.Enqueue(i + 1)
+		}
+	}
+	// each op leaves the queue with two items, 3 and 4, and must not hold on
+	// to the others.
+	ops := []struct {
+		name string
+		op   func(Queuer)
+	}{
+		{"dequeue", func(q Queuer) { q.Dequeue(); q.Dequeue() }},
+		{"dequeueN", func(q Queuer) { q.(interface{ DequeueN(int) []interface{} }).DequeueN(2) }},
+	}
+	for _, qq := range []struct {
+		name  string
+		q     Queuer
+		items func() []interface{}
+	}{
+		{"queue", q, func() []interface{} { return q.items[:cap(q.items)] }},
+		{"circular", c, func() []interface{} { return c.items }},
+	} {
+		for _, op := range ops {
+			fill(qq.q)
+			op.op(qq.q)
+			for i, v := range qq.items() {
+				if v != nil && v != 3 && v != 4 {
+					t.Errorf("%s %s: expected slot %d to be released, got %v", qq.name, op.name, i, v)
+				}
+			}
+		}
+		fill(qq.q)
+		qq.q.(interface{ Clear() }).Clear()
+		for i, v := range qq.items() {
+			if v != nil {
+				t.Errorf("%s clear: expected slot %d to be released, got %v", qq.name, i, v)
+			}
+		}
+	}
+
+	// shifting the items to the front releases the slots they were moved from.
+	q = NewQueue(4)
+	q.SetShiftPercent(25)
+	fill(q)
+	q.Dequeue()
+	q.Dequeue()
+	q.Enqueue(5)
+	if got := q.items[:cap(q.items)]; got[0] != 3 || got[1] != 4 || got[2] != 5 || got[3] != nil {
+		t.Errorf("shift: expected [3 4 5 <nil>], got %v", got)
+	}
+}
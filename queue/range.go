@@ -0,0 +1,29 @@
+package queue
+
+// Range calls fn for each item in the queue, in FIFO order, with the item's
+// position in the queue, until fn returns false. The queue is not modified:
+// fn is called with a snapshot of the items, taken under the lock, so the
+// walk is consistent even while the queue is in use, and fn may use the
+// queue.
+func (q *Queue) Range(fn func(i int, v interface{}) bool) {
+	rangeItems(q.Snapshot(), fn)
+}
+
+// Range calls fn for each item in the queue, in FIFO order; see Queue.Range.
+func (c *Circular) Range(fn func(i int, v interface{}) bool) {
+	rangeItems(c.Snapshot(), fn)
+}
+
+// Range calls fn for each item in the queue, in FIFO order; see Queue.Range.
+func (r ReadOnly) Range(fn func(i int, v interface{}) bool) {
+	rangeItems(r.q.Snapshot(), fn)
+}
+
+// rangeItems calls fn for each of the items until fn returns false.
+func rangeItems(items []interface{}, fn func(i int, v interface{}) bool) {
+	for i, v := range items {
+		if !fn(i, v) {
+			return
+		}
+	}
+}
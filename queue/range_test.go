@@ -0,0 +1,65 @@
+package queue
+
+import (
+	"testing"
+)
+
+func TestRange(t *testing.T) {
+	// wrap the circular queue around its slice so the walk has to follow the
+	// logical order.
+	c := NewCircular(4)
+	for i := 0; i < 3; i++ {
+		c.Enqueue(-1)
+	}
+	c.DequeueN(3)
+	q := NewQueue(2)
+	for i := 0; i < 4; i++ {
+		c.Enqueue(i)
+		q.Enqueue(i)
+	}
+	q.Dequeue()
+	q.Enqueue(4)
+	tests := []struct {
+		name string
+		q    interface {
+			Range(func(int, interface{}) bool)
+			Len() int
+		}
+		expected []int
+	}{
+		{"queue", q, []int{1, 2, 3, 4}},
+		{"circular", c, []int{0, 1, 2, 3}},
+		{"read only", NewReadOnly(c), []int{0, 1, 2, 3}},
+	}
+	for _, test := range tests {
+		var got []int
+		test.q.Range(func(i int, v interface{}) bool {
+			if i != len(got) {
+				t.Errorf("%s: expected position %d, got %d", test.name, len(got), i)
+			}
+			got = append(got, v.(int))
+			return true
+		})
+		if len(got) != len(test.expected) {
+			t.Fatalf("%s: expected %v, got %v", test.name, test.expected, got)
+		}
+		for i := range got {
+			if got[i] != test.expected[i] {
+				t.Errorf("%s: expected %v, got %v", test.name, test.expected, got)
+				break
+			}
+		}
+		// stopping early.
+		var n int
+		test.q.Range(func(int, interface{}) bool {
+			n++
+			return n < 2
+		})
+		if n != 2 {
+			t.Errorf("%s: expected to stop after 2 items, got %d", test.name, n)
+		}
+		if test.q.Len() != len(test.expected) {
+			t.Errorf("%s: expected the queue to be unchanged, got len %d", test.name, test.q.Len())
+		}
+	}
+}
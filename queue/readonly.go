@@ -0,0 +1,54 @@
+package queue
+
+// Viewer is the read-only part of a queue.
+type Viewer interface {
+	Peek() (interface{}, bool)
+	IsEmpty() bool
+	IsFull() bool
+	Len() int
+	Cap() int
+	Snapshot() []interface{}
+}
+
+// ReadOnly wraps a queue so that it can be handed to code, e.g. monitoring or
+// plugins, that should be able to look at the queue but must not be able to
+// enqueue, dequeue, reset, or resize it. The wrapped queue cannot be
+// retrieved from a ReadOnly.
+type ReadOnly struct {
+	q Viewer
+}
+
+// NewReadOnly returns a read-only view of q.
+func NewReadOnly(q Viewer) ReadOnly {
+	return ReadOnly{q: q}
+}
+
+// Peek returns the next item in the queue without removing it.
+func (r ReadOnly) Peek() (interface{}, bool) {
+	return r.q.Peek()
+}
+
+// IsEmpty returns whether or not the queue is empty.
+func (r ReadOnly) IsEmpty() bool {
+	return r.q.IsEmpty()
+}
+
+// IsFull returns whether or not the queue is full.
+func (r ReadOnly) IsFull() bool {
+	return r.q.IsFull()
+}
+
+// Len returns the number of items in the queue.
+func (r ReadOnly) Len() int {
+	return r.q.Len()
+}
+
+// Cap returns the capacity of the queue.
+func (r ReadOnly) Cap() int {
+	return r.q.Cap()
+}
+
+// Snapshot returns a copy of the items in the queue, in FIFO order.
+func (r ReadOnly) Snapshot() []interface{} {
+	return r.q.Snapshot()
+}
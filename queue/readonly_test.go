@@ -0,0 +1,30 @@
+package queue
+
+import (
+	"testing"
+)
+
+func TestReadOnly(t *testing.T) {
+	for i, q := range []Queuer{NewQueue(2), NewCircular(2)} {
+		r := NewReadOnly(q.(Viewer))
+		if !r.IsEmpty() || r.IsFull() || r.Len() != 0 || r.Cap() != 2 {
+			t.Errorf("%d: expected an empty queue with a cap of 2, got IsEmpty %t, IsFull %t, Len %d, Cap %d", i, r.IsEmpty(), r.IsFull(), r.Len(), r.Cap())
+		}
+		_ = q.Enqueue(1)
+		_ = q.Enqueue(2)
+		if v, ok := r.Peek(); !ok || v != 1 {
+			t.Errorf("%d: expected peek to return 1 true, got %v %t", i, v, ok)
+		}
+		if r.Len() != 2 {
+			t.Errorf("%d: expected len to be 2, got %d", i, r.Len())
+		}
+		s := r.Snapshot()
+		if len(s) != 2 || s[0] != 1 || s[1] != 2 {
+			t.Errorf("%d: expected snapshot to be [1 2], got %v", i, s)
+		}
+		// a ReadOnly must not be usable as a queue.
+		if _, ok := interface{}(r).(Queuer); ok {
+			t.Errorf("%d: expected ReadOnly to not implement Queuer", i)
+		}
+	}
+}
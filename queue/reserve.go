@@ -0,0 +1,228 @@
+package queue
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Token identifies a reserved item; see Reserve.
+type Token uint64
+
+// reservation is a reserved item.
+type reservation struct {
+	item interface{}
+	at   time.Time // when the item was reserved.
+}
+
+// Reserve removes the next item from the queue and reserves it for the
+// caller, who must either Ack it, once it has been processed, or Nack it, to
+// put it back in the queue. An item reserved by a consumer that crashes, or
+// hangs, is put back by Reclaim. This gives any number of consumers
+// at-least-once processing; Claim is the single consumer version. If the
+// queue is empty, a false will be returned.
+func (q *Queue) Reserve() (item interface{}, token Token, ok bool) {
+	q.Lock()
+	item, shrunk, ok := q.dequeue()
+	if ok {
+		token = q.reserve(item)
+	}
+	q.Unlock()
+	if !ok {
+		return nil, 0, false
+	}
+	q.emitDequeue(item, shrunk)
+	return item, token, true
+}
+
+// reserve records item as reserved and returns its token. The caller must
+// hold the lock.
+func (q *Queue) reserve(item interface{}) Token {
+	if q.reserved == nil {
+		q.reserved = make(map[Token]reservation)
+	}
+	q.tokens++
+	q.reserved[Token(q.tokens)] = reservation{item: item, at: time.Now()}
+	return Token(q.tokens)
+}
+
+// Ack acknowledges that the reserved item has been processed; the item is
+// forgotten. If token isn't reserved, e.g. because it was reclaimed, a false
+// will be returned.
+func (q *Queue) Ack(token Token) bool {
+	q.Lock()
+	defer q.Unlock()
+	if _, ok := q.reserved[token]; !ok {
+		return false
+	}
+	delete(q.reserved, token)
+	return true
+}
+
+// Nack puts the reserved item back in the queue, at the head, so that it is
+// retried next, if front is true; otherwise at the tail. If the head is
+// claimed, a front item goes right behind it. If token isn't reserved, e.g.
+// because it was reclaimed, an error is returned.
+func (q *Queue) Nack(token Token, front bool) error {
+	q.Lock()
+	r, ok := q.reserved[token]
+	if !ok {
+		q.Unlock()
+		return fmt.Errorf("cannot nack %d: token isn't reserved", token)
+	}
+	delete(q.reserved, token)
+	if front {
+		q.pushFront(r.item)
+	} else {
+		q.enqueue(r.item)
+	}
+	q.Unlock()
+	q.emit(EventEnqueue, r.item)
+	return nil
+}
+
+// Reclaim puts the items that have been reserved for longer than d back at
+// the head of the queue, in the order they were reserved, e.g. because their
+// consumers crashed. Their tokens are no longer reserved. The number of items
+// reclaimed is returned.
+func (q *Queue) Reclaim(d time.Duration) int {
+	q.Lock()
+	tokens := q.expiredReservations(d)
+	// push the newest first, so the oldest ends up at the head.
+	for i := len(tokens) - 1; i >= 0; i-- {
+		q.pushFront(q.reserved[tokens[i]].item)
+	}
+	items := q.unreserve(tokens)
+	q.Unlock()
+	for _, item := range items {
+		q.emit(EventEnqueue, item)
+	}
+	return len(items)
+}
+
+// expiredReservations returns the tokens that have been reserved for longer
+// than d, oldest first. The caller must hold the lock.
+func (q *Queue) expiredReservations(d time.Duration) []Token {
+	var tokens []Token
+	now := time.Now()
+	for t, r := range q.reserved {
+		if now.Sub(r.at) > d {
+			tokens = append(tokens, t)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i] < tokens[j] })
+	return tokens
+}
+
+// unreserve forgets the tokens and returns their items, in order. The caller
+// must hold the lock.
+func (q *Queue) unreserve(tokens []Token) []interface{} {
+	items := make([]interface{}, len(tokens))
+	for i, t := range tokens {
+		items[i] = q.reserved[t].item
+		delete(q.reserved, t)
+	}
+	return items
+}
+
+// Reserved returns the number of reserved items.
+func (q *Queue) Reserved() int {
+	q.Lock()
+	defer q.Unlock()
+	return len(q.reserved)
+}
+
+// pushFront puts item at the head of the queue or, if the head is claimed,
+// right behind it. The caller must hold the lock.
+func (q *Queue) pushFront(item interface{}) {
+	if q.head == 0 {
+		q.items = append(q.items, nil)
+		// shift the items up one to make room at the front.
+		copy(q.items[1:], q.items)
+		q.head = 1
+	}
+	q.head--
+	q.items[q.head] = item
+	if q.claimed {
+		q.items[q.head], q.items[q.head+1] = q.items[q.head+1], q.items[q.head]
+	}
+	q.account(item, 1)
+	q.publish()
+}
+
+// Reserve removes the next item from the queue and reserves it for the
+// caller; see Queue.Reserve.
+func (c *Circular) Reserve() (item interface{}, token Token, ok bool) {
+	c.Lock()
+	item, ok = c.dequeue()
+	if ok {
+		token = c.reserve(item)
+	}
+	c.Unlock()
+	if !ok {
+		return nil, 0, false
+	}
+	c.emit(EventDequeue, item)
+	return item, token, true
+}
+
+// Nack puts the reserved item back in the queue; see Queue.Nack. If the queue
+// is full, an error is returned and the item stays reserved, so it isn't lost.
+func (c *Circular) Nack(token Token, front bool) error {
+	c.Lock()
+	r, ok := c.reserved[token]
+	if !ok {
+		c.Unlock()
+		return fmt.Errorf("cannot nack %d: token isn't reserved", token)
+	}
+	if c.isFull() {
+		c.Unlock()
+		return fmt.Errorf("queue full: cannot nack %v", r.item)
+	}
+	delete(c.reserved, token)
+	if front {
+		c.pushFront(r.item)
+	} else {
+		c.enqueue(r.item)
+	}
+	c.Unlock()
+	c.emit(EventEnqueue, r.item)
+	return nil
+}
+
+// Reclaim puts the items that have been reserved for longer than d back at
+// the head of the queue; see Queue.Reclaim. If the queue fills up, the
+// newest of the items stay reserved.
+func (c *Circular) Reclaim(d time.Duration) int {
+	c.Lock()
+	tokens := c.expiredReservations(d)
+	if room := cap(c.items) - 1 - c.plen(); len(tokens) > room {
+		tokens = tokens[:room]
+	}
+	for i := len(tokens) - 1; i >= 0; i-- {
+		c.pushFront(c.reserved[tokens[i]].item)
+	}
+	items := c.unreserve(tokens)
+	c.Unlock()
+	for _, item := range items {
+		c.emit(EventEnqueue, item)
+	}
+	return len(items)
+}
+
+// pushFront puts item at the head of the queue or, if the head is claimed,
+// right behind it. The caller must hold the lock and the queue must not be
+// full.
+func (c *Circular) pushFront(item interface{}) {
+	head := c.head
+	if c.head == 0 {
+		c.head = cap(c.items)
+	}
+	c.head--
+	c.items[c.head] = item
+	if c.claimed {
+		c.items[c.head], c.items[head] = c.items[head], c.items[c.head]
+	}
+	c.account(item, 1)
+	c.publish()
+}
@@ -0,0 +1,114 @@
+package queue
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type reserver interface {
+	Queuer
+	Snapshot() []interface{}
+	Claim() (interface{}, bool)
+	Reserve() (interface{}, Token, bool)
+	Ack(Token) bool
+	Nack(Token, bool) error
+	Reclaim(time.Duration) int
+	Reserved() int
+}
+
+func TestReserve(t *testing.T) {
+	tests := []struct {
+		name string
+		q    reserver
+	}{
+		{"queue", NewQueue(4)},
+		{"circular", NewCircular(4)},
+	}
+	for _, test := range tests {
+		q := test.q
+		if _, _, ok := q.Reserve(); ok {
+			t.Errorf("%s: expected an empty queue to reserve false", test.name)
+		}
+		for i := 0; i < 4; i++ {
+			q.Enqueue(i)
+		}
+		v0, t0, _ := q.Reserve()
+		v1, t1, _ := q.Reserve()
+		_, t2, _ := q.Reserve()
+		if v0 != 0 || v1 != 1 || q.Reserved() != 3 || q.Len() != 1 {
+			t.Errorf("%s: expected 0 and 1 to be reserved with 3 reserved and 1 queued, got %v %v %d %d", test.name, v0, v1, q.Reserved(), q.Len())
+		}
+		if !q.Ack(t0) || q.Ack(t0) {
+			t.Errorf("%s: expected the first ack to succeed and the second to fail", test.name)
+		}
+		if err := q.Nack(t1, true); err != nil {
+			t.Errorf("%s: expected no error, got %s", test.name, err)
+		}
+		if err := q.Nack(t2, false); err != nil {
+			t.Errorf("%s: expected no error, got %s", test.name, err)
+		}
+		if err := q.Nack(t2, false); err == nil {
+			t.Errorf("%s: expected a second nack to return an error", test.name)
+		}
+		if s := q.Snapshot(); !reflect.DeepEqual(s, []interface{}{1, 3, 2}) {
+			t.Errorf("%s: expected 1 at the front and 2 at the back, got %v", test.name, s)
+		}
+		if q.Reserved() != 0 {
+			t.Errorf("%s: expected nothing to be reserved, got %d", test.name, q.Reserved())
+		}
+	}
+}
+
+func TestReclaim(t *testing.T) {
+	tests := []struct {
+		name     string
+		q        reserver
+		expected []interface{}
+		reserved int
+	}{
+		{"queue", NewQueue(1), []interface{}{4, 0, 1, 2, 5}, 1},
+		// there's only room for the oldest of the three.
+		{"circular", NewCircular(3), []interface{}{4, 0, 5}, 3},
+	}
+	for _, test := range tests {
+		q := test.q
+		for i := 0; i < 3; i++ {
+			q.Enqueue(i)
+			q.Reserve()
+		}
+		time.Sleep(10 * time.Millisecond)
+		q.Enqueue(3)
+		_, recent, _ := q.Reserve()
+		q.Enqueue(4)
+		q.Enqueue(5)
+		q.Claim()
+		if n := q.Reclaim(5 * time.Millisecond); n != 4-test.reserved {
+			t.Errorf("%s: expected %d items to be reclaimed, got %d", test.name, 4-test.reserved, n)
+		}
+		// the claimed head stays at the head.
+		if s := q.Snapshot(); !reflect.DeepEqual(s, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, s)
+		}
+		if q.Reserved() != test.reserved || !q.Ack(recent) {
+			t.Errorf("%s: expected %d reserved, including the recent reservation, got %d", test.name, test.reserved, q.Reserved())
+		}
+	}
+}
+
+func TestNackFull(t *testing.T) {
+	c := NewCircular(1)
+	c.Enqueue(1)
+	_, token, _ := c.Reserve()
+	c.Enqueue(2)
+	if err := c.Nack(token, true); err == nil || err.Error() != "queue full: cannot nack 1" {
+		t.Errorf("expected a queue full error, got %v", err)
+	}
+	if c.Reserved() != 1 {
+		t.Errorf("expected the item to stay reserved, got %d", c.Reserved())
+	}
+	c.Dequeue()
+	if err := c.Nack(token, true); err != nil {
+		t.Errorf("expected no error, got %s", err)
+	}
+}
@@ -0,0 +1,96 @@
+package queue
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"time"
+)
+
+// Dequeuer is implemented by anything items can be dequeued from.
+type Dequeuer interface {
+	Dequeue() (interface{}, bool)
+}
+
+// changer is implemented by queues that can tell a waiter when they change,
+// e.g. Queue and Circular.
+type changer interface {
+	// changes returns a channel that is closed the next time the queue
+	// changes.
+	changes() <-chan struct{}
+}
+
+// changes returns a channel that is closed the next time the queue changes.
+func (q *Queue) changes() <-chan struct{} {
+	q.Lock()
+	defer q.Unlock()
+	return q.wait()
+}
+
+// The bounds of the backoff used while waiting for a queue that can't say
+// when it changes to have an item.
+const (
+	minPoll = 10 * time.Microsecond
+	maxPoll = time.Millisecond
+)
+
+// Select blocks until one of the queues has an item, dequeues it, and returns
+// it along with the index of the queue it came from. The queues are tried in
+// turn, starting with a random one, so that when more than one queue has an
+// item no queue is favored. If ctx is done first, ctx's error is returned
+// with an index of -1.
+//
+// While the queues are all empty, Select waits for one of them to change.
+// Queues that can't say when they change, i.e. Dequeuers other than a Queue
+// or a Circular, are polled with an increasing backoff, up to 1ms.
+func Select(ctx context.Context, qs ...Dequeuer) (interface{}, int, error) {
+	if len(qs) == 0 {
+		<-ctx.Done()
+		return nil, -1, ctx.Err()
+	}
+	// cases are ctx's done channel, each changer's changes channel, and, if
+	// any of the queues has to be polled, a timer.
+	cases := []reflect.SelectCase{{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}}
+	var changers []changer
+	poll := time.Duration(0)
+	for _, q := range qs {
+		if c, ok := q.(changer); ok {
+			changers = append(changers, c)
+			continue
+		}
+		poll = minPoll
+	}
+	start := rand.Intn(len(qs))
+	for {
+		// get the channels before trying the queues so that a change made
+		// after a queue is found empty isn't missed.
+		cases = cases[:1]
+		for _, c := range changers {
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c.changes())})
+		}
+		for i := range qs {
+			j := (start + i) % len(qs)
+			if v, ok := qs[j].Dequeue(); ok {
+				return v, j, nil
+			}
+		}
+		var timer *time.Timer
+		if poll > 0 {
+			timer = time.NewTimer(poll)
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(timer.C)})
+		}
+		chosen, _, _ := reflect.Select(cases)
+		if timer != nil {
+			timer.Stop()
+			if chosen == len(cases)-1 {
+				if poll *= 2; poll > maxPoll {
+					poll = maxPoll
+				}
+			}
+		}
+		if chosen == 0 {
+			return nil, -1, ctx.Err()
+		}
+		start = (start + 1) % len(qs)
+	}
+}
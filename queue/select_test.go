@@ -0,0 +1,73 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSelect(t *testing.T) {
+	a, b := NewQueue(2), NewCircular(2)
+	_ = b.Enqueue("b")
+	v, i, err := Select(context.Background(), a, b)
+	if err != nil || v != "b" || i != 1 {
+		t.Errorf("expected b 1 nil, got %v %d %v", v, i, err)
+	}
+
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		_ = a.Enqueue("a")
+	}()
+	v, i, err = Select(context.Background(), a, b)
+	if err != nil || v != "a" || i != 0 {
+		t.Errorf("expected a 0 nil, got %v %d %v", v, i, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	v, i, err = Select(ctx, a, b)
+	if err != context.DeadlineExceeded || v != nil || i != -1 {
+		t.Errorf("expected nil -1 %v, got %v %d %v", context.DeadlineExceeded, v, i, err)
+	}
+	if _, _, err = Select(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected %v with no queues, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestSelectRandom(t *testing.T) {
+	a, b := NewQueue(100), NewQueue(100)
+	for i := 0; i < 100; i++ {
+		_ = a.Enqueue(i)
+		_ = b.Enqueue(i)
+	}
+	var counts [2]int
+	for i := 0; i < 100; i++ {
+		_, j, _ := Select(context.Background(), a, b)
+		counts[j]++
+	}
+	if counts[0] == 0 || counts[1] == 0 {
+		t.Errorf("expected items from both queues, got %v", counts)
+	}
+}
+
+func TestSelectPoll(t *testing.T) {
+	// a Timed queue can't say when it changes, so it is polled, while the
+	// Queue is waited on.
+	a, b := NewQueue(2), NewTimed(2)
+	for _, test := range []struct {
+		q        interface{ Enqueue(interface{}) error }
+		expected int
+	}{
+		{a, 0},
+		{b, 1},
+	} {
+		go func(q interface{ Enqueue(interface{}) error }) {
+			time.Sleep(5 * time.Millisecond)
+			_ = q.Enqueue("x")
+		}(test.q)
+		v, i, err := Select(context.Background(), a, b)
+		if err != nil || v != "x" || i != test.expected {
+			t.Errorf("expected x %d nil, got %v %d %v", test.expected, v, i, err)
+		}
+	}
+}
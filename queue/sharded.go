@@ -0,0 +1,132 @@
+package queue
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Sharded is a bounded queue made up of independently locked Circular queues,
+// shards. Spreading items across shards reduces lock contention between
+// goroutines at the cost of ordering: items are FIFO within a shard but not
+// across shards.
+//
+// Enqueue and Dequeue each pick their starting shard round-robin; if that
+// shard is full, or empty, the other shards are tried in order.
+type Sharded struct {
+	shards []*Circular
+	enq    atomic.Uint64 // next shard to enqueue to
+	deq    atomic.Uint64 // next shard to dequeue from
+}
+
+// NewSharded returns a sharded queue with n shards, each of which holds size
+// items. If n < 1, the queue will have 1 shard.
+func NewSharded(n, size int) *Sharded {
+	if n < 1 {
+		n = 1
+	}
+	s := &Sharded{shards: make([]*Circular, n)}
+	for i := range s.shards {
+		s.shards[i] = NewCircular(size)
+	}
+	return s
+}
+
+// Enqueue adds an item to the queue. An error is returned if every shard is
+// full.
+func (s *Sharded) Enqueue(item interface{}) error {
+	start := s.enq.Add(1) - 1
+	for i := 0; i < len(s.shards); i++ {
+		c := s.shards[(start+uint64(i))%uint64(len(s.shards))]
+		if c.IsFull() {
+			continue
+		}
+		if err := c.Enqueue(item); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("sharded queue full: cannot enqueue %v", item)
+}
+
+// Dequeue removes an item from the queue. If every shard is empty, a false
+// will be returned.
+func (s *Sharded) Dequeue() (interface{}, bool) {
+	start := s.deq.Add(1) - 1
+	for i := 0; i < len(s.shards); i++ {
+		c := s.shards[(start+uint64(i))%uint64(len(s.shards))]
+		if c.IsEmpty() {
+			continue
+		}
+		if v, ok := c.Dequeue(); ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// Peek returns the item that the next Dequeue would most likely return
+// without removing it from the queue. If every shard is empty, a false will be
+// returned.
+func (s *Sharded) Peek() (interface{}, bool) {
+	start := s.deq.Load()
+	for i := 0; i < len(s.shards); i++ {
+		if v, ok := s.shards[(start+uint64(i))%uint64(len(s.shards))].Peek(); ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// Len returns the number of items in the queue. This is exact: all of the
+// shards are locked while their lengths are summed, which blocks every
+// enqueue and dequeue while it runs. For monitoring, use LenApprox.
+func (s *Sharded) Len() int {
+	for _, c := range s.shards {
+		c.Lock()
+	}
+	var n int
+	for _, c := range s.shards {
+		n += c.Len()
+	}
+	for i := len(s.shards) - 1; i >= 0; i-- {
+		s.shards[i].Unlock()
+	}
+	return n
+}
+
+// LenApprox returns the sum of each shard's length without any coordination
+// between the shards. This never takes a lock. The result is approximate
+// because shards may change while they are being summed.
+func (s *Sharded) LenApprox() int {
+	var n int
+	for _, c := range s.shards {
+		n += c.Len()
+	}
+	return n
+}
+
+// Cap returns the total capacity of the queue.
+func (s *Sharded) Cap() int {
+	return len(s.shards) * s.shards[0].Cap()
+}
+
+// IsEmpty returns whether or not all of the shards are empty. Like LenApprox,
+// this does not take any locks.
+func (s *Sharded) IsEmpty() bool {
+	return s.LenApprox() == 0
+}
+
+// IsFull returns whether or not all of the shards are full. Like LenApprox,
+// this does not take any locks.
+func (s *Sharded) IsFull() bool {
+	for _, c := range s.shards {
+		if !c.IsFull() {
+			return false
+		}
+	}
+	return true
+}
+
+// Shards returns the number of shards.
+func (s *Sharded) Shards() int {
+	return len(s.shards)
+}
@@ -0,0 +1,87 @@
+package queue
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSharded(t *testing.T) {
+	tests := []struct {
+		shards      int
+		size        int
+		enqueue     int
+		expectedLen int
+		expectedCap int
+		err         string
+	}{
+		{0, 2, 2, 2, 2, ""},
+		{2, 2, 3, 3, 4, ""},
+		{4, 2, 8, 8, 8, ""},
+		{4, 2, 9, 8, 8, "sharded queue full: cannot enqueue 8"},
+	}
+	for i, test := range tests {
+		s := NewSharded(test.shards, test.size)
+		var err error
+		for j := 0; j < test.enqueue; j++ {
+			err = s.Enqueue(j)
+		}
+		if err != nil && err.Error() != test.err {
+			t.Errorf("%d: expected error to be %q, got %q", i, test.err, err)
+		}
+		if err == nil && test.err != "" {
+			t.Errorf("%d: expected error to be %q, got nil", i, test.err)
+		}
+		if s.Len() != test.expectedLen {
+			t.Errorf("%d: expected len to be %d, got %d", i, test.expectedLen, s.Len())
+		}
+		if s.LenApprox() != test.expectedLen {
+			t.Errorf("%d: expected approximate len to be %d, got %d", i, test.expectedLen, s.LenApprox())
+		}
+		if s.Cap() != test.expectedCap {
+			t.Errorf("%d: expected cap to be %d, got %d", i, test.expectedCap, s.Cap())
+		}
+		if s.IsFull() != (test.expectedLen == test.expectedCap) {
+			t.Errorf("%d: expected IsFull to be %t, got %t", i, test.expectedLen == test.expectedCap, s.IsFull())
+		}
+		seen := make(map[interface{}]bool)
+		for j := 0; j < test.expectedLen; j++ {
+			v, ok := s.Dequeue()
+			if !ok {
+				t.Errorf("%d: dequeue %d: expected true, got false", i, j)
+				continue
+			}
+			if seen[v] {
+				t.Errorf("%d: dequeue %d: got %v twice", i, j, v)
+			}
+			seen[v] = true
+		}
+		if !s.IsEmpty() {
+			t.Errorf("%d: expected queue to be empty, len was %d", i, s.Len())
+		}
+		if _, ok := s.Dequeue(); ok {
+			t.Errorf("%d: expected dequeue of an empty queue to return false", i)
+		}
+	}
+}
+
+func TestShardedConcurrent(t *testing.T) {
+	const producers, n = 8, 500
+	s := NewSharded(4, producers*n)
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				if err := s.Enqueue(p*n + i); err != nil {
+					t.Error(err)
+				}
+				_ = s.LenApprox()
+			}
+		}(p)
+	}
+	wg.Wait()
+	if s.Len() != producers*n {
+		t.Errorf("expected len to be %d, got %d", producers*n, s.Len())
+	}
+}
@@ -0,0 +1,80 @@
+package queue
+
+// Sizer returns the approximate size, in bytes, of an item.
+type Sizer func(item interface{}) int
+
+// SetSizer sets the func used to size the queue's items; Bytes reports the
+// total size of the items in the queue. The items already in the queue are
+// sized when SetSizer is called. A nil Sizer turns off size accounting.
+//
+// Size accounting is approximate: it is whatever the Sizer says the items
+// hold, not what the queue itself uses.
+func (q *Queue) SetSizer(fn Sizer) {
+	q.Lock()
+	defer q.Unlock()
+	q.setSizer(fn, q.items[q.head:])
+}
+
+// SetSizer sets the func used to size the queue's items; see Queue.SetSizer.
+func (c *Circular) SetSizer(fn Sizer) {
+	c.Lock()
+	defer c.Unlock()
+	c.setSizer(fn, c.snapshot())
+}
+
+// setSizer sets the sizer and sizes the received items, which are the items
+// in the queue. The caller must hold the lock.
+func (q *Queue) setSizer(fn Sizer, items []interface{}) {
+	q.sizer = fn
+	var n int
+	for _, item := range items {
+		n += q.size(item)
+	}
+	q.bytes.Store(int64(n))
+}
+
+// size returns the size of item; without a Sizer, this is 0. The caller must
+// hold the lock.
+func (q *Queue) size(item interface{}) int {
+	if q.sizer == nil {
+		return 0
+	}
+	h := q.panics.Load()
+	if h == nil {
+		return q.sizer(item)
+	}
+	var n int
+	protect(*h, "sizer", func() { n = q.sizer(item) })
+	return n
+}
+
+// account adds sign times the size of item to the queue's bytes; sign is 1
+// for an item being added to the queue and -1 for one being removed. Without
+// a Sizer, this does nothing. The caller must hold the lock.
+func (q *Queue) account(item interface{}, sign int) {
+	if q.sizer == nil {
+		return
+	}
+	q.bytes.Add(int64(sign * q.size(item)))
+}
+
+// SetPanicHandler sets the handler that the queue's Sizer and hook panics are
+// reported to. The Sizer is called while the queue's lock is held, in the
+// middle of an enqueue or dequeue; with a handler, a panicking Sizer is
+// recovered, the item is sized as 0, and the operation completes. A panicking
+// hook is recovered and the queue's other hooks are still called. A nil
+// handler, the default, doesn't recover panics.
+func (q *Queue) SetPanicHandler(h PanicHandler) {
+	if h == nil {
+		q.panics.Store(nil)
+		return
+	}
+	q.panics.Store(&h)
+}
+
+// Bytes returns the approximate total size, in bytes, of the items in the
+// queue, as reported by the queue's Sizer. Without a Sizer, this is 0. This
+// does not take the lock.
+func (q *Queue) Bytes() int {
+	return int(q.bytes.Load())
+}
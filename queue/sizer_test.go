@@ -0,0 +1,63 @@
+package queue
+
+import (
+	"testing"
+)
+
+func strlen(item interface{}) int {
+	return len(item.(string))
+}
+
+func TestQueueBytes(t *testing.T) {
+	q := NewQueue(2)
+	_ = q.Enqueue("ab")
+	if q.Bytes() != 0 {
+		t.Errorf("expected 0 bytes without a sizer, got %d", q.Bytes())
+	}
+	q.SetSizer(strlen)
+	if q.Bytes() != 2 {
+		t.Errorf("expected the queued items to be sized, got %d bytes", q.Bytes())
+	}
+	_ = q.Enqueue("cde")
+	_ = q.Enqueue("f")
+	if q.Bytes() != 6 {
+		t.Errorf("expected 6 bytes, got %d", q.Bytes())
+	}
+	q.Dequeue()
+	if q.Bytes() != 4 {
+		t.Errorf("expected 4 bytes, got %d", q.Bytes())
+	}
+	q.Resize(0)
+	if q.Bytes() != 4 {
+		t.Errorf("expected 4 bytes after resize, got %d", q.Bytes())
+	}
+	q.Reset()
+	if q.Bytes() != 0 {
+		t.Errorf("expected 0 bytes after reset, got %d", q.Bytes())
+	}
+}
+
+func TestCircularBytes(t *testing.T) {
+	c := NewCircular(2)
+	_ = c.Enqueue("ab")
+	c.Dequeue()
+	_ = c.Enqueue("cd")
+	_ = c.Enqueue("efg")
+	c.SetSizer(strlen)
+	if c.Bytes() != 5 {
+		t.Errorf("expected the queued items to be sized, got %d bytes", c.Bytes())
+	}
+	// a dropped item isn't counted.
+	_ = c.Enqueue("hijk")
+	if c.Bytes() != 5 {
+		t.Errorf("expected 5 bytes, got %d", c.Bytes())
+	}
+	c.Dequeue()
+	if c.Bytes() != 3 {
+		t.Errorf("expected 3 bytes, got %d", c.Bytes())
+	}
+	c.Reset()
+	if c.Bytes() != 0 {
+		t.Errorf("expected 0 bytes after reset, got %d", c.Bytes())
+	}
+}
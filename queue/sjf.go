@@ -0,0 +1,184 @@
+package queue
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// sjfJob is an item in an SJF queue.
+type sjfJob struct {
+	item     interface{}
+	cost     float64
+	seq      uint64    // orders jobs with the same cost.
+	enqueued time.Time // when the job was enqueued; see SJF's maxWait.
+	index    int       // the job's index in the heap; -1 once it's dequeued.
+}
+
+// sjfHeap is a min heap of jobs, by cost.
+type sjfHeap []*sjfJob
+
+func (h sjfHeap) Len() int { return len(h) }
+
+func (h sjfHeap) Less(i, j int) bool {
+	if h[i].cost == h[j].cost {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].cost < h[j].cost
+}
+
+func (h sjfHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *sjfHeap) Push(x interface{}) {
+	j := x.(*sjfJob)
+	j.index = len(*h)
+	*h = append(*h, j)
+}
+
+func (h *sjfHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	j := old[n-1]
+	old[n-1] = nil
+	j.index = -1
+	*h = old[:n-1]
+	return j
+}
+
+// SJF is an unbounded queue that dequeues the cheapest item first, where an
+// item's cost is its estimated run time, or anything else its consumer's work
+// grows with. Running short jobs first minimizes the average time jobs take
+// to complete when job sizes vary widely, so that a few large jobs don't hold
+// up every small job queued behind them, as they would with FIFO. Items with
+// the same cost are dequeued in the order they were enqueued.
+//
+// Left to itself, shortest job first starves expensive items whenever cheap
+// items keep arriving, so SJF bounds how long an item waits: once the oldest
+// item has been in the queue for maxWait, it is dequeued next, regardless of
+// its cost.
+type SJF struct {
+	mu      sync.Mutex
+	cost    func(item interface{}) float64
+	maxWait time.Duration
+	jobs    sjfHeap
+	order   []*sjfJob // the jobs in the order they were enqueued; dequeued jobs are skipped.
+	seq     uint64
+	aged    uint64 // the number of jobs dequeued because they waited maxWait.
+}
+
+// NewSJF returns an empty SJF queue that uses the cost func to estimate the
+// cost of the items passed to Enqueue; if cost is nil, every item costs 0 and
+// the queue is FIFO. A maxWait <= 0 doesn't bound how long an item waits.
+func NewSJF(cost func(item interface{}) float64, maxWait time.Duration) *SJF {
+	return &SJF{cost: cost, maxWait: maxWait}
+}
+
+// Enqueue adds an item to the queue at the cost estimated by the queue's cost
+// func. The cost func is called without holding the queue's lock.
+func (s *SJF) Enqueue(item interface{}) error {
+	var cost float64
+	if s.cost != nil {
+		cost = s.cost(item)
+	}
+	return s.EnqueueCost(item, cost)
+}
+
+// EnqueueCost adds an item to the queue at the received cost, for callers
+// that know an item's cost better than the queue's cost func.
+func (s *SJF) EnqueueCost(item interface{}, cost float64) error {
+	j := &sjfJob{item: item, cost: cost, enqueued: time.Now()}
+	s.mu.Lock()
+	j.seq = s.seq
+	s.seq++
+	heap.Push(&s.jobs, j)
+	s.order = append(s.order, j)
+	s.mu.Unlock()
+	return nil
+}
+
+// Dequeue removes the next item from the queue and returns it: the oldest
+// item, if it has waited for maxWait, otherwise the cheapest item. If the
+// queue is empty, a false will be returned.
+func (s *SJF) Dequeue() (interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, aged := s.next(time.Now())
+	if j == nil {
+		return nil, false
+	}
+	if aged {
+		s.aged++
+	}
+	heap.Remove(&s.jobs, j.index)
+	s.trim()
+	return j.item, true
+}
+
+// Peek returns the item Dequeue would return without removing it from the
+// queue. If the queue is empty, a false will be returned.
+func (s *SJF) Peek() (interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, _ := s.next(time.Now())
+	if j == nil {
+		return nil, false
+	}
+	return j.item, true
+}
+
+// next returns the job to dequeue at now, and whether it's being dequeued
+// because it waited for maxWait, or nil if the queue is empty. The caller
+// must hold the lock.
+func (s *SJF) next(now time.Time) (*sjfJob, bool) {
+	if len(s.jobs) == 0 {
+		return nil, false
+	}
+	if s.maxWait > 0 {
+		if oldest := s.order[0]; now.Sub(oldest.enqueued) >= s.maxWait && oldest != s.jobs[0] {
+			return oldest, true
+		}
+	}
+	return s.jobs[0], false
+}
+
+// trim removes the dequeued jobs from the front of order, so that order[0] is
+// always the oldest job in the queue. The caller must hold the lock.
+func (s *SJF) trim() {
+	for len(s.order) > 0 && s.order[0].index == -1 {
+		s.order[0] = nil
+		s.order = s.order[1:]
+	}
+}
+
+// Aged returns the number of items that were dequeued ahead of cheaper items
+// because they had waited for maxWait.
+func (s *SJF) Aged() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.aged
+}
+
+// IsEmpty returns whether or not the queue is empty.
+func (s *SJF) IsEmpty() bool {
+	return s.Len() == 0
+}
+
+// Len returns the number of items in the queue.
+func (s *SJF) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.jobs)
+}
+
+// Reset removes all of the items from the queue.
+func (s *SJF) Reset() {
+	s.mu.Lock()
+	clear(s.jobs)
+	s.jobs = s.jobs[:0]
+	s.order = nil
+	s.mu.Unlock()
+}
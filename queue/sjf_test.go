@@ -0,0 +1,81 @@
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSJF(t *testing.T) {
+	size := func(item interface{}) float64 { return float64(len(item.(string))) }
+	tests := []struct {
+		name     string
+		items    []string
+		expected []string
+	}{
+		{"empty", nil, nil},
+		{"cheapest first", []string{"large", "xl", "m"}, []string{"m", "xl", "large"}},
+		{"ties are fifo", []string{"bb", "aa", "c", "dd"}, []string{"c", "bb", "aa", "dd"}},
+	}
+	for _, test := range tests {
+		s := NewSJF(size, 0)
+		for _, v := range test.items {
+			s.Enqueue(v)
+		}
+		if s.Len() != len(test.items) {
+			t.Errorf("%s: expected len %d, got %d", test.name, len(test.items), s.Len())
+		}
+		for i, expected := range test.expected {
+			if v, _ := s.Peek(); v != expected {
+				t.Errorf("%s: %d: expected peek to return %s, got %v", test.name, i, expected, v)
+			}
+			if v, ok := s.Dequeue(); !ok || v != expected {
+				t.Errorf("%s: %d: expected %s true, got %v %t", test.name, i, expected, v, ok)
+			}
+		}
+		if v, ok := s.Dequeue(); ok {
+			t.Errorf("%s: expected the queue to be empty, got %v", test.name, v)
+		}
+	}
+}
+
+func TestSJFNilCost(t *testing.T) {
+	s := NewSJF(nil, 0)
+	for i := 0; i < 3; i++ {
+		s.Enqueue(i)
+	}
+	s.EnqueueCost("urgent", -1)
+	for _, expected := range []interface{}{"urgent", 0, 1, 2} {
+		if v, _ := s.Dequeue(); v != expected {
+			t.Errorf("expected %v, got %v", expected, v)
+		}
+	}
+}
+
+func TestSJFMaxWait(t *testing.T) {
+	s := NewSJF(nil, 20*time.Millisecond)
+	s.EnqueueCost("big", 100)
+	s.EnqueueCost("small", 1)
+	if v, _ := s.Dequeue(); v != "small" {
+		t.Errorf("expected small, got %v", v)
+	}
+	s.EnqueueCost("small", 1)
+	time.Sleep(25 * time.Millisecond)
+	s.EnqueueCost("small", 1)
+	if v, _ := s.Peek(); v != "big" {
+		t.Errorf("expected the aged item to be peeked, got %v", v)
+	}
+	if v, _ := s.Dequeue(); v != "big" {
+		t.Errorf("expected the aged item to be dequeued, got %v", v)
+	}
+	if s.Aged() != 1 {
+		t.Errorf("expected 1 aged item, got %d", s.Aged())
+	}
+	// the oldest remaining item is also the cheapest, so it isn't aged.
+	if v, _ := s.Dequeue(); v != "small" || s.Aged() != 1 {
+		t.Errorf("expected small with 1 aged item, got %v %d", v, s.Aged())
+	}
+	s.Reset()
+	if !s.IsEmpty() {
+		t.Errorf("expected the queue to be empty after a reset, got %d items", s.Len())
+	}
+}
@@ -0,0 +1,87 @@
+package queue
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats are a queue's counters, e.g. for a metrics exporter. The counts are
+// since counting was turned on with SetStats; they aren't affected by Reset.
+//
+// Stats has no methods and only exported fields, so it can be published as
+// is, e.g. with expvar:
+//
+//	expvar.Publish("jobs", expvar.Func(func() any { return q.Stats() }))
+type Stats struct {
+	Enqueued  uint64        // the number of items enqueued.
+	Dequeued  uint64        // the number of items dequeued.
+	Dropped   uint64        // the number of items rejected, or evicted, because the queue was full.
+	Len       int           // the current number of items in the queue.
+	HighWater int           // the most items the queue has held.
+	Blocked   time.Duration // the total time enqueues have spent waiting for room.
+}
+
+// counters are the counts behind a queue's Stats.
+type counters struct {
+	on       atomic.Bool // whether the queue is counting; see SetStats.
+	enqueued atomic.Uint64
+	dequeued atomic.Uint64
+	dropped  atomic.Uint64
+	high     atomic.Int64
+	blocked  atomic.Int64 // nanoseconds
+}
+
+// count counts an event of the received kind.
+func (c *counters) count(kind EventKind) {
+	if !c.on.Load() {
+		return
+	}
+	switch kind {
+	case EventEnqueue:
+		c.enqueued.Add(1)
+	case EventDequeue:
+		c.dequeued.Add(1)
+	case EventDrop:
+		c.dropped.Add(1)
+	}
+}
+
+// mark updates the high-water mark with the queue's length, l. The caller
+// must hold the queue's lock.
+func (c *counters) mark(l int) {
+	if c.on.Load() && int64(l) > c.high.Load() {
+		c.high.Store(int64(l))
+	}
+}
+
+// block counts d as time spent blocked.
+func (c *counters) block(d time.Duration) {
+	if c.on.Load() {
+		c.blocked.Add(int64(d))
+	}
+}
+
+// SetStats turns the queue's counting on or off. Counting is off by default:
+// it costs every enqueue and dequeue an atomic increment, which is a large
+// part of the cost of an uncontended operation. When counting is off, the
+// counts are kept but not added to, and only Stats' Len is current.
+func (q *Queue) SetStats(on bool) {
+	q.Lock()
+	q.stats.on.Store(on)
+	q.stats.mark(q.Len())
+	q.Unlock()
+}
+
+// Stats returns the queue's Stats. This does not take the lock; each count is
+// read atomically, but they aren't read as a snapshot, so, e.g., Enqueued -
+// Dequeued may momentarily differ from Len.
+func (q *Queue) Stats() Stats {
+	return Stats{
+		Enqueued:  q.stats.enqueued.Load(),
+		Dequeued:  q.stats.dequeued.Load(),
+		Dropped:   q.stats.dropped.Load(),
+		Len:       q.Len(),
+		HighWater: int(q.stats.high.Load()),
+		Blocked:   time.Duration(q.stats.blocked.Load()),
+	}
+}
@@ -0,0 +1,81 @@
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	c := NewCircular(2)
+	c.SetStats(true)
+	for i := 0; i < 3; i++ {
+		c.Enqueue(i) // the third is rejected
+	}
+	c.Dequeue()
+	c.Enqueue(3)
+	c.Reset()
+	c.Enqueue(4)
+	if err := c.EnqueueWait(5, time.Millisecond); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := c.EnqueueWait(6, 20*time.Millisecond); err == nil {
+		t.Fatal("expected a queue full error")
+	}
+	expected := Stats{Enqueued: 5, Dequeued: 1, Dropped: 2, Len: 2, HighWater: 2}
+	got := c.Stats()
+	if got.Blocked < 20*time.Millisecond {
+		t.Errorf("expected at least 20ms blocked, got %s", got.Blocked)
+	}
+	got.Blocked = 0
+	if got != expected {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+
+	q := NewQueue(1)
+	q.SetStats(true)
+	for i := 0; i < 5; i++ {
+		q.Enqueue(i)
+	}
+	q.DequeueN(4)
+	expected = Stats{Enqueued: 5, Dequeued: 4, Len: 1, HighWater: 5}
+	if got := q.Stats(); got != expected {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+}
+
+func TestStatsBlocked(t *testing.T) {
+	c := NewCircularWithPolicy(1, OverflowBlock)
+	c.SetStats(true)
+	c.Enqueue(0)
+	done := make(chan struct{})
+	go func() {
+		c.Enqueue(1)
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	c.Dequeue()
+	<-done
+	if b := c.Stats().Blocked; b < 10*time.Millisecond {
+		t.Errorf("expected the blocked enqueue to be counted, got %s", b)
+	}
+	if c.Stats().Dropped != 0 {
+		t.Errorf("expected no drops, got %d", c.Stats().Dropped)
+	}
+}
+
+func TestStatsOff(t *testing.T) {
+	q := NewQueue(1)
+	q.Enqueue(0)
+	q.Enqueue(1)
+	q.Dequeue()
+	if got := q.Stats(); got != (Stats{Len: 1}) {
+		t.Errorf("expected only Len without counting, got %+v", got)
+	}
+	q.SetStats(true)
+	q.Enqueue(2)
+	q.SetStats(false)
+	q.Enqueue(3)
+	if got := q.Stats(); got != (Stats{Enqueued: 1, Len: 3, HighWater: 2}) {
+		t.Errorf("expected only the enqueue made while counting, got %+v", got)
+	}
+}
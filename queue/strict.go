@@ -0,0 +1,113 @@
+package queue
+
+import (
+	"sync"
+)
+
+// LevelStats are the dequeue counts for one level of a StrictPriority.
+type LevelStats struct {
+	Served  uint64 // items dequeued from the level.
+	Guarded uint64 // items dequeued from the level by the starvation guard.
+}
+
+// StrictPriority dequeues from multiple queues, or levels, in strict priority
+// order: an item is only dequeued from a level when every level above it is
+// empty.
+//
+// To keep busy high priority levels from starving the lower ones, a
+// StrictPriority can be given a starvation guard: a level that hasn't been
+// served for 'every' consecutive dequeues is overdue, and is served next,
+// before the levels above it. When more than one level is overdue, the one
+// that has waited longest is served first, so overdue levels take turns.
+// This guarantees each non-empty level at least 1 of every every+n
+// dequeues, where n is the number of levels.
+type StrictPriority struct {
+	mu      sync.Mutex
+	levels  []Dequeuer
+	every   int
+	skipped []int // the dequeues since each level was served, or found empty.
+	stats   []LevelStats
+}
+
+// NewStrictPriority returns a StrictPriority for the received levels, the
+// first level is the highest priority. If every is <= 0, there is no
+// starvation guard.
+func NewStrictPriority(every int, levels ...Dequeuer) *StrictPriority {
+	return &StrictPriority{
+		levels:  levels,
+		every:   every,
+		skipped: make([]int, len(levels)),
+		stats:   make([]LevelStats, len(levels)),
+	}
+}
+
+// Dequeue dequeues an item from the highest priority level that has one,
+// unless a lower level is due to be served by the starvation guard. If every
+// level is empty, a false is returned.
+func (s *StrictPriority) Dequeue() (interface{}, bool) {
+	v, _, ok := s.DequeueLevel()
+	return v, ok
+}
+
+// DequeueLevel is Dequeue but also returns the level the item came from.
+func (s *StrictPriority) DequeueLevel() (interface{}, int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.every > 0 {
+		i := s.overdue()
+		if i < 0 {
+			break
+		}
+		v, ok := s.levels[i].Dequeue()
+		if !ok {
+			// an empty level isn't being starved.
+			s.skipped[i] = 0
+			continue
+		}
+		if i > 0 {
+			s.stats[i].Guarded++
+		}
+		s.served(i)
+		return v, i, true
+	}
+	for i, q := range s.levels {
+		if v, ok := q.Dequeue(); ok {
+			s.served(i)
+			return v, i, true
+		}
+	}
+	return nil, -1, false
+}
+
+// overdue returns the level that has waited longest of those that haven't
+// been served for every dequeues, the higher priority level if more than one
+// has waited as long, or -1 if no level is overdue. Once a level is overdue,
+// each of the other levels can be served ahead of it at most once, because a
+// level that has been served has waited less than it until it is served in
+// turn. The lock must be held.
+func (s *StrictPriority) overdue() int {
+	level := -1
+	for i, n := range s.skipped {
+		if n >= s.every && (level < 0 || n > s.skipped[level]) {
+			level = i
+		}
+	}
+	return level
+}
+
+// served records a dequeue from level i; all of the other levels were passed
+// over. The lock must be held.
+func (s *StrictPriority) served(i int) {
+	s.stats[i].Served++
+	for j := range s.skipped {
+		s.skipped[j]++
+	}
+	s.skipped[i] = 0
+}
+
+// Stats returns the dequeue counts for each level.
+func (s *StrictPriority) Stats() []LevelStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]LevelStats(nil), s.stats...)
+}
@@ -0,0 +1,95 @@
+package queue
+
+import (
+	"testing"
+)
+
+func TestStrictPriority(t *testing.T) {
+	tests := []struct {
+		every    int
+		items    []int // the number of items in each level
+		dequeue  int
+		expected []LevelStats
+	}{
+		{0, []int{10, 10, 10}, 10, []LevelStats{{10, 0}, {0, 0}, {0, 0}}},
+		{0, []int{5, 10, 10}, 10, []LevelStats{{5, 0}, {5, 0}, {0, 0}}},
+		{0, []int{0, 0, 3}, 5, []LevelStats{{0, 0}, {0, 0}, {3, 0}}},
+		{3, []int{100, 100}, 8, []LevelStats{{6, 0}, {2, 2}}},
+		{3, []int{100, 0}, 8, []LevelStats{{8, 0}, {0, 0}}},
+		{2, []int{100, 100, 100}, 9, []LevelStats{{4, 0}, {3, 3}, {2, 2}}},
+		{4, []int{100, 100, 100, 100}, 14, []LevelStats{{8, 0}, {2, 2}, {2, 2}, {2, 2}}},
+	}
+	for i, test := range tests {
+		levels := make([]Dequeuer, len(test.items))
+		for j, n := range test.items {
+			q := NewQueue(n)
+			for k := 0; k < n; k++ {
+				_ = q.Enqueue(k)
+			}
+			levels[j] = q
+		}
+		s := NewStrictPriority(test.every, levels...)
+		for j := 0; j < test.dequeue; j++ {
+			s.Dequeue()
+		}
+		stats := s.Stats()
+		for j := range test.expected {
+			if stats[j] != test.expected[j] {
+				t.Errorf("%d: level %d: expected %+v, got %+v", i, j, test.expected[j], stats[j])
+			}
+		}
+	}
+}
+
+func TestStrictPriorityOrder(t *testing.T) {
+	hi, lo := NewQueue(4), NewQueue(4)
+	for i := 0; i < 4; i++ {
+		_ = hi.Enqueue(i)
+		_ = lo.Enqueue(i)
+	}
+	s := NewStrictPriority(2, hi, lo)
+	var order []int
+	for {
+		_, level, ok := s.DequeueLevel()
+		if !ok {
+			break
+		}
+		order = append(order, level)
+	}
+	expected := []int{0, 0, 1, 0, 0, 1, 1, 1}
+	if len(order) != len(expected) {
+		t.Fatalf("expected %d dequeues, got %d", len(expected), len(order))
+	}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Errorf("expected the levels to be served in the order %v, got %v", expected, order)
+			break
+		}
+	}
+}
+
+func TestStrictPriorityGuarantee(t *testing.T) {
+	for _, every := range []int{1, 2, 3, 5} {
+		for _, n := range []int{2, 3, 4, 6} {
+			levels := make([]Dequeuer, n)
+			for i := range levels {
+				q := NewQueue(1000)
+				for j := 0; j < 1000; j++ {
+					_ = q.Enqueue(j)
+				}
+				levels[i] = q
+			}
+			s := NewStrictPriority(every, levels...)
+			last := make([]int, n) // the dequeue each level was last served at.
+			for d := 1; d <= 500; d++ {
+				_, level, _ := s.DequeueLevel()
+				last[level] = d
+				for i, l := range last {
+					if d-l >= every+n {
+						t.Fatalf("every %d, %d levels: level %d wasn't served in dequeues %d to %d", every, n, i, l+1, d)
+					}
+				}
+			}
+		}
+	}
+}
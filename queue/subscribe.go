@@ -0,0 +1,85 @@
+package queue
+
+import (
+	"context"
+	"time"
+)
+
+// LenChange is a change in a queue's length, sent by Subscribe.
+type LenChange struct {
+	Len    int
+	Cap    int
+	Bucket int // the occupancy bucket, if Subscribe was called with buckets.
+	Time   time.Time
+}
+
+// Subscribe returns a channel that is sent the queue's length whenever it
+// changes, starting with its current length, for UIs and autoscalers that
+// want to be told about changes instead of polling Len. Changes are
+// coalesced: at most one is sent per interval and a slow receiver is sent
+// the latest length, not every length in between. An interval <= 0 doesn't
+// limit the rate.
+//
+// If buckets is > 0, the queue's capacity is divided into that many
+// occupancy buckets and a change is only sent when the queue moves to a
+// different bucket; a full queue is in bucket buckets. This is mostly useful
+// for bounded queues.
+//
+// The channel is closed when ctx is done.
+func (q *Queue) Subscribe(ctx context.Context, interval time.Duration, buckets int) <-chan LenChange {
+	ch := make(chan LenChange)
+	go q.subscribe(ctx, ch, interval, buckets)
+	return ch
+}
+
+// subscribe sends the queue's length changes to ch until ctx is done.
+func (q *Queue) subscribe(ctx context.Context, ch chan<- LenChange, interval time.Duration, buckets int) {
+	defer close(ch)
+	var last LenChange
+	var sent time.Time
+	for first := true; ; {
+		q.Lock()
+		l, cp := unpack(q.state.Load())
+		changed := q.wait()
+		q.Unlock()
+		cur := LenChange{Len: l, Cap: cp, Bucket: bucket(l, cp, buckets)}
+		if first || (buckets > 0 && cur.Bucket != last.Bucket) || (buckets <= 0 && cur.Len != last.Len) {
+			if d := interval - time.Since(sent); !first && d > 0 {
+				// too soon; whatever the length is once d has passed is
+				// sent instead.
+				t := time.NewTimer(d)
+				select {
+				case <-ctx.Done():
+					t.Stop()
+					return
+				case <-t.C:
+				}
+				continue
+			}
+			cur.Time = time.Now()
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- cur:
+			}
+			last, sent, first = cur, cur.Time, false
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-changed:
+		}
+	}
+}
+
+// bucket returns the occupancy bucket for l of cp.
+func bucket(l, cp, buckets int) int {
+	if buckets <= 0 || cp <= 0 {
+		return 0
+	}
+	if l >= cp {
+		return buckets
+	}
+	return l * buckets / cp
+}
@@ -0,0 +1,106 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// recv receives a LenChange or fails.
+func recv(t *testing.T, ch <-chan LenChange) LenChange {
+	t.Helper()
+	select {
+	case c, ok := <-ch:
+		if !ok {
+			t.Fatal("unexpected close")
+		}
+		return c
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for a change")
+	}
+	return LenChange{}
+}
+
+func TestSubscribe(t *testing.T) {
+	q := NewQueue(4)
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := q.Subscribe(ctx, 0, 0)
+	if c := recv(t, ch); c.Len != 0 || c.Cap != 4 {
+		t.Errorf("expected the initial length, got %+v", c)
+	}
+	for i := 1; i <= 3; i++ {
+		q.Enqueue(i)
+		if c := recv(t, ch); c.Len != i {
+			t.Errorf("expected len %d, got %d", i, c.Len)
+		}
+	}
+	// changes made while the receiver isn't receiving are coalesced.
+	q.Dequeue()
+	q.Dequeue()
+	time.Sleep(10 * time.Millisecond)
+	if c := recv(t, ch); c.Len != 1 {
+		t.Errorf("expected the latest len, 1, got %d", c.Len)
+	}
+	cancel()
+	for range ch {
+	}
+}
+
+func TestSubscribeBuckets(t *testing.T) {
+	c := NewCircular(8)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := c.Subscribe(ctx, 0, 4)
+	if lc := recv(t, ch); lc.Bucket != 0 || lc.Cap != 8 {
+		t.Errorf("expected bucket 0 of a cap of 8, got %+v", lc)
+	}
+	// only moving to a new bucket is sent.
+	for i, expected := range []int{-1, 1, -1, 2, -1, 3, -1, 4} {
+		c.Enqueue(i)
+		if expected < 0 {
+			continue
+		}
+		if lc := recv(t, ch); lc.Bucket != expected || lc.Len != i+1 {
+			t.Errorf("%d: expected bucket %d, got %+v", i, expected, lc)
+		}
+	}
+}
+
+func TestSubscribeInterval(t *testing.T) {
+	q := NewQueue(4)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := q.Subscribe(ctx, 50*time.Millisecond, 0)
+	first := recv(t, ch)
+	go func() {
+		for i := 0; i < 10; i++ {
+			q.Enqueue(i)
+		}
+	}()
+	c := recv(t, ch)
+	if d := c.Time.Sub(first.Time); d < 50*time.Millisecond {
+		t.Errorf("expected changes to be sent at most every 50ms, got %s", d)
+	}
+	for c.Len != 10 {
+		c = recv(t, ch)
+	}
+}
+
+func TestBucket(t *testing.T) {
+	tests := []struct {
+		l, cp, buckets, expected int
+	}{
+		{0, 10, 4, 0},
+		{2, 10, 4, 0},
+		{3, 10, 4, 1},
+		{9, 10, 4, 3},
+		{10, 10, 4, 4},
+		{5, 10, 0, 0},
+		{5, 0, 4, 0},
+	}
+	for _, test := range tests {
+		if b := bucket(test.l, test.cp, test.buckets); b != test.expected {
+			t.Errorf("%d/%d in %d: expected %d, got %d", test.l, test.cp, test.buckets, test.expected, b)
+		}
+	}
+}
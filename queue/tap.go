@@ -0,0 +1,82 @@
+package queue
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+)
+
+// tapBuffer is the number of sampled items a tap's channel buffers.
+const tapBuffer = 64
+
+// tap is a Tap: a sample of the items enqueued on a queue.
+type tap struct {
+	rate   float64
+	mu     sync.Mutex // protects ch from being closed while it's sent to.
+	ch     chan interface{}
+	closed bool
+}
+
+// Tap returns a channel that is sent a sample of the items enqueued on the
+// queue, so live traffic can be inspected, e.g. by an engineer watching
+// production payloads. rate is the fraction of items sampled: 0.01 samples 1%
+// of them, 1 samples all of them. The items aren't copied, so receivers must
+// not modify them.
+//
+// Tapping never affects delivery: the items are still dequeued as usual and
+// enqueues never wait for the tap. Samples are dropped when the channel's
+// buffer is full. The channel is closed when ctx is done.
+func (q *Queue) Tap(ctx context.Context, rate float64) <-chan interface{} {
+	t := &tap{rate: rate, ch: make(chan interface{}, tapBuffer)}
+	q.fmu.Lock()
+	var taps []*tap
+	if p := q.taps.Load(); p != nil {
+		taps = append(taps, *p...)
+	}
+	taps = append(taps, t)
+	q.taps.Store(&taps)
+	q.fmu.Unlock()
+	go func() {
+		<-ctx.Done()
+		q.untap(t)
+	}()
+	return t.ch
+}
+
+// untap removes t from the queue's taps and closes its channel.
+func (q *Queue) untap(t *tap) {
+	q.fmu.Lock()
+	var taps []*tap
+	for _, tt := range *q.taps.Load() {
+		if tt != t {
+			taps = append(taps, tt)
+		}
+	}
+	q.taps.Store(&taps)
+	q.fmu.Unlock()
+	t.mu.Lock()
+	t.closed = true
+	close(t.ch)
+	t.mu.Unlock()
+}
+
+// sample sends item to the queue's taps that sample it.
+func (q *Queue) sample(item interface{}) {
+	p := q.taps.Load()
+	if p == nil {
+		return
+	}
+	for _, t := range *p {
+		if t.rate < 1 && rand.Float64() >= t.rate {
+			continue
+		}
+		t.mu.Lock()
+		if !t.closed {
+			select {
+			case t.ch <- item:
+			default:
+			}
+		}
+		t.mu.Unlock()
+	}
+}
@@ -0,0 +1,64 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTap(t *testing.T) {
+	c := NewCircular(200)
+	ctx, cancel := context.WithCancel(context.Background())
+	all := c.Tap(ctx, 1)
+	none := c.Tap(ctx, 0)
+	half := c.Tap(ctx, 0.5)
+	for i := 0; i < 200; i++ {
+		c.Enqueue(i)
+	}
+	c.Dequeue()
+	// items are still delivered.
+	if c.Len() != 199 {
+		t.Errorf("expected the tapped queue to have 199 items, got %d", c.Len())
+	}
+	// the tap's buffer is full; the rest of the samples were dropped.
+	for i := 0; i < tapBuffer; i++ {
+		if v := <-all; v != i {
+			t.Fatalf("expected sample %d, got %v", i, v)
+		}
+	}
+	cancel()
+	for _, ch := range []<-chan interface{}{all, none, half} {
+		select {
+		case _, ok := <-ch:
+			if ch == none && ok {
+				t.Error("expected a rate of 0 not to sample")
+			}
+			for range ch {
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for the tap to close")
+		}
+	}
+	// enqueues after the taps are closed don't panic.
+	c.Dequeue()
+	c.Enqueue(200)
+}
+
+func TestTapRate(t *testing.T) {
+	q := NewQueue(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := q.Tap(ctx, 0.25)
+	var n int
+	for i := 0; i < 4000; i++ {
+		q.Enqueue(i)
+		select {
+		case <-ch:
+			n++
+		default:
+		}
+	}
+	if n < 800 || n > 1200 {
+		t.Errorf("expected about 1000 samples, got %d", n)
+	}
+}
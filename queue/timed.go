@@ -0,0 +1,99 @@
+package queue
+
+import (
+	"fmt"
+	"time"
+)
+
+// stamped is an item in a Timed queue.
+type stamped struct {
+	item     interface{}
+	enqueued time.Time
+}
+
+// Timed is a bounded FIFO queue that timestamps its items as they are
+// enqueued, so that how long items wait in the queue, the queue's latency,
+// can be measured without wrapping every item: DequeueWithMeta returns how
+// long an item waited and OldestAge how long the item at the head has been
+// waiting. Latency is a better backpressure signal than length when the time
+// it takes to handle an item varies.
+type Timed struct {
+	c *Circular
+}
+
+// NewTimed returns an empty Timed queue that holds size items.
+func NewTimed(size int) *Timed {
+	return &Timed{c: NewCircular(size)}
+}
+
+// Enqueue adds an item to the queue, stamped with the current time. If the
+// queue is full, an error is returned.
+func (t *Timed) Enqueue(item interface{}) error {
+	if t.c.Enqueue(stamped{item: item, enqueued: time.Now()}) != nil {
+		return fmt.Errorf("queue full: cannot enqueue %v", item)
+	}
+	return nil
+}
+
+// Dequeue removes the next item from the queue and returns it. If the queue
+// is empty, a false will be returned.
+func (t *Timed) Dequeue() (interface{}, bool) {
+	item, _, ok := t.DequeueWithMeta()
+	return item, ok
+}
+
+// DequeueWithMeta is Dequeue, but it also returns how long the item waited in
+// the queue.
+func (t *Timed) DequeueWithMeta() (item interface{}, waited time.Duration, ok bool) {
+	v, ok := t.c.Dequeue()
+	if !ok {
+		return nil, 0, false
+	}
+	s := v.(stamped)
+	return s.item, time.Since(s.enqueued), true
+}
+
+// Peek returns the next item in the queue without removing it. If the queue
+// is empty, a false will be returned.
+func (t *Timed) Peek() (interface{}, bool) {
+	v, ok := t.c.Peek()
+	if !ok {
+		return nil, false
+	}
+	return v.(stamped).item, true
+}
+
+// OldestAge returns how long the item at the head of the queue, the oldest
+// item, has been waiting. If the queue is empty, 0 is returned.
+func (t *Timed) OldestAge() time.Duration {
+	v, ok := t.c.Peek()
+	if !ok {
+		return 0
+	}
+	return time.Since(v.(stamped).enqueued)
+}
+
+// Len returns the number of items in the queue.
+func (t *Timed) Len() int {
+	return t.c.Len()
+}
+
+// Cap returns the number of items the queue can hold.
+func (t *Timed) Cap() int {
+	return t.c.Cap()
+}
+
+// IsEmpty returns whether or not the queue is empty.
+func (t *Timed) IsEmpty() bool {
+	return t.c.IsEmpty()
+}
+
+// IsFull returns whether or not the queue is full.
+func (t *Timed) IsFull() bool {
+	return t.c.IsFull()
+}
+
+// Reset removes all of the items from the queue.
+func (t *Timed) Reset() {
+	t.c.Reset()
+}
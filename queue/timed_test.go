@@ -0,0 +1,43 @@
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimed(t *testing.T) {
+	q := NewTimed(2)
+	if q.OldestAge() != 0 {
+		t.Errorf("expected an empty queue to have an oldest age of 0, got %s", q.OldestAge())
+	}
+	if _, _, ok := q.DequeueWithMeta(); ok {
+		t.Error("expected an empty queue to dequeue false")
+	}
+	q.Enqueue("old")
+	time.Sleep(20 * time.Millisecond)
+	q.Enqueue("new")
+	if err := q.Enqueue("full"); err == nil || err.Error() != "queue full: cannot enqueue full" {
+		t.Errorf("expected a queue full error, got %v", err)
+	}
+	if age := q.OldestAge(); age < 20*time.Millisecond {
+		t.Errorf("expected the oldest item to have waited at least 20ms, got %s", age)
+	}
+	if v, _ := q.Peek(); v != "old" {
+		t.Errorf("expected old, got %v", v)
+	}
+	v, waited, ok := q.DequeueWithMeta()
+	if !ok || v != "old" || waited < 20*time.Millisecond {
+		t.Errorf("expected old to have waited at least 20ms, got %v %s %t", v, waited, ok)
+	}
+	if age := q.OldestAge(); age >= 20*time.Millisecond {
+		t.Errorf("expected the new oldest item to have waited less than 20ms, got %s", age)
+	}
+	if v, ok := q.Dequeue(); !ok || v != "new" {
+		t.Errorf("expected new true, got %v %t", v, ok)
+	}
+	q.Enqueue("reset")
+	q.Reset()
+	if !q.IsEmpty() || q.IsFull() || q.Len() != 0 || q.Cap() != 2 {
+		t.Errorf("expected an empty queue with a cap of 2, got %d of %d", q.Len(), q.Cap())
+	}
+}
@@ -0,0 +1,157 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// expiring is an item in a TTL queue.
+type expiring struct {
+	item    interface{}
+	expires time.Time // zero if the item never expires.
+}
+
+// expired returns whether or not the item has expired at now.
+func (e expiring) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+// TTL is a bounded FIFO queue whose items expire: each item has a time to
+// live, the queue's default or its own, see EnqueueTTL, after which it is
+// useless, e.g. a request whose caller has given up. Expired items are
+// skipped by Dequeue and Peek, so consumers never see them, and can be
+// removed from anywhere in the queue by Sweep, or periodically by Run, so
+// they don't take up room.
+type TTL struct {
+	c       *Circular
+	ttl     time.Duration
+	expired atomic.Uint64
+}
+
+// NewTTL returns an empty TTL queue that holds size items, each of which
+// expires ttl after it is enqueued unless it is enqueued with its own TTL. A
+// ttl <= 0 doesn't expire items by default.
+func NewTTL(size int, ttl time.Duration) *TTL {
+	return &TTL{c: NewCircular(size), ttl: ttl}
+}
+
+// Enqueue adds an item to the queue with the queue's TTL. If the queue is
+// full, an error is returned.
+func (t *TTL) Enqueue(item interface{}) error {
+	return t.EnqueueTTL(item, t.ttl)
+}
+
+// EnqueueTTL adds an item to the queue that expires d from now; a d <= 0
+// never expires. If the queue is full, an error is returned.
+func (t *TTL) EnqueueTTL(item interface{}, d time.Duration) error {
+	e := expiring{item: item}
+	if d > 0 {
+		e.expires = time.Now().Add(d)
+	}
+	if t.c.Enqueue(e) != nil {
+		return fmt.Errorf("queue full: cannot enqueue %v", item)
+	}
+	return nil
+}
+
+// Dequeue removes the next unexpired item from the queue and returns it;
+// the expired items in front of it are removed. If there isn't one, a false
+// will be returned.
+func (t *TTL) Dequeue() (interface{}, bool) {
+	now := time.Now()
+	for {
+		v, ok := t.c.Dequeue()
+		if !ok {
+			return nil, false
+		}
+		if e := v.(expiring); !e.expired(now) {
+			return e.item, true
+		}
+		t.expired.Add(1)
+	}
+}
+
+// Peek returns the next unexpired item without removing it; the expired
+// items in front of it are removed. If there isn't one, a false will be
+// returned.
+func (t *TTL) Peek() (interface{}, bool) {
+	now := time.Now()
+	t.c.Lock()
+	defer t.c.Unlock()
+	for {
+		v, ok := t.c.peek()
+		if !ok {
+			return nil, false
+		}
+		if e := v.(expiring); !e.expired(now) {
+			return e.item, true
+		}
+		t.c.dequeue()
+		t.expired.Add(1)
+	}
+}
+
+// Sweep removes every expired item from the queue, wherever it is, and
+// returns the number removed.
+func (t *TTL) Sweep() int {
+	now := time.Now()
+	t.c.Lock()
+	defer t.c.Unlock()
+	items := t.c.snapshot()
+	kept := items[:0]
+	for _, v := range items {
+		if !v.(expiring).expired(now) {
+			kept = append(kept, v)
+		}
+	}
+	n := len(items) - len(kept)
+	if n > 0 {
+		t.c.load(kept)
+		t.expired.Add(uint64(n))
+	}
+	return n
+}
+
+// Run sweeps the queue every interval until ctx is done, then returns ctx's
+// error.
+func (t *TTL) Run(ctx context.Context, interval time.Duration) error {
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick.C:
+			t.Sweep()
+		}
+	}
+}
+
+// Expired returns the number of expired items that have been removed.
+func (t *TTL) Expired() uint64 {
+	return t.expired.Load()
+}
+
+// Len returns the number of items in the queue, including the expired items
+// that haven't been removed yet.
+func (t *TTL) Len() int {
+	return t.c.Len()
+}
+
+// Cap returns the number of items the queue can hold.
+func (t *TTL) Cap() int {
+	return t.c.Cap()
+}
+
+// IsEmpty returns whether or not the queue is empty; a queue of only expired
+// items that haven't been removed yet isn't empty.
+func (t *TTL) IsEmpty() bool {
+	return t.c.IsEmpty()
+}
+
+// IsFull returns whether or not the queue is full.
+func (t *TTL) IsFull() bool {
+	return t.c.IsFull()
+}
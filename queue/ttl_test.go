@@ -0,0 +1,72 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTTL(t *testing.T) {
+	q := NewTTL(4, 20*time.Millisecond)
+	q.Enqueue("short")
+	q.EnqueueTTL("forever", 0)
+	q.EnqueueTTL("long", time.Hour)
+	q.Enqueue("short 2")
+	if err := q.Enqueue("full"); err == nil || err.Error() != "queue full: cannot enqueue full" {
+		t.Errorf("expected a queue full error, got %v", err)
+	}
+	if v, _ := q.Peek(); v != "short" {
+		t.Errorf("expected short, got %v", v)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if v, _ := q.Peek(); v != "forever" {
+		t.Errorf("expected the expired head to be skipped, got %v", v)
+	}
+	if q.Expired() != 1 || q.Len() != 3 {
+		t.Errorf("expected 1 expired and 3 left, got %d %d", q.Expired(), q.Len())
+	}
+	for _, expected := range []string{"forever", "long"} {
+		if v, ok := q.Dequeue(); !ok || v != expected {
+			t.Errorf("expected %s true, got %v %t", expected, v, ok)
+		}
+	}
+	if v, ok := q.Dequeue(); ok {
+		t.Errorf("expected the rest to have expired, got %v", v)
+	}
+	if q.Expired() != 2 || !q.IsEmpty() {
+		t.Errorf("expected 2 expired and an empty queue, got %d %d", q.Expired(), q.Len())
+	}
+}
+
+func TestTTLSweep(t *testing.T) {
+	q := NewTTL(4, 0)
+	q.EnqueueTTL(1, time.Hour)
+	q.EnqueueTTL(2, time.Millisecond)
+	q.Enqueue(3)
+	q.EnqueueTTL(4, time.Millisecond)
+	if !q.IsFull() {
+		t.Error("expected the queue to be full")
+	}
+	time.Sleep(5 * time.Millisecond)
+	if n := q.Sweep(); n != 2 {
+		t.Errorf("expected 2 items to be swept, got %d", n)
+	}
+	if q.Len() != 2 || q.Cap() != 4 {
+		t.Errorf("expected 2 items with a cap of 4, got %d %d", q.Len(), q.Cap())
+	}
+	for _, expected := range []int{1, 3} {
+		if v, _ := q.Dequeue(); v != expected {
+			t.Errorf("expected %d, got %v", expected, v)
+		}
+	}
+
+	q.EnqueueTTL(5, time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := q.Run(ctx, time.Millisecond); err != context.DeadlineExceeded {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if q.Len() != 0 || q.Expired() != 3 {
+		t.Errorf("expected Run to sweep the expired item, got len %d, %d expired", q.Len(), q.Expired())
+	}
+}
@@ -0,0 +1,162 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TunerConfig configures a Tuner. The zero value of each field uses the
+// default.
+type TunerConfig struct {
+	Min      int           // the minimum capacity; the default is 1.
+	Max      int           // the maximum capacity; if 0 it is the queue's current capacity, if < Min it is set to Min.
+	GrowAt   float64       // the drop rate at which the queue grows; the default is 0.01.
+	ShrinkAt float64       // the peak occupancy, as a fraction of cap, below which the queue shrinks; the default is 0.25.
+	Factor   float64       // the amount the capacity is multiplied or divided by; the default is 2.
+	MaxWait  time.Duration // if > 0, the estimated wait time above which the queue shrinks instead of growing.
+}
+
+// Tuner adjusts the capacity of a Circular queue, within a minimum and a
+// maximum, based on how the queue is being used: it grows when too many items
+// are being dropped and shrinks when it is mostly empty. This reduces the need
+// to hand tune queue sizes for each deployment.
+//
+// If MaxWait is set, latency takes precedence over drops: when the estimated
+// time an item waits in the queue, its length divided by the rate of
+// dequeues, is more than MaxWait, the queue shrinks even if it is dropping
+// items, trading drops for latency.
+//
+// The Tuner sees the queue's traffic by wrapping it: enqueues and dequeues
+// must go through the Tuner. The capacity is adjusted each time Step is
+// called, or periodically by Run.
+type Tuner struct {
+	c        *Circular
+	cfg      TunerConfig
+	offered  atomic.Uint64
+	dropped  atomic.Uint64
+	dequeued atomic.Uint64
+	peak     atomic.Int64
+	mu       sync.Mutex // serializes Step.
+	last     time.Time
+}
+
+// NewTuner returns a Tuner for c. The queue is resized to be within the
+// configured bounds.
+func NewTuner(c *Circular, cfg TunerConfig) *Tuner {
+	if cfg.Min < 1 {
+		cfg.Min = 1
+	}
+	if cfg.Max == 0 {
+		cfg.Max = c.Cap()
+	}
+	if cfg.Max < cfg.Min {
+		cfg.Max = cfg.Min
+	}
+	if cfg.GrowAt <= 0 {
+		cfg.GrowAt = 0.01
+	}
+	if cfg.ShrinkAt <= 0 {
+		cfg.ShrinkAt = 0.25
+	}
+	if cfg.Factor <= 1 {
+		cfg.Factor = 2
+	}
+	t := &Tuner{c: c, cfg: cfg, last: time.Now()}
+	t.setCap(c.Cap())
+	return t
+}
+
+// Queue returns the tuned queue.
+func (t *Tuner) Queue() *Circular {
+	return t.c
+}
+
+// Enqueue enqueues the item on the queue.
+func (t *Tuner) Enqueue(item interface{}) error {
+	t.offered.Add(1)
+	err := t.c.Enqueue(item)
+	if err != nil {
+		t.dropped.Add(1)
+		return err
+	}
+	l := int64(t.c.Len())
+	for {
+		p := t.peak.Load()
+		if l <= p || t.peak.CompareAndSwap(p, l) {
+			return nil
+		}
+	}
+}
+
+// Dequeue dequeues an item from the queue.
+func (t *Tuner) Dequeue() (interface{}, bool) {
+	v, ok := t.c.Dequeue()
+	if ok {
+		t.dequeued.Add(1)
+	}
+	return v, ok
+}
+
+// Step looks at the queue's traffic since the last step and adjusts its
+// capacity; the new capacity is returned.
+func (t *Tuner) Step() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	elapsed := now.Sub(t.last)
+	t.last = now
+	offered := t.offered.Swap(0)
+	dropped := t.dropped.Swap(0)
+	dequeued := t.dequeued.Swap(0)
+	l := t.c.Len()
+	peak := int(t.peak.Swap(int64(l)))
+	if l > peak {
+		peak = l
+	}
+
+	cp := t.c.Cap()
+	if t.cfg.MaxWait > 0 && dequeued > 0 {
+		wait := time.Duration(float64(l) / float64(dequeued) * float64(elapsed))
+		if wait > t.cfg.MaxWait {
+			return t.setCap(int(float64(cp) / t.cfg.Factor))
+		}
+	}
+	if offered > 0 && float64(dropped)/float64(offered) >= t.cfg.GrowAt {
+		return t.setCap(int(float64(cp) * t.cfg.Factor))
+	}
+	if float64(peak) < t.cfg.ShrinkAt*float64(cp) {
+		return t.setCap(int(float64(cp) / t.cfg.Factor))
+	}
+	return cp
+}
+
+// setCap sets the queue's capacity to n, bounded by the configured minimum
+// and maximum, if it isn't already; the queue's capacity is returned.
+func (t *Tuner) setCap(n int) int {
+	if n < t.cfg.Min {
+		n = t.cfg.Min
+	}
+	if n > t.cfg.Max {
+		n = t.cfg.Max
+	}
+	if n == t.c.Cap() {
+		return n
+	}
+	return t.c.setCap(n)
+}
+
+// Run calls Step every interval until ctx is done; ctx's error is returned.
+func (t *Tuner) Run(ctx context.Context, interval time.Duration) error {
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick.C:
+			t.Step()
+		}
+	}
+}
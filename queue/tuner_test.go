@@ -0,0 +1,88 @@
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTuner(t *testing.T) {
+	c := NewCircular(8)
+	tn := NewTuner(c, TunerConfig{Min: 4, Max: 32})
+	if c.Cap() != 8 {
+		t.Fatalf("expected a cap within bounds to be unchanged, got %d", c.Cap())
+	}
+	// fill the queue and keep enqueueing: it should grow to the max.
+	var n int
+	for _, expected := range []int{16, 32, 32} {
+		for i := 0; i < 40; i++ {
+			if tn.Enqueue(n) == nil {
+				n++
+			}
+		}
+		if n := tn.Step(); n != expected || c.Cap() != expected {
+			t.Errorf("expected cap to be %d, got %d %d", expected, n, c.Cap())
+		}
+	}
+	if c.Len() != 32 {
+		t.Errorf("expected the queue to be full, got %d", c.Len())
+	}
+	// the items must be kept, in order, across resizes.
+	for i := 0; i < 32; i++ {
+		v, ok := tn.Dequeue()
+		if !ok || v != i {
+			t.Fatalf("%d: expected %d true, got %v %t", i, i, v, ok)
+		}
+	}
+	// the queue was full at the start of the step, so it isn't shrunk
+	// until the next one. An empty queue shrinks to the min.
+	for _, expected := range []int{32, 16, 8, 4, 4} {
+		if n := tn.Step(); n != expected {
+			t.Errorf("expected cap to be %d, got %d", expected, n)
+		}
+	}
+	// between the thresholds, the cap is left alone.
+	_ = tn.Enqueue(1)
+	_ = tn.Enqueue(2)
+	if n := tn.Step(); n != 4 {
+		t.Errorf("expected cap to be 4, got %d", n)
+	}
+}
+
+func TestTunerMin(t *testing.T) {
+	c := NewCircular(2)
+	NewTuner(c, TunerConfig{Min: 4, Max: 8})
+	if c.Cap() != 4 {
+		t.Errorf("expected the cap to be raised to the min, got %d", c.Cap())
+	}
+}
+
+func TestTunerZeroConfig(t *testing.T) {
+	c := NewCircular(8)
+	tn := NewTuner(c, TunerConfig{})
+	if c.Cap() != 8 {
+		t.Fatalf("expected the cap to be unchanged, got %d", c.Cap())
+	}
+	// a zero Max is the queue's capacity: a full, dropping queue isn't
+	// grown, or shrunk.
+	for i := 0; i < 10; i++ {
+		_ = tn.Enqueue(i)
+	}
+	if n := tn.Step(); n != 8 || c.Cap() != 8 {
+		t.Errorf("expected cap to be 8, got %d %d", n, c.Cap())
+	}
+}
+
+func TestTunerMaxWait(t *testing.T) {
+	c := NewCircular(16)
+	tn := NewTuner(c, TunerConfig{Min: 2, Max: 32, MaxWait: time.Nanosecond})
+	for i := 0; i < 20; i++ {
+		_ = tn.Enqueue(i)
+	}
+	tn.Dequeue()
+	time.Sleep(time.Millisecond)
+	// the queue is dropping items but the wait is too long: it shrinks,
+	// but not below its length.
+	if n := tn.Step(); n != 15 {
+		t.Errorf("expected cap to be 15, got %d", n)
+	}
+}
@@ -0,0 +1,108 @@
+package queue
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// TwoLock is a bounded FIFO queue with one lock for producers and another for
+// consumers. With Circular, a producer and a consumer block each other even
+// though they work at opposite ends of the queue; with TwoLock, an enqueue only
+// waits for other enqueues, and a dequeue for other dequeues, so producers and
+// consumers proceed concurrently. The two ends share nothing but the head and
+// tail positions, which each end reads atomically.
+//
+// Unlike MPMC, which is lock-free, TwoLock's capacity isn't rounded up to a
+// power of two, and it supports Peek.
+type TwoLock struct {
+	tmu   sync.Mutex    // serializes producers.
+	tail  atomic.Uint64 // the next position to enqueue at.
+	_     cacheLinePad
+	hmu   sync.Mutex    // serializes consumers.
+	head  atomic.Uint64 // the next position to dequeue from.
+	_     cacheLinePad
+	items []interface{}
+}
+
+// NewTwoLock returns an empty TwoLock queue that holds size items. A size < 1
+// is set to 1.
+func NewTwoLock(size int) *TwoLock {
+	if size < 1 {
+		size = 1
+	}
+	return &TwoLock{items: make([]interface{}, size)}
+}
+
+// Enqueue adds an item to the queue. If the queue is full, an error is
+// returned.
+func (q *TwoLock) Enqueue(item interface{}) error {
+	q.tmu.Lock()
+	tail := q.tail.Load()
+	if tail-q.head.Load() == uint64(len(q.items)) {
+		q.tmu.Unlock()
+		return fmt.Errorf("queue full: cannot enqueue %v", item)
+	}
+	q.items[tail%uint64(len(q.items))] = item
+	// publishing the new tail hands the slot to the consumers.
+	q.tail.Store(tail + 1)
+	q.tmu.Unlock()
+	return nil
+}
+
+// Dequeue removes the oldest item from the queue and returns it. If the queue
+// is empty, a false will be returned.
+func (q *TwoLock) Dequeue() (interface{}, bool) {
+	q.hmu.Lock()
+	head := q.head.Load()
+	if head == q.tail.Load() {
+		q.hmu.Unlock()
+		return nil, false
+	}
+	i := head % uint64(len(q.items))
+	item := q.items[i]
+	q.items[i] = nil
+	// publishing the new head hands the slot back to the producers.
+	q.head.Store(head + 1)
+	q.hmu.Unlock()
+	return item, true
+}
+
+// Peek returns the oldest item in the queue without removing it. If the queue
+// is empty, a false will be returned.
+func (q *TwoLock) Peek() (interface{}, bool) {
+	q.hmu.Lock()
+	defer q.hmu.Unlock()
+	head := q.head.Load()
+	if head == q.tail.Load() {
+		return nil, false
+	}
+	return q.items[head%uint64(len(q.items))], true
+}
+
+// Len returns the number of items in the queue. This doesn't take either
+// lock; while items are being enqueued and dequeued it is approximate.
+func (q *TwoLock) Len() int {
+	for {
+		head := q.head.Load()
+		tail := q.tail.Load()
+		if q.head.Load() == head {
+			return int(tail - head)
+		}
+	}
+}
+
+// Cap returns the capacity of the queue.
+func (q *TwoLock) Cap() int {
+	return len(q.items)
+}
+
+// IsEmpty returns whether or not the queue is empty.
+func (q *TwoLock) IsEmpty() bool {
+	return q.Len() == 0
+}
+
+// IsFull returns whether or not the queue is full.
+func (q *TwoLock) IsFull() bool {
+	return q.Len() >= len(q.items)
+}
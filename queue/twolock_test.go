@@ -0,0 +1,169 @@
+package queue
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestTwoLock(t *testing.T) {
+	q := NewTwoLock(3)
+	if _, ok := q.Dequeue(); ok {
+		t.Error("expected an empty queue to dequeue false")
+	}
+	// go around the ring more than once.
+	for lap := 0; lap < 3; lap++ {
+		for i := 0; i < 3; i++ {
+			if err := q.Enqueue(i); err != nil {
+				t.Errorf("%d: %d: expected no error, got %s", lap, i, err)
+			}
+		}
+		if err := q.Enqueue(3); err == nil || err.Error() != "queue full: cannot enqueue 3" {
+			t.Errorf("%d: expected a queue full error, got %v", lap, err)
+		}
+		if !q.IsFull() || q.Len() != 3 || q.Cap() != 3 {
+			t.Errorf("%d: expected a full queue of 3, got %d of %d", lap, q.Len(), q.Cap())
+		}
+		for i := 0; i < 3; i++ {
+			if v, _ := q.Peek(); v != i {
+				t.Errorf("%d: expected peek to return %d, got %v", lap, i, v)
+			}
+			if v, ok := q.Dequeue(); !ok || v != i {
+				t.Errorf("%d: expected %d true, got %v %t", lap, i, v, ok)
+			}
+		}
+		if !q.IsEmpty() {
+			t.Errorf("%d: expected the queue to be empty, got %d", lap, q.Len())
+		}
+	}
+	if q := NewTwoLock(0); q.Cap() != 1 {
+		t.Errorf("expected a size of 0 to be set to 1, got %d", q.Cap())
+	}
+}
+
+func TestTwoLockConcurrent(t *testing.T) {
+	const producers, n = 4, 1000
+	q := NewTwoLock(16)
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < n; {
+				if q.Enqueue([2]int{p, i}) != nil {
+					runtime.Gosched()
+					continue
+				}
+				i++
+			}
+		}(p)
+	}
+	results := make(chan [][2]int)
+	for c := 0; c < 4; c++ {
+		go func() {
+			var got [][2]int
+			for len(got) < n {
+				v, ok := q.Dequeue()
+				if !ok {
+					runtime.Gosched()
+					continue
+				}
+				got = append(got, v.([2]int))
+			}
+			results <- got
+		}()
+	}
+	seen := make(map[[2]int]bool)
+	for c := 0; c < 4; c++ {
+		last := make(map[int]int)
+		for _, v := range <-results {
+			if seen[v] {
+				t.Fatalf("%v dequeued twice", v)
+			}
+			seen[v] = true
+			if l, ok := last[v[0]]; ok && v[1] <= l {
+				t.Fatalf("producer %d: %d dequeued after %d", v[0], v[1], l)
+			}
+			last[v[0]] = v[1]
+		}
+	}
+	wg.Wait()
+	if len(seen) != producers*n {
+		t.Errorf("expected %d items, got %d", producers*n, len(seen))
+	}
+}
+
+func BenchmarkTwoLockContended(b *testing.B) {
+	benchmarkContended(b, NewTwoLock(1024))
+}
+
+// benchmarkProduceConsume moves b.N items through q with producers
+// goroutines enqueueing and as many goroutines dequeueing, the load that a
+// single lock serializes.
+func benchmarkProduceConsume(b *testing.B, q interface {
+	Enqueue(interface{}) error
+	Dequeue() (interface{}, bool)
+}, producers int) {
+	b.ReportAllocs()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	left := b.N
+	take := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if left == 0 {
+			return false
+		}
+		left--
+		return true
+	}
+	b.ResetTimer()
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := p; i < b.N; i += producers {
+				for q.Enqueue(payload) != nil {
+					runtime.Gosched()
+				}
+			}
+		}()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for take() {
+				for {
+					if _, ok := q.Dequeue(); ok {
+						break
+					}
+					runtime.Gosched()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkProduceConsume8TwoLock(b *testing.B) {
+	benchmarkProduceConsume(b, NewTwoLock(1024), 4)
+}
+
+func BenchmarkProduceConsume8Circular(b *testing.B) {
+	benchmarkProduceConsume(b, NewCircular(1024), 4)
+}
+
+func BenchmarkProduceConsume8MPMC(b *testing.B) {
+	benchmarkProduceConsume(b, NewMPMC(1024), 4)
+}
+
+func BenchmarkProduceConsume16TwoLock(b *testing.B) {
+	benchmarkProduceConsume(b, NewTwoLock(1024), 8)
+}
+
+func BenchmarkProduceConsume16Circular(b *testing.B) {
+	benchmarkProduceConsume(b, NewCircular(1024), 8)
+}
+
+func BenchmarkProduceConsume16MPMC(b *testing.B) {
+	benchmarkProduceConsume(b, NewMPMC(1024), 8)
+}
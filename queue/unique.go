@@ -0,0 +1,163 @@
+package queue
+
+import (
+	"fmt"
+)
+
+// DuplicatePolicy is what a Unique queue's Enqueue does with an item whose
+// key is already queued.
+type DuplicatePolicy int
+
+// The duplicate policies.
+const (
+	// DuplicateReject rejects the new item with an error, leaving the
+	// queued item as it is. This is the default.
+	DuplicateReject DuplicatePolicy = iota
+	// DuplicateRefresh replaces the queued item with the new one; the item
+	// keeps its place in the queue.
+	DuplicateRefresh
+)
+
+func (p DuplicatePolicy) String() string {
+	switch p {
+	case DuplicateReject:
+		return "reject"
+	case DuplicateRefresh:
+		return "refresh"
+	}
+	return "unknown"
+}
+
+// keyed is an item in a Unique queue. The queue's set of keys points at
+// them, so that a refresh can replace the item in place.
+type keyed struct {
+	key  interface{}
+	item interface{}
+}
+
+// Unique is a bounded FIFO queue that holds at most one item per key: the
+// work queue with deduplication that controllers use, where many changes to
+// an object only need it to be processed once. The queue keeps the set of
+// queued keys itself, under the same lock as its items, so the set is never
+// out of step with the queue, not even while items are being dequeued. Once
+// an item is dequeued, its key can be enqueued again.
+type Unique struct {
+	c       *Circular
+	key     func(item interface{}) interface{}
+	policy  DuplicatePolicy
+	pending map[interface{}]*keyed
+}
+
+// NewUnique returns an empty Unique queue that holds size items. key returns
+// an item's key, which must be comparable; a nil key uses the item itself.
+func NewUnique(size int, key func(item interface{}) interface{}, policy DuplicatePolicy) *Unique {
+	return &Unique{c: NewCircular(size), key: key, policy: policy, pending: make(map[interface{}]*keyed, size)}
+}
+
+// keyOf returns item's key.
+func (u *Unique) keyOf(item interface{}) interface{} {
+	if u.key == nil {
+		return item
+	}
+	return u.key(item)
+}
+
+// Enqueue adds an item to the queue. If an item with the same key is already
+// queued, the queue's duplicate policy is applied: with DuplicateReject, an
+// error is returned; with DuplicateRefresh, the queued item is replaced. If
+// the queue is full, an error is returned.
+func (u *Unique) Enqueue(item interface{}) error {
+	k := u.keyOf(item)
+	u.c.Lock()
+	if p, ok := u.pending[k]; ok {
+		if u.policy == DuplicateRefresh {
+			p.item = item
+			u.c.Unlock()
+			return nil
+		}
+		u.c.Unlock()
+		return fmt.Errorf("duplicate: cannot enqueue %v: its key, %v, is already queued", item, k)
+	}
+	p := &keyed{key: k, item: item}
+	if !u.c.enqueue(p) {
+		u.c.Unlock()
+		u.c.emit(EventDrop, item)
+		return fmt.Errorf("queue full: cannot enqueue %v", item)
+	}
+	u.pending[k] = p
+	u.c.Unlock()
+	u.c.emit(EventEnqueue, item)
+	return nil
+}
+
+// Dequeue removes the next item from the queue and returns it. If the queue
+// is empty, a false will be returned.
+func (u *Unique) Dequeue() (interface{}, bool) {
+	u.c.Lock()
+	v, ok := u.c.dequeue()
+	if !ok {
+		u.c.Unlock()
+		return nil, false
+	}
+	p := v.(*keyed)
+	delete(u.pending, p.key)
+	u.c.Unlock()
+	u.c.emit(EventDequeue, p.item)
+	return p.item, true
+}
+
+// Peek returns the next item in the queue without removing it. If the queue
+// is empty, a false will be returned.
+func (u *Unique) Peek() (interface{}, bool) {
+	u.c.Lock()
+	defer u.c.Unlock()
+	v, ok := u.c.peek()
+	if !ok {
+		return nil, false
+	}
+	return v.(*keyed).item, true
+}
+
+// Queued returns whether or not an item with the received key is queued.
+func (u *Unique) Queued(key interface{}) bool {
+	u.c.Lock()
+	defer u.c.Unlock()
+	_, ok := u.pending[key]
+	return ok
+}
+
+// Len returns the number of items in the queue.
+func (u *Unique) Len() int {
+	return u.c.Len()
+}
+
+// Cap returns the number of items the queue can hold.
+func (u *Unique) Cap() int {
+	return u.c.Cap()
+}
+
+// IsEmpty returns whether or not the queue is empty.
+func (u *Unique) IsEmpty() bool {
+	return u.c.IsEmpty()
+}
+
+// IsFull returns whether or not the queue is full.
+func (u *Unique) IsFull() bool {
+	return u.c.IsFull()
+}
+
+// Reset removes all of the items from the queue.
+func (u *Unique) Reset() {
+	u.c.Lock()
+	u.c.load(nil)
+	clear(u.pending)
+	u.c.Unlock()
+	u.c.emit(EventReset, nil)
+}
+
+// Queue returns the underlying queue, for its hooks, stats, taps, and Bus;
+// their events have the Unique's items, not its keys. Items must be enqueued
+// and dequeued through the Unique.
+func (u *Unique) Queue() *Circular {
+	return u.c
+}
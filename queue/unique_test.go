@@ -0,0 +1,109 @@
+package queue
+
+import (
+	"testing"
+)
+
+type change struct {
+	object  string
+	version int
+}
+
+func byObject(item interface{}) interface{} { return item.(change).object }
+
+func TestUnique(t *testing.T) {
+	tests := []struct {
+		policy   DuplicatePolicy
+		expected []change
+	}{
+		{DuplicateReject, []change{{"a", 1}, {"b", 1}}},
+		{DuplicateRefresh, []change{{"a", 3}, {"b", 1}}},
+	}
+	for _, test := range tests {
+		u := NewUnique(2, byObject, test.policy)
+		u.Enqueue(change{"a", 1})
+		u.Enqueue(change{"b", 1})
+		err := u.Enqueue(change{"a", 3})
+		if test.policy == DuplicateReject && (err == nil || err.Error() != "duplicate: cannot enqueue {a 3}: its key, a, is already queued") {
+			t.Errorf("%s: expected a duplicate error, got %v", test.policy, err)
+		}
+		if test.policy == DuplicateRefresh && err != nil {
+			t.Errorf("%s: expected no error, got %s", test.policy, err)
+		}
+		if err := u.Enqueue(change{"c", 1}); err == nil || err.Error() != "queue full: cannot enqueue {c 1}" {
+			t.Errorf("%s: expected a queue full error, got %v", test.policy, err)
+		}
+		if !u.IsFull() || u.Len() != 2 || u.Cap() != 2 {
+			t.Errorf("%s: expected a full queue of 2, got %d of %d", test.policy, u.Len(), u.Cap())
+		}
+		if v, _ := u.Peek(); v != test.expected[0] {
+			t.Errorf("%s: expected peek to return %v, got %v", test.policy, test.expected[0], v)
+		}
+		for _, expected := range test.expected {
+			if v, ok := u.Dequeue(); !ok || v != expected {
+				t.Errorf("%s: expected %v true, got %v %t", test.policy, expected, v, ok)
+			}
+			if u.Queued(expected.object) {
+				t.Errorf("%s: expected %s to not be queued once it was dequeued", test.policy, expected.object)
+			}
+		}
+		if _, ok := u.Dequeue(); ok || !u.IsEmpty() {
+			t.Errorf("%s: expected the queue to be empty", test.policy)
+		}
+		// a dequeued key can be enqueued again.
+		if err := u.Enqueue(change{"a", 4}); err != nil {
+			t.Errorf("%s: expected no error, got %s", test.policy, err)
+		}
+	}
+}
+
+func TestUniqueReset(t *testing.T) {
+	u := NewUnique(3, nil, DuplicateReject)
+	for _, v := range []int{1, 2, 1} {
+		u.Enqueue(v)
+	}
+	if !u.Queued(1) || u.Len() != 2 {
+		t.Errorf("expected 1 to be queued once with a len of 2, got %t %d", u.Queued(1), u.Len())
+	}
+	u.Reset()
+	if u.Queued(1) || !u.IsEmpty() {
+		t.Errorf("expected a reset to forget the queued keys, got %t %d", u.Queued(1), u.Len())
+	}
+	if err := u.Enqueue(1); err != nil {
+		t.Errorf("expected no error, got %s", err)
+	}
+}
+
+func TestUniqueEvents(t *testing.T) {
+	u := NewUnique(2, byObject, DuplicateRefresh)
+	u.Queue().SetStats(true)
+	var enqueued, dequeued []interface{}
+	u.Queue().OnEnqueue(func(item interface{}) { enqueued = append(enqueued, item) })
+	u.Queue().OnDequeue(func(item interface{}) { dequeued = append(dequeued, item) })
+	u.Enqueue(change{"a", 1})
+	u.Enqueue(change{"a", 2}) // a refresh isn't another enqueue.
+	u.Enqueue(change{"b", 1})
+	u.Enqueue(change{"c", 1})
+	u.Dequeue()
+	if len(enqueued) != 2 || enqueued[0] != (change{"a", 1}) || enqueued[1] != (change{"b", 1}) {
+		t.Errorf("expected the hooks to see the enqueued items, got %v", enqueued)
+	}
+	if len(dequeued) != 1 || dequeued[0] != (change{"a", 2}) {
+		t.Errorf("expected the hooks to see the refreshed item dequeued, got %v", dequeued)
+	}
+	st := u.Queue().Stats()
+	if st.Enqueued != 2 || st.Dequeued != 1 || st.Dropped != 1 {
+		t.Errorf("expected 2 enqueued, 1 dequeued, and 1 dropped, got %+v", st)
+	}
+}
+
+func TestDuplicatePolicyString(t *testing.T) {
+	for _, test := range []struct {
+		p        DuplicatePolicy
+		expected string
+	}{{DuplicateReject, "reject"}, {DuplicateRefresh, "refresh"}, {DuplicatePolicy(9), "unknown"}} {
+		if s := test.p.String(); s != test.expected {
+			t.Errorf("expected %s, got %s", test.expected, s)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// wait returns a channel that is closed the next time the queue changes. The
+// caller must hold the lock.
+//
+// Waiting is a broadcast: every waiter is woken and rechecks the queue. The
+// channel is only made when someone waits, so a queue without waiters
+// doesn't pay for it.
+func (q *Queue) wait() <-chan struct{} {
+	if q.changed == nil {
+		q.changed = make(chan struct{})
+	}
+	return q.changed
+}
+
+// broadcast wakes everyone waiting for the queue to change. The caller must
+// hold the lock.
+func (q *Queue) broadcast() {
+	if q.changed != nil {
+		close(q.changed)
+		q.changed = nil
+	}
+}
+
+// EnqueueCtx adds an item to the queue. An unbounded queue is never full, so
+// this never blocks; it is here so that the queue can be used wherever a
+// blocking queue is expected. If ctx is already done, ctx's error is returned
+// and the item is not enqueued.
+func (q *Queue) EnqueueCtx(ctx context.Context, item interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return q.Enqueue(item)
+}
+
+// DequeueCtx removes an item from the queue, blocking until there is one or
+// ctx is done. If ctx is done first, ctx's error is returned.
+func (q *Queue) DequeueCtx(ctx context.Context) (interface{}, error) {
+	for {
+		q.Lock()
+		item, shrunk, ok := q.dequeue()
+		if ok {
+			q.Unlock()
+			q.emitDequeue(item, shrunk)
+			return item, nil
+		}
+		changed := q.wait()
+		q.Unlock()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// EnqueueCtx adds an item to the queue, blocking until there is room for it
+// or ctx is done. If ctx is done first, ctx's error is returned and the item is
+// not enqueued.
+//
+// A queue with a drop overflow policy never blocks: if ctx isn't already done,
+// the item is enqueued as it would be by Enqueue.
+func (c *Circular) EnqueueCtx(ctx context.Context, item interface{}) error {
+	if c.policy == OverflowDropOldest || c.policy == OverflowDropNewest {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return c.Enqueue(item)
+	}
+	var start time.Time // when the enqueue started waiting, if it has.
+	defer func() {
+		if !start.IsZero() {
+			c.stats.block(time.Since(start))
+		}
+	}()
+	for {
+		c.Lock()
+		if c.enqueue(item) {
+			c.Unlock()
+			c.emit(EventEnqueue, item)
+			return nil
+		}
+		changed := c.wait()
+		c.Unlock()
+		if start.IsZero() {
+			start = time.Now()
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// DequeueCtx removes an item from the queue, blocking until there is one or
+// ctx is done. If ctx is done first, ctx's error is returned.
+func (c *Circular) DequeueCtx(ctx context.Context) (interface{}, error) {
+	for {
+		c.Lock()
+		item, ok := c.dequeue()
+		if ok {
+			c.Unlock()
+			c.emit(EventDequeue, item)
+			return item, nil
+		}
+		changed := c.wait()
+		c.Unlock()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// DequeueWait removes an item from the queue, waiting up to timeout for
+// there to be one. The waiting goroutine is parked, not spinning, until the
+// queue changes. If no item is dequeued before the timeout, a false will be
+// returned; a timeout <= 0 doesn't wait.
+func (q *Queue) DequeueWait(timeout time.Duration) (interface{}, bool) {
+	if timeout <= 0 {
+		return q.Dequeue()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	item, err := q.DequeueCtx(ctx)
+	return item, err == nil
+}
+
+// EnqueueWait adds an item to the queue. An unbounded queue is never full, so
+// this never waits; it is here so that the queue can be used wherever a
+// waiting queue is expected.
+func (q *Queue) EnqueueWait(item interface{}, timeout time.Duration) error {
+	return q.Enqueue(item)
+}
+
+// DequeueWait removes an item from the queue, waiting up to timeout for there
+// to be one; see Queue.DequeueWait.
+func (c *Circular) DequeueWait(timeout time.Duration) (interface{}, bool) {
+	if timeout <= 0 {
+		return c.Dequeue()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	item, err := c.DequeueCtx(ctx)
+	return item, err == nil
+}
+
+// EnqueueWait adds an item to the queue, waiting up to timeout for there to be
+// room for it. The waiting goroutine is parked, not spinning, until the queue
+// changes. If the queue is still full when the timeout passes, the item is
+// dropped, as it would be by Enqueue, and the queue full error is returned; a
+// timeout <= 0 doesn't wait. As with EnqueueCtx, a queue
+// with a drop overflow policy never waits.
+func (c *Circular) EnqueueWait(item interface{}, timeout time.Duration) error {
+	if timeout <= 0 {
+		if c.policy != OverflowBlock {
+			return c.Enqueue(item)
+		}
+		// Enqueue would wait for room; try once instead.
+		c.Lock()
+		ok := c.enqueue(item)
+		c.Unlock()
+		if !ok {
+			c.emit(EventDrop, item)
+			return fmt.Errorf("queue full: cannot enqueue %v", item)
+		}
+		c.emit(EventEnqueue, item)
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := c.EnqueueCtx(ctx, item); err != nil {
+		c.emit(EventDrop, item)
+		return fmt.Errorf("queue full: cannot enqueue %v", item)
+	}
+	return nil
+}
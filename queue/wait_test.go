@@ -0,0 +1,189 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blocking is the method set of the queues with context aware operations.
+type blocking interface {
+	EnqueueCtx(context.Context, interface{}) error
+	DequeueCtx(context.Context) (interface{}, error)
+}
+
+func TestDequeueCtx(t *testing.T) {
+	tests := []struct {
+		name string
+		q    interface {
+			blocking
+			Enqueue(interface{}) error
+		}
+	}{
+		{"queue", NewQueue(2)},
+		{"circular", NewCircular(2)},
+	}
+	for _, test := range tests {
+		ctx := context.Background()
+		got := make(chan interface{})
+		go func() {
+			v, err := test.q.DequeueCtx(ctx)
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", test.name, err)
+			}
+			got <- v
+		}()
+		time.Sleep(5 * time.Millisecond)
+		_ = test.q.Enqueue(42)
+		select {
+		case v := <-got:
+			if v != 42 {
+				t.Errorf("%s: expected 42, got %v", test.name, v)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: timed out waiting for the dequeue", test.name)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+		_, err := test.q.DequeueCtx(ctx)
+		cancel()
+		if err != context.DeadlineExceeded {
+			t.Errorf("%s: expected %v, got %v", test.name, context.DeadlineExceeded, err)
+		}
+	}
+}
+
+func TestCircularEnqueueCtx(t *testing.T) {
+	c := NewCircular(1)
+	ctx := context.Background()
+	if err := c.EnqueueCtx(ctx, 1); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	done := make(chan error)
+	go func() { done <- c.EnqueueCtx(ctx, 2) }()
+	select {
+	case err := <-done:
+		t.Fatalf("expected enqueue on a full queue to block, got %v", err)
+	case <-time.After(5 * time.Millisecond):
+	}
+	c.Dequeue()
+	if err := <-done; err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if v, _ := c.Peek(); v != 2 {
+		t.Errorf("expected 2 to have been enqueued, got %v", v)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := c.EnqueueCtx(ctx, 3); err != context.Canceled {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+	if err := NewQueue(1).EnqueueCtx(ctx, 3); err != context.Canceled {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestCircularCtxConcurrent(t *testing.T) {
+	const producers, n = 4, 500
+	c := NewCircular(8)
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				if err := c.EnqueueCtx(ctx, i); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < producers*n; i++ {
+		if _, err := c.DequeueCtx(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+	if !c.IsEmpty() {
+		t.Errorf("expected the queue to be empty, len is %d", c.Len())
+	}
+}
+
+func TestDequeueWait(t *testing.T) {
+	tests := []struct {
+		name string
+		q    interface {
+			Enqueue(interface{}) error
+			DequeueWait(time.Duration) (interface{}, bool)
+		}
+	}{
+		{"queue", NewQueue(2)},
+		{"circular", NewCircular(2)},
+	}
+	for _, test := range tests {
+		q := test.q
+		if _, ok := q.DequeueWait(0); ok {
+			t.Errorf("%s: expected an empty queue not to be dequeued without waiting", test.name)
+		}
+		start := time.Now()
+		if _, ok := q.DequeueWait(20 * time.Millisecond); ok {
+			t.Errorf("%s: expected the wait to time out", test.name)
+		}
+		if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+			t.Errorf("%s: expected to wait 20ms, waited %s", test.name, elapsed)
+		}
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			q.Enqueue(1)
+		}()
+		if v, ok := q.DequeueWait(time.Second); !ok || v != 1 {
+			t.Errorf("%s: expected 1 true, got %v %t", test.name, v, ok)
+		}
+	}
+}
+
+func TestEnqueueWait(t *testing.T) {
+	q := NewQueue(1)
+	for i := 0; i < 3; i++ {
+		if err := q.EnqueueWait(i, 0); err != nil {
+			t.Errorf("expected an unbounded queue to never be full, got %s", err)
+		}
+	}
+	tests := []struct {
+		name   string
+		policy OverflowPolicy
+	}{
+		{"error", OverflowError},
+		{"block", OverflowBlock},
+	}
+	for _, test := range tests {
+		c := NewCircularWithPolicy(1, test.policy)
+		c.Enqueue(1)
+		if err := c.EnqueueWait(2, 0); err == nil || err.Error() != "queue full: cannot enqueue 2" {
+			t.Errorf("%s: expected a full error without waiting, got %v", test.name, err)
+		}
+		if err := c.EnqueueWait(2, 10*time.Millisecond); err == nil || err.Error() != "queue full: cannot enqueue 2" {
+			t.Errorf("%s: expected a full error once the wait timed out, got %v", test.name, err)
+		}
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			c.Dequeue()
+		}()
+		if err := c.EnqueueWait(3, time.Second); err != nil {
+			t.Errorf("%s: expected the enqueue to succeed once there was room, got %s", test.name, err)
+		}
+		if v, _ := c.Peek(); v != 3 {
+			t.Errorf("%s: expected 3, got %v", test.name, v)
+		}
+	}
+	// drop policies never wait.
+	c := NewCircularWithPolicy(1, OverflowDropOldest)
+	c.Enqueue(1)
+	if err := c.EnqueueWait(2, time.Hour); err != nil {
+		t.Errorf("drop oldest: unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,85 @@
+package queue
+
+import (
+	"sync"
+)
+
+// Weighted dequeues from multiple queues in proportion to their weights: with
+// weights of 5, 3, and 1, out of every 9 dequeues, 5 come from the first
+// queue, 3 from the second, and 1 from the third. Empty queues are skipped;
+// their share goes to the queues that have items.
+//
+// Weighted uses smooth weighted round-robin, so a queue's turns are spread
+// out instead of being served in a burst.
+type Weighted struct {
+	mu      sync.Mutex
+	qs      []Dequeuer
+	weights []int
+	current []int
+	served  []int
+	skip    []bool
+}
+
+// NewWeighted returns a Weighted dequeuer for qs; weights[i] is the weight of
+// qs[i]. Missing weights, and weights less than 1, are set to 1.
+func NewWeighted(qs []Dequeuer, weights []int) *Weighted {
+	w := &Weighted{
+		qs:      qs,
+		weights: make([]int, len(qs)),
+		current: make([]int, len(qs)),
+		served:  make([]int, len(qs)),
+		skip:    make([]bool, len(qs)),
+	}
+	for i := range w.weights {
+		w.weights[i] = 1
+		if i < len(weights) && weights[i] > 1 {
+			w.weights[i] = weights[i]
+		}
+	}
+	return w
+}
+
+// Dequeue dequeues an item from the queue whose turn it is. If that queue is
+// empty, the next queue in turn is tried. If every queue is empty, a false is
+// returned.
+func (w *Weighted) Dequeue() (interface{}, bool) {
+	v, _, ok := w.DequeueIndex()
+	return v, ok
+}
+
+// DequeueIndex is Dequeue but also returns the index of the queue the item
+// came from.
+func (w *Weighted) DequeueIndex() (interface{}, int, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i := range w.skip {
+		w.skip[i] = false
+	}
+	for range w.qs {
+		best, total := -1, 0
+		for i := range w.qs {
+			if w.skip[i] {
+				continue
+			}
+			w.current[i] += w.weights[i]
+			total += w.weights[i]
+			if best == -1 || w.current[i] > w.current[best] {
+				best = i
+			}
+		}
+		w.current[best] -= total
+		if v, ok := w.qs[best].Dequeue(); ok {
+			w.served[best]++
+			return v, best, true
+		}
+		w.skip[best] = true
+	}
+	return nil, -1, false
+}
+
+// Served returns the number of items dequeued from each queue.
+func (w *Weighted) Served() []int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]int(nil), w.served...)
+}
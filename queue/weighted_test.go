@@ -0,0 +1,70 @@
+package queue
+
+import (
+	"testing"
+)
+
+func TestWeighted(t *testing.T) {
+	tests := []struct {
+		weights  []int
+		items    []int // the number of items in each queue
+		dequeue  int
+		expected []int // the number of items served from each queue
+	}{
+		{[]int{5, 3, 1}, []int{100, 100, 100}, 9, []int{5, 3, 1}},
+		{[]int{5, 3, 1}, []int{100, 100, 100}, 90, []int{50, 30, 10}},
+		{[]int{5, 3, 1}, []int{100, 0, 100}, 60, []int{50, 0, 10}},
+		{[]int{5, 3, 1}, []int{2, 2, 2}, 10, []int{2, 2, 2}},
+		{[]int{0, 2}, []int{10, 10}, 3, []int{1, 2}},
+		{nil, []int{10, 10}, 4, []int{2, 2}},
+	}
+	for i, test := range tests {
+		qs := make([]Dequeuer, len(test.items))
+		for j, n := range test.items {
+			q := NewQueue(n)
+			for k := 0; k < n; k++ {
+				_ = q.Enqueue(k)
+			}
+			qs[j] = q
+		}
+		w := NewWeighted(qs, test.weights)
+		var dequeued int
+		for j := 0; j < test.dequeue; j++ {
+			if _, ok := w.Dequeue(); ok {
+				dequeued++
+			}
+		}
+		served := w.Served()
+		var total int
+		for j, n := range test.expected {
+			total += n
+			if served[j] != n {
+				t.Errorf("%d: queue %d: expected %d items served, got %d", i, j, n, served[j])
+			}
+		}
+		if dequeued != total {
+			t.Errorf("%d: expected %d items dequeued, got %d", i, total, dequeued)
+		}
+	}
+}
+
+func TestWeightedSmooth(t *testing.T) {
+	a, b := NewQueue(10), NewQueue(10)
+	for i := 0; i < 10; i++ {
+		_ = a.Enqueue(i)
+		_ = b.Enqueue(i)
+	}
+	w := NewWeighted([]Dequeuer{a, b}, []int{2, 1})
+	var order []int
+	for i := 0; i < 6; i++ {
+		_, j, _ := w.DequeueIndex()
+		order = append(order, j)
+	}
+	expected := []int{0, 1, 0, 0, 1, 0}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Errorf("expected the queues to be served in the order %v, got %v", expected, order)
+			break
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package queue
+
+// DequeueIf removes the first item, in FIFO order, for which fn returns true
+// and returns it; the items in front of it keep their places. If no item
+// matches, a false will be returned. A claimed head is never dequeued.
+//
+// fn is called while holding the queue's lock, so it must not use the queue.
+func (q *Queue) DequeueIf(fn func(item interface{}) bool) (interface{}, bool) {
+	q.Lock()
+	i := q.head
+	if q.claimed {
+		i++
+	}
+	for ; i < len(q.items); i++ {
+		if fn(q.items[i]) {
+			break
+		}
+	}
+	if i >= len(q.items) {
+		q.Unlock()
+		return nil, false
+	}
+	item := q.items[i]
+	n := copy(q.items[i:], q.items[i+1:])
+	q.items[i+n] = nil
+	q.items = q.items[:i+n]
+	q.account(item, -1)
+	q.publish()
+	q.Unlock()
+	q.emit(EventDequeue, item)
+	return item, true
+}
+
+// RemoveWhere removes every item for which fn returns true, e.g. items whose
+// work has been cancelled, and returns the number removed. The remaining
+// items keep their order. Removing a claimed head ends its claim. Each removed
+// item is emitted as a dequeue, so the queue's hooks, stats, and Bus see it.
+//
+// fn is called once per item, while holding the queue's lock, so it must not
+// use the queue.
+func (q *Queue) RemoveWhere(fn func(item interface{}) bool) int {
+	q.Lock()
+	removed := q.removeWhere(fn)
+	q.Unlock()
+	q.emitRemoved(removed)
+	return len(removed)
+}
+
+// removeWhere is the unexported version of RemoveWhere; the removed items
+// are returned. The caller must hold the lock.
+func (q *Queue) removeWhere(fn func(item interface{}) bool) []interface{} {
+	var removed []interface{}
+	j := q.head
+	for i := q.head; i < len(q.items); i++ {
+		if fn(q.items[i]) {
+			if i == q.head {
+				q.claimed = false
+			}
+			q.account(q.items[i], -1)
+			removed = append(removed, q.items[i])
+			continue
+		}
+		q.items[j] = q.items[i]
+		j++
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	clear(q.items[j:])
+	q.items = q.items[:j]
+	q.publish()
+	return removed
+}
+
+// emitRemoved emits the events for the items removed by removeWhere.
+func (q *Queue) emitRemoved(removed []interface{}) {
+	for _, item := range removed {
+		q.emit(EventDequeue, item)
+	}
+}
+
+// Contains returns whether or not fn returns true for any of the items in
+// the queue, e.g. to check whether a job is already queued before enqueueing
+// it. The queue isn't changed.
+//
+// fn is called while holding the queue's lock, so it must not use the queue.
+func (q *Queue) Contains(fn func(item interface{}) bool) bool {
+	q.Lock()
+	defer q.Unlock()
+	for _, item := range q.items[q.head:] {
+		if fn(item) {
+			return true
+		}
+	}
+	return false
+}
+
+// DequeueIf removes the first item, in FIFO order, for which fn returns true
+// and returns it; see Queue.DequeueIf.
+func (c *Circular) DequeueIf(fn func(item interface{}) bool) (interface{}, bool) {
+	c.Lock()
+	i := c.head
+	if c.claimed {
+		i = c.inc(i)
+	}
+	for ; i != c.tail; i = c.inc(i) {
+		if fn(c.items[i]) {
+			break
+		}
+	}
+	if i == c.tail {
+		c.Unlock()
+		return nil, false
+	}
+	item := c.items[i]
+	// close the gap by moving the items behind it forward.
+	for j := c.inc(i); j != c.tail; j = c.inc(j) {
+		c.items[i] = c.items[j]
+		i = j
+	}
+	c.items[i] = nil
+	c.tail = i
+	c.account(item, -1)
+	c.publish()
+	c.Unlock()
+	c.emit(EventDequeue, item)
+	return item, true
+}
+
+// RemoveWhere removes every item for which fn returns true and returns the
+// number removed; see Queue.RemoveWhere. The remaining items are moved to the
+// front of the queue.
+func (c *Circular) RemoveWhere(fn func(item interface{}) bool) int {
+	c.Lock()
+	removed := c.removeWhere(fn)
+	c.Unlock()
+	c.emitRemoved(removed)
+	return len(removed)
+}
+
+// removeWhere is the unexported version of RemoveWhere; the removed items
+// are returned. The caller must hold the lock.
+func (c *Circular) removeWhere(fn func(item interface{}) bool) []interface{} {
+	items := c.snapshot()
+	var removed []interface{}
+	kept := items[:0]
+	for i, item := range items {
+		if fn(item) {
+			if i == 0 {
+				c.claimed = false
+			}
+			c.account(item, -1)
+			removed = append(removed, item)
+			continue
+		}
+		kept = append(kept, item)
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	j := copy(c.items, kept)
+	clear(c.items[j:])
+	c.head = 0
+	c.tail = j
+	c.publish()
+	return removed
+}
+
+// Contains returns whether or not fn returns true for any of the items in
+// the queue; see Queue.Contains.
+func (c *Circular) Contains(fn func(item interface{}) bool) bool {
+	c.Lock()
+	defer c.Unlock()
+	for i := c.head; i != c.tail; i = c.inc(i) {
+		if fn(c.items[i]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+package queue
+
+import (
+	"testing"
+)
+
+type predicater interface {
+	Queuer
+	Claim() (interface{}, bool)
+	Commit() bool
+	DequeueIf(func(interface{}) bool) (interface{}, bool)
+	RemoveWhere(func(interface{}) bool) int
+	OnDequeue(func(interface{})) func()
+	SetStats(bool)
+	Stats() Stats
+}
+
+func even(item interface{}) bool { return item.(int)%2 == 0 }
+
+func TestDequeueIf(t *testing.T) {
+	tests := []struct {
+		name string
+		q    predicater
+	}{
+		{"queue", NewQueue(2)},
+		{"circular", NewCircular(5)},
+		{"wrapped circular", NewCircular(5)},
+	}
+	for _, test := range tests {
+		q := test.q
+		if test.name == "wrapped circular" {
+			// move the head and tail so that the items wrap around.
+			for i := 0; i < 4; i++ {
+				q.Enqueue(-1)
+				q.Dequeue()
+			}
+		}
+		if _, ok := q.DequeueIf(even); ok {
+			t.Errorf("%s: expected an empty queue to have no match", test.name)
+		}
+		for _, v := range []int{1, 3, 4, 5, 6} {
+			q.Enqueue(v)
+		}
+		if v, ok := q.DequeueIf(even); !ok || v != 4 {
+			t.Errorf("%s: expected 4 true, got %v %t", test.name, v, ok)
+		}
+		if v, ok := q.DequeueIf(func(v interface{}) bool { return v == 7 }); ok {
+			t.Errorf("%s: expected no match, got %v", test.name, v)
+		}
+		if q.Len() != 4 {
+			t.Errorf("%s: expected len 4, got %d", test.name, q.Len())
+		}
+		q.Claim()
+		if v, ok := q.DequeueIf(func(interface{}) bool { return true }); !ok || v != 3 {
+			t.Errorf("%s: expected the claimed head to be skipped, got %v %t", test.name, v, ok)
+		}
+		if v, ok := q.Peek(); v != 1 {
+			t.Errorf("%s: expected the claimed head to remain, got %v %t", test.name, v, ok)
+		}
+		q.Commit()
+		for i, expected := range []int{5, 6} {
+			if v, ok := q.Dequeue(); !ok || v != expected {
+				t.Errorf("%s: %d: expected %d true, got %v %t", test.name, i, expected, v, ok)
+			}
+		}
+	}
+}
+
+func TestRemoveWhere(t *testing.T) {
+	tests := []struct {
+		name string
+		q    predicater
+	}{
+		{"queue", NewQueue(2)},
+		{"circular", NewCircular(6)},
+	}
+	for _, test := range tests {
+		q := test.q
+		if n := q.RemoveWhere(even); n != 0 {
+			t.Errorf("%s: expected nothing to be removed from an empty queue, got %d", test.name, n)
+		}
+		for i := 0; i < 6; i++ {
+			q.Enqueue(i)
+		}
+		q.Claim()
+		q.SetStats(true)
+		var dequeued []interface{}
+		q.OnDequeue(func(item interface{}) { dequeued = append(dequeued, item) })
+		calls := 0
+		if n := q.RemoveWhere(func(item interface{}) bool { calls++; return even(item) }); n != 3 {
+			t.Errorf("%s: expected 3 items to be removed, got %d", test.name, n)
+		}
+		if calls != 6 {
+			t.Errorf("%s: expected fn to be called once per item, got %d calls", test.name, calls)
+		}
+		if len(dequeued) != 3 || dequeued[0] != 0 || dequeued[1] != 2 || dequeued[2] != 4 {
+			t.Errorf("%s: expected the removed items to be emitted as dequeues, got %v", test.name, dequeued)
+		}
+		if st := q.Stats(); st.Dequeued != 3 {
+			t.Errorf("%s: expected 3 dequeues to be counted, got %d", test.name, st.Dequeued)
+		}
+		if q.Len() != 3 {
+			t.Errorf("%s: expected len 3, got %d", test.name, q.Len())
+		}
+		for i, expected := range []int{1, 3, 5} {
+			if v, ok := q.Dequeue(); !ok || v != expected {
+				t.Errorf("%s: %d: expected %d true, got %v %t", test.name, i, expected, v, ok)
+			}
+		}
+	}
+}
+
+func TestRemoveWhereNone(t *testing.T) {
+	// a wrapped circular queue must be left as it is when nothing is removed.
+	c := NewCircular(3)
+	for _, v := range []int{1, 3, 5} {
+		c.Enqueue(v)
+	}
+	c.Dequeue()
+	c.Enqueue(7)
+	if n := c.RemoveWhere(even); n != 0 {
+		t.Errorf("expected nothing to be removed, got %d", n)
+	}
+	for i, expected := range []int{3, 5, 7} {
+		if v, ok := c.Dequeue(); !ok || v != expected {
+			t.Errorf("%d: expected %d true, got %v %t", i, expected, v, ok)
+		}
+	}
+}
+
+func TestContains(t *testing.T) {
+	tests := []struct {
+		name string
+		q    interface {
+			Queuer
+			Contains(func(interface{}) bool) bool
+		}
+	}{
+		{"queue", NewQueue(2)},
+		{"circular", NewCircular(3)},
+	}
+	for _, test := range tests {
+		q := test.q
+		if q.Contains(func(interface{}) bool { return true }) {
+			t.Errorf("%s: expected an empty queue to not contain anything", test.name)
+		}
+		// move the head so that the circular queue's items wrap around.
+		for _, v := range []int{1, 3, 5} {
+			q.Enqueue(v)
+		}
+		q.Dequeue()
+		q.Enqueue(8)
+		if q.Contains(func(v interface{}) bool { return v == 1 }) {
+			t.Errorf("%s: expected a dequeued item to not be contained", test.name)
+		}
+		if !q.Contains(even) {
+			t.Errorf("%s: expected an even item to be contained", test.name)
+		}
+		if q.Len() != 3 {
+			t.Errorf("%s: expected Contains to leave 3 items, got %d", test.name, q.Len())
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package queuetest
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mohae/firkin/queue"
+	"github.com/mohae/firkin/typed"
+)
+
+// Impl describes a FIFO queue implementation for the conformance checks and
+// the benchmarks. A conforming queue is lossless: every item that is
+// successfully enqueued is dequeued exactly once, in FIFO order.
+type Impl struct {
+	Name string
+	// New returns an empty queue that holds at least size items.
+	New func(size int) Queue
+	// Bounded is whether Enqueue fails once the queue holds size items.
+	// Enqueue on an unbounded queue must never fail.
+	Bounded bool
+	// SingleConsumer is whether Dequeue must only be called by one goroutine.
+	SingleConsumer bool
+}
+
+// priority is a Priority whose items all have the same priority, which makes
+// it a FIFO queue.
+type priority struct {
+	*queue.Priority
+}
+
+func (p priority) Enqueue(item interface{}) error {
+	return p.Priority.Enqueue(item, 0)
+}
+
+// Impls returns the lossless FIFO queues in this repo. New implementations
+// should be added here so that they are covered by the conformance tests and
+// the benchmarks. Queues that drop items by design, e.g. buffer.Ring, or that
+// aren't FIFO, e.g. queue.Sharded, aren't included.
+func Impls() []Impl {
+	return []Impl{
+		{Name: "Queue", New: func(size int) Queue { return queue.NewQueue(size) }},
+		{Name: "Circular", New: func(size int) Queue { return queue.NewCircular(size) }, Bounded: true},
+		{Name: "TwoLock", New: func(size int) Queue { return queue.NewTwoLock(size) }, Bounded: true},
+		{Name: "MPMC", New: func(size int) Queue { return queue.NewMPMC(size) }, Bounded: true},
+		{Name: "LinkedMPSC", New: func(int) Queue { return queue.NewLinkedMPSC(0) }, SingleConsumer: true},
+		{Name: "Timed", New: func(size int) Queue { return queue.NewTimed(size) }, Bounded: true},
+		{Name: "TTL", New: func(size int) Queue { return queue.NewTTL(size, time.Hour) }, Bounded: true},
+		{Name: "Unique", New: func(size int) Queue {
+			return queue.NewUnique(size, func(item interface{}) interface{} { return item }, queue.DuplicateReject)
+		}, Bounded: true},
+		{Name: "Priority", New: func(size int) Queue { return priority{queue.NewPriority(size)} }},
+		{Name: "typed.Queue", New: func(size int) Queue { return typed.NewQueue[interface{}](size) }},
+		{Name: "typed.Circular", New: func(size int) Queue { return typed.NewCircular[interface{}](size) }, Bounded: true},
+	}
+}
+
+// CheckFIFO runs ops, one at a time, against a queue created with size and
+// checks the results against a model FIFO queue. Each op is an enqueue, of the
+// next int, if it is even, and a dequeue if it is odd; this makes CheckFIFO
+// suitable for fuzzing. The first difference from the model is returned.
+func CheckFIFO(impl Impl, size int, ops []byte) error {
+	q := impl.New(size)
+	var model []int
+	next := 0
+	for i, op := range ops {
+		if op%2 == 0 {
+			err := q.Enqueue(next)
+			if err != nil {
+				if !impl.Bounded || len(model) < size {
+					return fmt.Errorf("op %d: enqueue %d with %d items queued: %s", i, next, len(model), err)
+				}
+				continue
+			}
+			model = append(model, next)
+			next++
+			continue
+		}
+		v, ok := q.Dequeue()
+		if len(model) == 0 {
+			if ok {
+				return fmt.Errorf("op %d: dequeue from an empty queue returned %v", i, v)
+			}
+			continue
+		}
+		if !ok {
+			return fmt.Errorf("op %d: dequeue with %d items queued returned nothing", i, len(model))
+		}
+		if v != model[0] {
+			return fmt.Errorf("op %d: expected dequeue to return %d, got %v", i, model[0], v)
+		}
+		model = model[1:]
+	}
+	for len(model) > 0 {
+		v, ok := q.Dequeue()
+		if !ok || v != model[0] {
+			return fmt.Errorf("drain: expected dequeue to return %d, got %v %t", model[0], v, ok)
+		}
+		model = model[1:]
+	}
+	if v, ok := q.Dequeue(); ok {
+		return fmt.Errorf("drain: dequeue from an empty queue returned %v", v)
+	}
+	return nil
+}
+
+// stamp is an item enqueued by CheckConcurrent.
+type stamp struct {
+	producer, seq int
+}
+
+// CheckConcurrent has producers goroutines each enqueue n items to a queue
+// created with size while consumers goroutines dequeue them, and checks that
+// every item is dequeued exactly once and that each consumer dequeues the
+// items of each producer in the order they were enqueued. A SingleConsumer
+// queue always has one consumer. If the items haven't all been dequeued
+// within timeout, an error is returned.
+func CheckConcurrent(impl Impl, size, producers, consumers, n int, timeout time.Duration) error {
+	if impl.SingleConsumer {
+		consumers = 1
+	}
+	q := impl.New(size)
+	total := int64(producers * n)
+	var received atomic.Int64
+	deadline := time.Now().Add(timeout)
+	errs := make(chan error, producers+consumers)
+	seen := make([][]int, consumers) // the stamps each consumer dequeued, by producer and seq.
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				for {
+					err := q.Enqueue(stamp{p, i})
+					if err == nil {
+						break
+					}
+					if !impl.Bounded {
+						errs <- fmt.Errorf("producer %d: enqueue %d: %s", p, i, err)
+						return
+					}
+					if time.Now().After(deadline) {
+						errs <- fmt.Errorf("producer %d: enqueue %d: timed out on a full queue", p, i)
+						return
+					}
+					runtime.Gosched()
+				}
+			}
+		}(p)
+	}
+	for c := 0; c < consumers; c++ {
+		wg.Add(1)
+		go func(c int) {
+			defer wg.Done()
+			last := make([]int, producers)
+			for i := range last {
+				last[i] = -1
+			}
+			for received.Load() < total {
+				v, ok := q.Dequeue()
+				if !ok {
+					if time.Now().After(deadline) {
+						errs <- fmt.Errorf("consumer %d: timed out with %d of %d items dequeued", c, received.Load(), total)
+						return
+					}
+					runtime.Gosched()
+					continue
+				}
+				received.Add(1)
+				s, ok := v.(stamp)
+				if !ok || s.producer < 0 || s.producer >= producers {
+					errs <- fmt.Errorf("consumer %d: dequeued an unexpected item: %v", c, v)
+					return
+				}
+				if s.seq <= last[s.producer] {
+					errs <- fmt.Errorf("consumer %d: dequeued item %d of producer %d after item %d", c, s.seq, s.producer, last[s.producer])
+					return
+				}
+				last[s.producer] = s.seq
+				seen[c] = append(seen[c], s.producer*n+s.seq)
+			}
+		}(c)
+	}
+	wg.Wait()
+	close(errs)
+	if err := <-errs; err != nil {
+		return err
+	}
+	counts := make([]int, total)
+	for _, s := range seen {
+		for _, i := range s {
+			counts[i]++
+		}
+	}
+	for i, cnt := range counts {
+		if cnt != 1 {
+			return fmt.Errorf("item %d of producer %d was dequeued %d times", i%n, i/n, cnt)
+		}
+	}
+	if v, ok := q.Dequeue(); ok {
+		return fmt.Errorf("dequeued an extra item: %v", v)
+	}
+	return nil
+}
+
+// Conformance runs the conformance checks against impl as subtests of t: FIFO
+// ordering, with the queue cycling through empty and full, and no lost or
+// duplicated items with 1, 4, and 16 producers and consumers.
+func Conformance(t *testing.T, impl Impl) {
+	t.Run("FIFO", func(t *testing.T) {
+		for _, ops := range [][]byte{
+			{0, 0, 0, 1, 1, 1, 1},
+			{0, 1, 0, 1, 0, 0, 1, 0, 1, 1},
+			{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 0, 0, 0, 1, 1, 1, 1, 1, 1, 1},
+		} {
+			if err := CheckFIFO(impl, 4, ops); err != nil {
+				t.Errorf("%v: %s", ops, err)
+			}
+		}
+	})
+	for _, g := range []int{1, 4, 16} {
+		t.Run(fmt.Sprintf("Concurrent%d", g), func(t *testing.T) {
+			if err := CheckConcurrent(impl, 64, g, g, 500, 10*time.Second); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
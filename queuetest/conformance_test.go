@@ -0,0 +1,93 @@
+package queuetest
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mohae/firkin/queue"
+)
+
+func TestConformance(t *testing.T) {
+	for _, impl := range Impls() {
+		t.Run(impl.Name, func(t *testing.T) {
+			Conformance(t, impl)
+		})
+	}
+}
+
+func FuzzFIFO(f *testing.F) {
+	f.Add([]byte{0, 0, 0, 1, 1, 1, 1})
+	f.Add([]byte{0, 0, 0, 0, 0, 0, 1, 0, 1, 1, 1})
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		for _, impl := range Impls() {
+			if err := CheckFIFO(impl, 4, ops); err != nil {
+				t.Errorf("%s: %s", impl.Name, err)
+			}
+		}
+	})
+}
+
+// lifo is a stack, which CheckFIFO and CheckConcurrent must reject.
+type lifo struct {
+	sync.Mutex
+	items []interface{}
+}
+
+func (l *lifo) Enqueue(item interface{}) error {
+	l.Lock()
+	l.items = append(l.items, item)
+	l.Unlock()
+	return nil
+}
+
+func (l *lifo) Dequeue() (interface{}, bool) {
+	l.Lock()
+	defer l.Unlock()
+	if len(l.items) == 0 {
+		return nil, false
+	}
+	item := l.items[len(l.items)-1]
+	l.items = l.items[:len(l.items)-1]
+	return item, true
+}
+
+// lossy drops every other item.
+type lossy struct {
+	Queue
+	n int
+}
+
+func (l *lossy) Enqueue(item interface{}) error {
+	if l.n++; l.n%2 == 0 {
+		return nil
+	}
+	return l.Queue.Enqueue(item)
+}
+
+func TestConformanceFailures(t *testing.T) {
+	stack := Impl{Name: "lifo", New: func(int) Queue { return &lifo{} }}
+	if err := CheckFIFO(stack, 4, []byte{0, 0, 1}); err == nil || !strings.Contains(err.Error(), "expected dequeue to return 0, got 1") {
+		t.Errorf("lifo: expected an ordering error, got %v", err)
+	}
+	drops := Impl{Name: "lossy", New: func(size int) Queue { return &lossy{Queue: queue.NewQueue(size)} }}
+	if err := CheckFIFO(drops, 4, []byte{0, 0, 1, 1}); err == nil {
+		t.Error("lossy: expected CheckFIFO to fail")
+	}
+	if err := CheckConcurrent(drops, 4, 1, 1, 10, 100*time.Millisecond); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("lossy: expected a time out, got %v", err)
+	}
+	refuses := Impl{Name: "refuser", New: func(int) Queue { return refuser{} }}
+	if err := CheckFIFO(refuses, 4, []byte{0}); err == nil {
+		t.Error("refuser: expected an unbounded queue's failed enqueue to be an error")
+	}
+}
+
+// refuser is an unbounded queue that refuses every item.
+type refuser struct{}
+
+func (refuser) Enqueue(item interface{}) error { return errors.New("refused") }
+
+func (refuser) Dequeue() (interface{}, bool) { return nil, false }
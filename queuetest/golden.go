@@ -0,0 +1,111 @@
+package queuetest
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mohae/firkin/buffer"
+	"github.com/mohae/firkin/queue"
+)
+
+var update = flag.Bool("queuetest.update", false, "update the golden files instead of comparing against them")
+
+// State returns the canonical textual form of a queue's logical state: its
+// length, its capacity, and its items in FIFO order, one per line. The
+// physical layout of the queue, e.g. where its head and tail are, is not part
+// of the state. Items are formatted with %#v.
+//
+// State supports *queue.Queue, *queue.Circular, and *buffer.Ring.
+func State(q interface{}) (string, error) {
+	var items []interface{}
+	var cp int
+	switch q := q.(type) {
+	case *queue.Queue:
+		items, cp = q.Snapshot(), q.Cap()
+	case *buffer.Ring:
+		return State(&q.Circular)
+	case *queue.Circular:
+		items, cp = q.Snapshot(), q.Cap()
+	default:
+		return "", fmt.Errorf("queuetest: unsupported queue type %T", q)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "len: %d\ncap: %d\n", len(items), cp)
+	for i, v := range items {
+		fmt.Fprintf(&b, "%d: %#v\n", i, v)
+	}
+	return b.String(), nil
+}
+
+// Golden compares the state of q, see State, against the golden file at path.
+// If they differ, the test fails with a line by line diff. When the tests
+// are run with the -queuetest.update flag, the golden file is written
+// instead.
+func Golden(t testing.TB, q interface{}, path string) {
+	t.Helper()
+	got, err := State(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%s; run the tests with -queuetest.update to create it", err)
+	}
+	if want := string(b); got != want {
+		t.Errorf("queue state does not match %s:\n%s", path, Diff(want, got))
+	}
+}
+
+// Diff returns a line by line diff of want and got: lines only in want are
+// prefixed with "-", lines only in got with "+", and common lines with " ".
+func Diff(want, got string) string {
+	a := strings.Split(strings.TrimSuffix(want, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and
+	// b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var d strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			d.WriteString(" " + a[i] + "\n")
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			d.WriteString("+" + b[j] + "\n")
+			j++
+		default:
+			d.WriteString("-" + a[i] + "\n")
+			i++
+		}
+	}
+	return d.String()
+}
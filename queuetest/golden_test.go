@@ -0,0 +1,59 @@
+package queuetest
+
+import (
+	"testing"
+
+	"github.com/mohae/firkin/buffer"
+	"github.com/mohae/firkin/queue"
+)
+
+func TestState(t *testing.T) {
+	q := queue.NewQueue(4)
+	c := queue.NewCircular(2)
+	r := buffer.NewRing(2)
+	for i := 0; i < 3; i++ {
+		_ = q.Enqueue(i)
+		_ = c.Enqueue(i)
+		_ = r.Enqueue(i)
+	}
+	q.Dequeue()
+	tests := []struct {
+		q        interface{}
+		expected string
+	}{
+		{q, "len: 2\ncap: 4\n0: 1\n1: 2\n"},
+		{c, "len: 2\ncap: 2\n0: 0\n1: 1\n"},
+		{r, "len: 2\ncap: 2\n0: 1\n1: 2\n"},
+	}
+	for i, test := range tests {
+		got, err := State(test.q)
+		if err != nil {
+			t.Errorf("%d: unexpected error: %s", i, err)
+			continue
+		}
+		if got != test.expected {
+			t.Errorf("%d: expected %q, got %q", i, test.expected, got)
+		}
+	}
+	if _, err := State(1); err == nil {
+		t.Error("expected an error for an unsupported type, got nil")
+	}
+}
+
+func TestGolden(t *testing.T) {
+	c := queue.NewCircular(4)
+	for _, v := range []interface{}{1, 2, "three", 4} {
+		_ = c.Enqueue(v)
+	}
+	c.Dequeue()
+	// the head has moved; the logical state is what's compared.
+	Golden(t, c, "testdata/circular.golden")
+}
+
+func TestDiff(t *testing.T) {
+	got := Diff("a\nb\nc\n", "a\nc\nd\n")
+	expected := " a\n-b\n c\n+d\n"
+	if got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,180 @@
+// Package queuetest provides utilities for testing code that uses queues.
+package queuetest
+
+import (
+	"sync"
+	"time"
+)
+
+// Queue is the interface of the queues that can be scripted.
+type Queue interface {
+	Enqueue(interface{}) error
+	Dequeue() (interface{}, bool)
+}
+
+// Clock is the source of time for a Scripted queue's delays.
+type Clock interface {
+	Now() time.Time
+	Sleep(time.Duration)
+}
+
+// RealClock is a Clock that uses the time package.
+type RealClock struct{}
+
+// Now returns time.Now().
+func (RealClock) Now() time.Time { return time.Now() }
+
+// Sleep calls time.Sleep.
+func (RealClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// FakeClock is a Clock whose time only moves when it is told to: Sleep
+// returns immediately after advancing the clock. It is safe for concurrent use.
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFakeClock returns a FakeClock set to t.
+func NewFakeClock(t time.Time) *FakeClock {
+	return &FakeClock{now: t}
+}
+
+// Now returns the clock's current time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Sleep advances the clock by d.
+func (c *FakeClock) Sleep(d time.Duration) {
+	c.Advance(d)
+}
+
+// Advance advances the clock by d.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+// fault is what happens to a scripted operation.
+type fault struct {
+	delay time.Duration
+	err   error // for enqueues: fail with err.
+	empty bool  // for dequeues: report the queue as empty.
+}
+
+// Scripted wraps a queue and applies scripted outcomes to specific calls: fail
+// the 3rd Enqueue, delay the next Dequeue by 50ms, and so on. Calls are
+// numbered from 1, in the order they are made; calls without a scripted
+// outcome are passed through to the wrapped queue. Delays are slept on the
+// Scripted queue's Clock, so with a FakeClock they take no real time.
+//
+// A Scripted queue is safe for concurrent use, but scripting specific calls
+// is only deterministic when the calls are made from one goroutine.
+type Scripted struct {
+	q        Queue
+	clock    Clock
+	mu       sync.Mutex
+	enqueues int
+	dequeues int
+	enqueue  map[int]fault
+	dequeue  map[int]fault
+}
+
+// NewScripted returns a Scripted queue wrapping q. If clock is nil, the real
+// clock is used.
+func NewScripted(q Queue, clock Clock) *Scripted {
+	if clock == nil {
+		clock = RealClock{}
+	}
+	return &Scripted{q: q, clock: clock, enqueue: make(map[int]fault), dequeue: make(map[int]fault)}
+}
+
+// FailEnqueue makes the n'th Enqueue return err without enqueueing its item.
+func (s *Scripted) FailEnqueue(n int, err error) *Scripted {
+	s.mu.Lock()
+	f := s.enqueue[n]
+	f.err = err
+	s.enqueue[n] = f
+	s.mu.Unlock()
+	return s
+}
+
+// DelayEnqueue makes the n'th Enqueue sleep for d before enqueueing.
+func (s *Scripted) DelayEnqueue(n int, d time.Duration) *Scripted {
+	s.mu.Lock()
+	f := s.enqueue[n]
+	f.delay = d
+	s.enqueue[n] = f
+	s.mu.Unlock()
+	return s
+}
+
+// EmptyDequeue makes the n'th Dequeue report that the queue is empty without
+// dequeueing an item.
+func (s *Scripted) EmptyDequeue(n int) *Scripted {
+	s.mu.Lock()
+	f := s.dequeue[n]
+	f.empty = true
+	s.dequeue[n] = f
+	s.mu.Unlock()
+	return s
+}
+
+// DelayDequeue makes the n'th Dequeue sleep for d before dequeueing.
+func (s *Scripted) DelayDequeue(n int, d time.Duration) *Scripted {
+	s.mu.Lock()
+	f := s.dequeue[n]
+	f.delay = d
+	s.dequeue[n] = f
+	s.mu.Unlock()
+	return s
+}
+
+// NextEnqueue returns the number of the next Enqueue call.
+func (s *Scripted) NextEnqueue() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enqueues + 1
+}
+
+// NextDequeue returns the number of the next Dequeue call.
+func (s *Scripted) NextDequeue() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dequeues + 1
+}
+
+// Enqueue applies the call's scripted outcome, if any, and otherwise
+// enqueues item onto the wrapped queue.
+func (s *Scripted) Enqueue(item interface{}) error {
+	s.mu.Lock()
+	s.enqueues++
+	f := s.enqueue[s.enqueues]
+	s.mu.Unlock()
+	if f.delay > 0 {
+		s.clock.Sleep(f.delay)
+	}
+	if f.err != nil {
+		return f.err
+	}
+	return s.q.Enqueue(item)
+}
+
+// Dequeue applies the call's scripted outcome, if any, and otherwise
+// dequeues an item from the wrapped queue.
+func (s *Scripted) Dequeue() (interface{}, bool) {
+	s.mu.Lock()
+	s.dequeues++
+	f := s.dequeue[s.dequeues]
+	s.mu.Unlock()
+	if f.delay > 0 {
+		s.clock.Sleep(f.delay)
+	}
+	if f.empty {
+		return nil, false
+	}
+	return s.q.Dequeue()
+}
@@ -0,0 +1,46 @@
+package queuetest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mohae/firkin/queue"
+)
+
+func TestScripted(t *testing.T) {
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	fail := errors.New("injected")
+	s := NewScripted(queue.NewQueue(4), clock).FailEnqueue(3, fail).DelayEnqueue(3, time.Second)
+	for i := 1; i <= 4; i++ {
+		err := s.Enqueue(i)
+		if i == 3 && err != fail {
+			t.Errorf("enqueue %d: expected %v, got %v", i, fail, err)
+		}
+		if i != 3 && err != nil {
+			t.Errorf("enqueue %d: unexpected error: %v", i, err)
+		}
+	}
+	if d := clock.Now().Sub(start); d != time.Second {
+		t.Errorf("expected the clock to have advanced 1s, got %s", d)
+	}
+
+	s.DelayDequeue(s.NextDequeue(), 50*time.Millisecond).EmptyDequeue(2)
+	expected := []struct {
+		v  interface{}
+		ok bool
+	}{{1, true}, {nil, false}, {2, true}, {4, true}, {nil, false}}
+	for i, e := range expected {
+		v, ok := s.Dequeue()
+		if v != e.v || ok != e.ok {
+			t.Errorf("dequeue %d: expected %v %t, got %v %t", i+1, e.v, e.ok, v, ok)
+		}
+	}
+	if d := clock.Now().Sub(start); d != time.Second+50*time.Millisecond {
+		t.Errorf("expected the clock to have advanced 1.05s, got %s", d)
+	}
+	if s.NextEnqueue() != 5 || s.NextDequeue() != 6 {
+		t.Errorf("expected the next enqueue and dequeue to be 5 and 6, got %d and %d", s.NextEnqueue(), s.NextDequeue())
+	}
+}
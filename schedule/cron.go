@@ -0,0 +1,176 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule returns the times a job runs at.
+type Schedule interface {
+	// Next returns the first run time after t.
+	Next(t time.Time) time.Time
+}
+
+// every is a fixed interval Schedule.
+type every time.Duration
+
+// Every returns a Schedule that runs every d, starting d after the job is
+// added. A d < 1s is set to 1s.
+func Every(d time.Duration) Schedule {
+	if d < time.Second {
+		d = time.Second
+	}
+	return every(d)
+}
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+// cron is a Schedule parsed from a cron expression. Each field is a bitset of
+// the values that match.
+type cron struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool // whether dom and dow were unrestricted.
+}
+
+// field describes a cron field's range of values.
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = []field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// Cron parses a standard 5 field cron expression: minute, hour, day of month,
+// month, and day of week, in the schedule's time zone, which is that of the
+// times passed to Next. Each field is a '*', a value, a range, 'lo-hi', or a
+// list of them, separated by commas; each can have a step, '/n'. Days of the
+// week are 0-7, with both 0 and 7 being Sunday. As in cron, when both the day
+// of the month and the day of the week are restricted, a day that matches
+// either runs the job.
+//
+// e.g. "*/15 9-17 * * 1-5" runs every 15 minutes from 9:00 to 17:45, Monday
+// to Friday.
+func Cron(expr string) (Schedule, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("cron %q: expected %d fields, got %d", expr, len(fields), len(parts))
+	}
+	var c cron
+	sets := []*uint64{&c.minute, &c.hour, &c.dom, &c.month, &c.dow}
+	for i, part := range parts {
+		set, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("cron %q: %s", expr, err)
+		}
+		*sets[i] = set
+	}
+	c.domStar = parts[2] == "*" || strings.HasPrefix(parts[2], "*/")
+	c.dowStar = parts[4] == "*" || strings.HasPrefix(parts[4], "*/")
+	// Sunday is both 0 and 7.
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	return c, nil
+}
+
+// parseField parses one field of a cron expression into a bitset.
+func parseField(s string, f field) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(s, ",") {
+		rng, step := part, 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("%s: invalid step %q", f.name, part[i+1:])
+			}
+			rng, step = part[:i], n
+		}
+		lo, hi := f.min, f.max
+		if rng != "*" {
+			var err error
+			if i := strings.IndexByte(rng, '-'); i >= 0 {
+				lo, err = parseValue(rng[:i], f)
+				if err == nil {
+					hi, err = parseValue(rng[i+1:], f)
+				}
+			} else {
+				lo, err = parseValue(rng, f)
+				hi = lo
+				if step > 1 {
+					hi = f.max
+				}
+			}
+			if err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("%s: invalid range %q", f.name, rng)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+// parseValue parses a single value of a field.
+func parseValue(s string, f field) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s: %q is not in %d-%d", f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
+
+// maxYears is how far ahead Next looks for a match; an expression that can
+// never match, e.g. February 30th, returns the zero time.
+const maxYears = 5
+
+// Next returns the first minute after t that matches the expression. If
+// nothing matches within 5 years, the zero time is returned.
+func (c cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	end := t.AddDate(maxYears, 0, 0)
+	for t.Before(end) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.day(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// day returns whether or not t's day matches the day of month and day of
+// week fields.
+func (c cron) day(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
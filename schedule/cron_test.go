@@ -0,0 +1,82 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCron(t *testing.T) {
+	// 2024-01-01 is a Monday.
+	start := time.Date(2024, 1, 1, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		expr     string
+		expected []string
+	}{
+		{"* * * * *", []string{"2024-01-01 10:08", "2024-01-01 10:09"}},
+		{"*/15 * * * *", []string{"2024-01-01 10:15", "2024-01-01 10:30", "2024-01-01 10:45", "2024-01-01 11:00"}},
+		{"0 9-10 * * *", []string{"2024-01-02 09:00", "2024-01-02 10:00", "2024-01-03 09:00"}},
+		{"30 8 * * 0", []string{"2024-01-07 08:30", "2024-01-14 08:30"}},
+		{"30 8 * * 7", []string{"2024-01-07 08:30", "2024-01-14 08:30"}},
+		{"0 0 31 * *", []string{"2024-01-31 00:00", "2024-03-31 00:00", "2024-05-31 00:00"}},
+		{"0 0 29 2 *", []string{"2024-02-29 00:00", "2028-02-29 00:00"}},
+		{"0 12 1,15 * *", []string{"2024-01-01 12:00", "2024-01-15 12:00", "2024-02-01 12:00"}},
+		// dom and dow restricted: either matches.
+		{"0 0 13 * 5", []string{"2024-01-05 00:00", "2024-01-12 00:00", "2024-01-13 00:00", "2024-01-19 00:00"}},
+		{"5-20/5 10 * * *", []string{"2024-01-01 10:10", "2024-01-01 10:15", "2024-01-01 10:20", "2024-01-02 10:05"}},
+		{"0 0 30 2 *", []string{"0001-01-01 00:00"}},
+	}
+	for _, test := range tests {
+		s, err := Cron(test.expr)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.expr, err)
+			continue
+		}
+		next := start
+		for i, expected := range test.expected {
+			next = s.Next(next)
+			if got := next.Format("2006-01-02 15:04"); got != expected {
+				t.Errorf("%s: %d: expected %s, got %s", test.expr, i, expected, got)
+				break
+			}
+		}
+	}
+}
+
+func TestCronErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"1,,2 * * * *",
+	}
+	for _, test := range tests {
+		if _, err := Cron(test); err == nil {
+			t.Errorf("%q: expected an error, got none", test)
+		}
+	}
+}
+
+func TestEvery(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		d        time.Duration
+		expected time.Duration
+	}{
+		{time.Minute, time.Minute},
+		{time.Millisecond, time.Second},
+		{0, time.Second},
+	}
+	for _, test := range tests {
+		if got := Every(test.d).Next(start).Sub(start); got != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.d, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+// Package schedule enqueues items on a queue on recurring schedules, either
+// fixed intervals or cron expressions, so periodic jobs can be built on the
+// queues in this module: the scheduler produces the work, the queue's
+// consumers do it.
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Queue is the interface of the queues a Scheduler enqueues to.
+type Queue interface {
+	Enqueue(interface{}) error
+}
+
+// Clock is the Scheduler's source of time.
+type Clock interface {
+	Now() time.Time
+	After(time.Duration) <-chan time.Time
+}
+
+// RealClock is a Clock that uses the time package.
+type RealClock struct{}
+
+// Now returns time.Now().
+func (RealClock) Now() time.Time { return time.Now() }
+
+// After calls time.After.
+func (RealClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// OverlapPolicy is what happens when a job is due while its previous run is
+// still in progress.
+type OverlapPolicy int
+
+// The overlap policies.
+const (
+	// OverlapAllow enqueues every run. This is the default.
+	OverlapAllow OverlapPolicy = iota
+	// OverlapSkip skips a run if the previous run hasn't finished; a run
+	// has finished once its consumer has called Done.
+	OverlapSkip
+)
+
+// Job is a recurring job.
+type Job struct {
+	Name     string
+	Schedule Schedule
+	// Item returns the item to enqueue for the run scheduled at t.
+	Item func(t time.Time) interface{}
+	// Jitter, if > 0, delays each run by a random amount up to Jitter, so
+	// that jobs that are scheduled together don't all run at once.
+	Jitter  time.Duration
+	Overlap OverlapPolicy
+}
+
+// JobStats are a job's run counts.
+type JobStats struct {
+	Runs    int       // runs whose item was enqueued.
+	Skipped int       // runs skipped because of the overlap policy.
+	Failed  int       // runs whose enqueue failed.
+	LastErr error     // the error from the last failed enqueue.
+	Next    time.Time // when the job runs next, including its jitter.
+}
+
+// entry is a scheduled job.
+type entry struct {
+	job     Job
+	nominal time.Time // the next run time per the schedule.
+	next    time.Time // nominal plus jitter.
+	running bool      // whether a run is waiting for Done.
+	stats   JobStats
+}
+
+// Scheduler enqueues the items of its jobs on a queue when they are due. If
+// the scheduler falls behind, e.g. because the process was suspended, a job's
+// missed runs are collapsed into one.
+type Scheduler struct {
+	q     Queue
+	clock Clock
+	mu    sync.Mutex
+	rand  *rand.Rand
+	jobs  map[string]*entry
+	wake  chan struct{} // signalled when a job is added.
+	h     func(job string, recovered interface{})
+}
+
+// NewScheduler returns a Scheduler that enqueues to q. If clock is nil, the
+// real clock is used.
+func NewScheduler(q Queue, clock Clock) *Scheduler {
+	if clock == nil {
+		clock = RealClock{}
+	}
+	return &Scheduler{
+		q:     q,
+		clock: clock,
+		rand:  rand.New(rand.NewSource(time.Now().UnixNano())),
+		jobs:  make(map[string]*entry),
+		wake:  make(chan struct{}, 1),
+	}
+}
+
+// Add adds a job; its first run is its schedule's first run time after now.
+// An error is returned if the job has no schedule or item func, or if there is
+// already a job with its name.
+func (s *Scheduler) Add(job Job) error {
+	if job.Schedule == nil || job.Item == nil {
+		return fmt.Errorf("schedule: job %q needs a schedule and an item func", job.Name)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[job.Name]; ok {
+		return fmt.Errorf("schedule: job %q already exists", job.Name)
+	}
+	e := &entry{job: job}
+	s.schedule(e, s.clock.Now())
+	s.jobs[job.Name] = e
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Remove removes a job.
+func (s *Scheduler) Remove(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.jobs, name)
+}
+
+// Done marks the job's current run as finished; it's for jobs with the
+// OverlapSkip policy, whose consumers must call it when they finish a run.
+func (s *Scheduler) Done(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.jobs[name]; ok {
+		e.running = false
+	}
+}
+
+// SetPanicHandler sets the handler that panics in a job's Item func are
+// reported to, with the job's name; with a handler, a panicking Item func is
+// recovered and the run counts as failed, so the scheduler keeps running. A
+// nil handler, the default, doesn't recover panics.
+func (s *Scheduler) SetPanicHandler(h func(job string, recovered interface{})) {
+	s.mu.Lock()
+	s.h = h
+	s.mu.Unlock()
+}
+
+// item returns the item for e's run at t. If the Item func panics and there
+// is a panic handler, the panic is reported and an error is returned. The
+// caller must hold the lock.
+func (s *Scheduler) item(e *entry, t time.Time) (item interface{}, err error) {
+	if s.h == nil {
+		return e.job.Item(t), nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("schedule: job %q: panic: %v", e.job.Name, r)
+			s.h(e.job.Name, r)
+		}
+	}()
+	return e.job.Item(t), nil
+}
+
+// Stats returns the job's stats.
+func (s *Scheduler) Stats(name string) (JobStats, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.jobs[name]
+	if !ok {
+		return JobStats{}, false
+	}
+	return e.stats, true
+}
+
+// schedule sets e's next run to the first run after t. The caller must hold
+// the lock.
+func (s *Scheduler) schedule(e *entry, t time.Time) {
+	e.nominal = e.job.Schedule.Next(t)
+	e.next = e.nominal
+	if e.job.Jitter > 0 && !e.nominal.IsZero() {
+		e.next = e.nominal.Add(time.Duration(s.rand.Int63n(int64(e.job.Jitter))))
+	}
+	e.stats.Next = e.next
+}
+
+// Tick runs every job that is due at now and returns the number of items
+// enqueued. Run calls Tick as jobs come due; Tick can also be called
+// directly, e.g. by tests, to drive the scheduler without a clock.
+func (s *Scheduler) Tick(now time.Time) int {
+	type run struct {
+		e    *entry
+		item interface{}
+	}
+	var runs []run
+	s.mu.Lock()
+	for _, e := range s.jobs {
+		if e.next.IsZero() || e.next.After(now) {
+			continue
+		}
+		if e.job.Overlap == OverlapSkip && e.running {
+			e.stats.Skipped++
+		} else if item, err := s.item(e, e.nominal); err != nil {
+			e.stats.Failed++
+			e.stats.LastErr = err
+		} else {
+			runs = append(runs, run{e, item})
+			e.running = true
+		}
+		// collapse any missed runs into this one.
+		s.schedule(e, now)
+	}
+	s.mu.Unlock()
+	var n int
+	for _, r := range runs {
+		err := s.q.Enqueue(r.item)
+		s.mu.Lock()
+		if err != nil {
+			r.e.stats.Failed++
+			r.e.stats.LastErr = err
+			r.e.running = false
+		} else {
+			r.e.stats.Runs++
+			n++
+		}
+		s.mu.Unlock()
+	}
+	return n
+}
+
+// next returns the time the next job is due; false is returned if there are
+// no jobs to run.
+func (s *Scheduler) next() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var next time.Time
+	for _, e := range s.jobs {
+		if !e.next.IsZero() && (next.IsZero() || e.next.Before(next)) {
+			next = e.next
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// Run runs the jobs as they come due until ctx is done; ctx's error is
+// returned.
+func (s *Scheduler) Run(ctx context.Context) error {
+	for {
+		var due <-chan time.Time
+		if next, ok := s.next(); ok {
+			due = s.clock.After(next.Sub(s.clock.Now()))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.wake:
+		case <-due:
+			s.Tick(s.clock.Now())
+		}
+	}
+}
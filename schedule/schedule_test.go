@@ -0,0 +1,260 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock whose time only changes when it is advanced.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []waiter
+}
+
+type waiter struct {
+	at time.Time
+	c  chan time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c := make(chan time.Time, 1)
+	if d <= 0 {
+		c <- f.now
+		return c
+	}
+	f.waiters = append(f.waiters, waiter{f.now.Add(d), c})
+	return c
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+	waiters := f.waiters[:0]
+	for _, w := range f.waiters {
+		if w.at.After(f.now) {
+			waiters = append(waiters, w)
+			continue
+		}
+		w.c <- f.now
+	}
+	f.waiters = waiters
+}
+
+// sliceQueue is a Queue that records its items, or fails with err.
+type sliceQueue struct {
+	mu    sync.Mutex
+	items []interface{}
+	err   error
+}
+
+func (q *sliceQueue) Enqueue(item interface{}) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.err != nil {
+		return q.err
+	}
+	q.items = append(q.items, item)
+	return nil
+}
+
+func (q *sliceQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+func stamp(t time.Time) interface{} { return t }
+
+func TestSchedulerTick(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+	q := &sliceQueue{}
+	s := NewScheduler(q, clock)
+	if err := s.Add(Job{Name: "a", Schedule: Every(time.Minute), Item: stamp}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	tests := []struct {
+		at       time.Duration
+		expected int
+	}{
+		{30 * time.Second, 0},
+		{time.Minute, 1},
+		{90 * time.Second, 0},
+		{2 * time.Minute, 1},
+		// missed runs are collapsed into one.
+		{10 * time.Minute, 1},
+		{10*time.Minute + 30*time.Second, 0},
+		{11 * time.Minute, 1},
+	}
+	for _, test := range tests {
+		if n := s.Tick(start.Add(test.at)); n != test.expected {
+			t.Errorf("%s: expected %d, got %d", test.at, test.expected, n)
+		}
+	}
+	// items are given their nominal run time.
+	expected := []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute, 11 * time.Minute}
+	if len(q.items) != len(expected) {
+		t.Fatalf("expected %d items, got %d", len(expected), len(q.items))
+	}
+	for i, d := range expected {
+		if q.items[i] != start.Add(d) {
+			t.Errorf("%d: expected %s, got %v", i, start.Add(d), q.items[i])
+		}
+	}
+	st, ok := s.Stats("a")
+	if !ok || st.Runs != 4 || !st.Next.Equal(start.Add(12*time.Minute)) {
+		t.Errorf("expected 4 runs, next at 12m, got %+v %t", st, ok)
+	}
+}
+
+func TestSchedulerAdd(t *testing.T) {
+	s := NewScheduler(&sliceQueue{}, nil)
+	tests := []struct {
+		job Job
+		err bool
+	}{
+		{Job{Name: "a", Schedule: Every(time.Minute), Item: stamp}, false},
+		{Job{Name: "a", Schedule: Every(time.Hour), Item: stamp}, true},
+		{Job{Name: "b", Item: stamp}, true},
+		{Job{Name: "c", Schedule: Every(time.Minute)}, true},
+	}
+	for _, test := range tests {
+		if err := s.Add(test.job); (err != nil) != test.err {
+			t.Errorf("%s: expected error %t, got %v", test.job.Name, test.err, err)
+		}
+	}
+	s.Remove("a")
+	if _, ok := s.Stats("a"); ok {
+		t.Error("expected a removed job to have no stats")
+	}
+	if err := s.Add(Job{Name: "a", Schedule: Every(time.Hour), Item: stamp}); err != nil {
+		t.Errorf("expected a removed job's name to be reusable, got %s", err)
+	}
+}
+
+func TestSchedulerOverlap(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	q := &sliceQueue{}
+	s := NewScheduler(q, &fakeClock{now: start})
+	s.Add(Job{Name: "allow", Schedule: Every(time.Minute), Item: stamp})
+	s.Add(Job{Name: "skip", Schedule: Every(time.Minute), Item: stamp, Overlap: OverlapSkip})
+	for i := 1; i <= 3; i++ {
+		s.Tick(start.Add(time.Duration(i) * time.Minute))
+	}
+	allow, _ := s.Stats("allow")
+	skip, _ := s.Stats("skip")
+	if allow.Runs != 3 || allow.Skipped != 0 {
+		t.Errorf("allow: expected 3 runs, 0 skipped, got %+v", allow)
+	}
+	if skip.Runs != 1 || skip.Skipped != 2 {
+		t.Errorf("skip: expected 1 run, 2 skipped, got %+v", skip)
+	}
+	s.Done("skip")
+	s.Tick(start.Add(4 * time.Minute))
+	if skip, _ = s.Stats("skip"); skip.Runs != 2 {
+		t.Errorf("skip: expected a run after Done, got %+v", skip)
+	}
+}
+
+func TestSchedulerFailed(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	full := errors.New("full")
+	q := &sliceQueue{err: full}
+	s := NewScheduler(q, &fakeClock{now: start})
+	s.Add(Job{Name: "a", Schedule: Every(time.Minute), Item: stamp, Overlap: OverlapSkip})
+	if n := s.Tick(start.Add(time.Minute)); n != 0 {
+		t.Errorf("expected no items to be enqueued, got %d", n)
+	}
+	st, _ := s.Stats("a")
+	if st.Failed != 1 || st.LastErr != full {
+		t.Errorf("expected 1 failure, got %+v", st)
+	}
+	// a failed run isn't running, so it doesn't block the next one.
+	q.err = nil
+	if n := s.Tick(start.Add(2 * time.Minute)); n != 1 {
+		t.Errorf("expected 1 item to be enqueued, got %d", n)
+	}
+}
+
+func TestSchedulerJitter(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewScheduler(&sliceQueue{}, &fakeClock{now: start})
+	for i := 0; i < 20; i++ {
+		s.Remove("a")
+		s.Add(Job{Name: "a", Schedule: Every(time.Minute), Item: stamp, Jitter: 10 * time.Second})
+		st, _ := s.Stats("a")
+		if d := st.Next.Sub(start); d < time.Minute || d >= time.Minute+10*time.Second {
+			t.Fatalf("expected the next run to be within the jitter, got %s", d)
+		}
+	}
+}
+
+func TestSchedulerRun(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+	q := &sliceQueue{}
+	s := NewScheduler(q, clock)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+	s.Add(Job{Name: "a", Schedule: Every(time.Minute), Item: stamp})
+	for i := 1; i <= 3; i++ {
+		// wait for Run to be waiting on the clock before advancing it.
+		for deadline := time.Now().Add(time.Second); ; {
+			clock.mu.Lock()
+			n := len(clock.waiters)
+			clock.mu.Unlock()
+			if n > 0 || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		clock.Advance(time.Minute)
+		for deadline := time.Now().Add(time.Second); q.Len() < i && time.Now().Before(deadline); {
+			time.Sleep(time.Millisecond)
+		}
+		if q.Len() != i {
+			t.Fatalf("%d: expected %d items, got %d", i, i, q.Len())
+		}
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("expected %s, got %v", context.Canceled, err)
+	}
+}
+
+func TestSchedulerPanicHandler(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	q := &sliceQueue{}
+	s := NewScheduler(q, &fakeClock{now: start})
+	var panicked []string
+	s.SetPanicHandler(func(job string, recovered interface{}) { panicked = append(panicked, job) })
+	s.Add(Job{Name: "bad", Schedule: Every(time.Minute), Item: func(time.Time) interface{} { panic("boom") }})
+	s.Add(Job{Name: "good", Schedule: Every(time.Minute), Item: stamp})
+	if n := s.Tick(start.Add(time.Minute)); n != 1 {
+		t.Errorf("expected the good job to run, got %d", n)
+	}
+	if len(panicked) != 1 || panicked[0] != "bad" {
+		t.Errorf("expected the bad job's panic, got %v", panicked)
+	}
+	if st, _ := s.Stats("bad"); st.Failed != 1 || st.LastErr == nil {
+		t.Errorf("expected the bad job's run to fail, got %+v", st)
+	}
+	// the lock was released.
+	if _, ok := s.Stats("good"); !ok {
+		t.Error("expected the good job's stats")
+	}
+}
@@ -0,0 +1,77 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"time"
+)
+
+// maxBackoff is the longest a LineProducer waits between enqueue attempts.
+const maxBackoff = 10 * time.Millisecond
+
+// ScanDelim returns a bufio.SplitFunc that splits its input on delim. The
+// delimiter is not part of the returned tokens.
+func ScanDelim(delim byte) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if atEOF && len(data) == 0 {
+			return 0, nil, nil
+		}
+		if i := bytes.IndexByte(data, delim); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		if atEOF {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
+
+// LineProducer reads an io.Reader line by line, or by a custom delimiter, and
+// enqueues each line as a []byte. Unlike ReaderSource, an enqueue that fails
+// because the queue is full is retried until it succeeds: reading is paused
+// while the queue is full so that the input is never buffered in memory
+// beyond the current line.
+type LineProducer struct {
+	s *bufio.Scanner
+}
+
+// NewLineProducer returns a LineProducer that reads lines from r.
+func NewLineProducer(r io.Reader) *LineProducer {
+	return &LineProducer{s: bufio.NewScanner(r)}
+}
+
+// NewDelimProducer returns a LineProducer whose lines are delimited by delim.
+func NewDelimProducer(r io.Reader, delim byte) *LineProducer {
+	p := NewLineProducer(r)
+	p.s.Split(ScanDelim(delim))
+	return p
+}
+
+// Run reads r until EOF, enqueueing each line onto q. If q is full, Run
+// waits, with an increasing backoff, until the line can be enqueued. Run
+// returns the number of lines enqueued and, if ctx is done before r is
+// exhausted, ctx's error; the line being enqueued at the time is lost.
+func (p *LineProducer) Run(ctx context.Context, q Queue) (int, error) {
+	var n int
+	for p.s.Scan() {
+		line := append([]byte(nil), p.s.Bytes()...)
+		backoff := 50 * time.Microsecond
+		for q.Enqueue(line) != nil {
+			select {
+			case <-ctx.Done():
+				return n, ctx.Err()
+			case <-time.After(backoff):
+			}
+			if backoff *= 2; backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		}
+		n++
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+	}
+	return n, p.s.Err()
+}
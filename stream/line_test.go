@@ -0,0 +1,64 @@
+package stream
+
+import (
+	"bufio"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mohae/firkin/queue"
+)
+
+func TestScanDelim(t *testing.T) {
+	s := bufio.NewScanner(strings.NewReader("a,b,,c"))
+	s.Split(ScanDelim(','))
+	var got []string
+	for s.Scan() {
+		got = append(got, s.Text())
+	}
+	if strings.Join(got, "|") != "a|b||c" {
+		t.Errorf("expected %q, got %q", "a|b||c", strings.Join(got, "|"))
+	}
+}
+
+func TestLineProducerBackpressure(t *testing.T) {
+	q := queue.NewCircular(2)
+	p := NewDelimProducer(strings.NewReader("0;1;2;3;4"), ';')
+	done := make(chan int)
+	go func() {
+		n, err := p.Run(context.Background(), q)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+		done <- n
+	}()
+	var got []string
+	for len(got) < 5 {
+		v, ok := q.Dequeue()
+		if !ok {
+			time.Sleep(time.Millisecond)
+			continue
+		}
+		got = append(got, string(v.([]byte)))
+	}
+	if n := <-done; n != 5 {
+		t.Errorf("expected 5 lines, got %d", n)
+	}
+	if strings.Join(got, "") != "01234" {
+		t.Errorf("expected %q, got %q", "01234", strings.Join(got, ""))
+	}
+}
+
+func TestLineProducerCancel(t *testing.T) {
+	q := queue.NewCircular(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	n, err := NewLineProducer(strings.NewReader("a\nb\nc\n")).Run(ctx, q)
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 line to be enqueued, got %d", n)
+	}
+}
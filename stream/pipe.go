@@ -0,0 +1,126 @@
+package stream
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Dequeuer is the interface of the queues that sinks dequeue from.
+type Dequeuer interface {
+	Dequeue() (interface{}, bool)
+}
+
+// ReaderSource reads tokens from an io.Reader, e.g. os.Stdin, and enqueues
+// each token as a []byte.
+type ReaderSource struct {
+	s *bufio.Scanner
+}
+
+// NewReaderSource returns a ReaderSource that splits r into tokens using
+// split. If split is nil, r is split into lines.
+func NewReaderSource(r io.Reader, split bufio.SplitFunc) *ReaderSource {
+	s := bufio.NewScanner(r)
+	if split != nil {
+		s.Split(split)
+	}
+	return &ReaderSource{s: s}
+}
+
+// Run reads r until EOF, enqueueing each token onto q. The number of tokens
+// enqueued is returned. If an enqueue fails, e.g. the queue is full, Run
+// stops and returns the enqueue's error; the token that failed is lost.
+func (r *ReaderSource) Run(q Queue) (int, error) {
+	var n int
+	for r.s.Scan() {
+		// the scanner reuses its buffer; each token needs its own copy.
+		tok := append([]byte(nil), r.s.Bytes()...)
+		if err := q.Enqueue(tok); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, r.s.Err()
+}
+
+// Encoder encodes an item for writing.
+type Encoder func(interface{}) ([]byte, error)
+
+// Lines is the default Encoder: []byte and string items are written as is,
+// anything else is formatted with fmt.Sprint. Each item is followed by a
+// newline.
+func Lines(v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case []byte:
+		return append(append([]byte(nil), v...), '\n'), nil
+	case string:
+		return []byte(v + "\n"), nil
+	}
+	return []byte(fmt.Sprintln(v)), nil
+}
+
+// WriterSink dequeues items, encodes them, and writes them to an io.Writer,
+// e.g. os.Stdout.
+type WriterSink struct {
+	w      io.Writer
+	encode Encoder
+	// Poll is how long Run waits before checking an empty queue again. If 0,
+	// 10ms is used.
+	Poll time.Duration
+}
+
+// NewWriterSink returns a WriterSink that writes to w using encode. If encode
+// is nil, Lines is used.
+func NewWriterSink(w io.Writer, encode Encoder) *WriterSink {
+	if encode == nil {
+		encode = Lines
+	}
+	return &WriterSink{w: w, encode: encode}
+}
+
+// Drain writes every item in q until q is empty. The number of items written
+// is returned. If an item can't be encoded or written, Drain stops and
+// returns the error; the item is lost.
+func (s *WriterSink) Drain(q Dequeuer) (int, error) {
+	var n int
+	for {
+		v, ok := q.Dequeue()
+		if !ok {
+			return n, nil
+		}
+		if err := s.write(v); err != nil {
+			return n, err
+		}
+		n++
+	}
+}
+
+// Run writes the items in q as they arrive until ctx is done or a write
+// fails. Once ctx is done, any items remaining in q are left there.
+func (s *WriterSink) Run(ctx context.Context, q Dequeuer) error {
+	poll := s.Poll
+	if poll == 0 {
+		poll = 10 * time.Millisecond
+	}
+	for {
+		if _, err := s.Drain(q); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(poll):
+		}
+	}
+}
+
+func (s *WriterSink) write(v interface{}) error {
+	b, err := s.encode(v)
+	if err != nil {
+		return err
+	}
+	_, err = s.w.Write(b)
+	return err
+}
@@ -0,0 +1,99 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mohae/firkin/queue"
+)
+
+func TestReaderSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		split    bufio.SplitFunc
+		size     int
+		expected []string
+		err      string
+	}{
+		{"a\nb\nc\n", nil, 4, []string{"a", "b", "c"}, ""},
+		{"a b  c", bufio.ScanWords, 4, []string{"a", "b", "c"}, ""},
+		{"a\nb\nc\n", nil, 2, []string{"a", "b"}, "queue full: cannot enqueue [99]"},
+	}
+	for i, test := range tests {
+		q := queue.NewCircular(test.size)
+		n, err := NewReaderSource(strings.NewReader(test.input), test.split).Run(q)
+		if err != nil && err.Error() != test.err {
+			t.Errorf("%d: expected error %q, got %q", i, test.err, err)
+		}
+		if err == nil && test.err != "" {
+			t.Errorf("%d: expected error %q, got nil", i, test.err)
+		}
+		if n != len(test.expected) {
+			t.Errorf("%d: expected %d tokens, got %d", i, len(test.expected), n)
+		}
+		for _, s := range test.expected {
+			v, _ := q.Dequeue()
+			if string(v.([]byte)) != s {
+				t.Errorf("%d: expected %q, got %q", i, s, v)
+			}
+		}
+	}
+}
+
+func TestWriterSink(t *testing.T) {
+	q := queue.NewQueue(4)
+	_ = q.Enqueue([]byte("a"))
+	_ = q.Enqueue("b")
+	_ = q.Enqueue(3)
+	var buf bytes.Buffer
+	n, err := NewWriterSink(&buf, nil).Drain(q)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if n != 3 || buf.String() != "a\nb\n3\n" {
+		t.Errorf("expected 3 items and %q, got %d and %q", "a\nb\n3\n", n, buf.String())
+	}
+
+	_ = q.Enqueue(1)
+	_ = q.Enqueue(2)
+	fail := errors.New("cannot encode")
+	enc := func(v interface{}) ([]byte, error) {
+		if v == 2 {
+			return nil, fail
+		}
+		return Lines(v)
+	}
+	buf.Reset()
+	n, err = NewWriterSink(&buf, enc).Drain(q)
+	if err != fail || n != 1 {
+		t.Errorf("expected 1 item and %v, got %d and %v", fail, n, err)
+	}
+}
+
+func TestWriterSinkRun(t *testing.T) {
+	q := queue.NewQueue(4)
+	var buf bytes.Buffer
+	s := NewWriterSink(&buf, nil)
+	s.Poll = time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- s.Run(ctx, q)
+	}()
+	_ = q.Enqueue("a")
+	for q.Len() > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+	if buf.String() != "a\n" {
+		t.Errorf("expected %q, got %q", "a\n", buf.String())
+	}
+}
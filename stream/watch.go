@@ -0,0 +1,230 @@
+// Package stream connects queues to the outside world: sources that produce
+// items and enqueue them onto a queue, and sinks that dequeue items and write
+// them somewhere.
+package stream
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Queue is the interface of the queues that sources enqueue onto.
+type Queue interface {
+	Enqueue(interface{}) error
+}
+
+// FileOp is the kind of change a FileEvent reports.
+type FileOp int
+
+// The file changes a Watcher reports.
+const (
+	FileCreate FileOp = iota
+	FileWrite
+	FileRemove
+	FileRename
+)
+
+func (o FileOp) String() string {
+	switch o {
+	case FileCreate:
+		return "create"
+	case FileWrite:
+		return "write"
+	case FileRemove:
+		return "remove"
+	case FileRename:
+		return "rename"
+	}
+	return "unknown"
+}
+
+// FileEvent is the item a Watcher enqueues.
+type FileEvent struct {
+	Op      FileOp
+	Path    string
+	OldPath string // for renames, the file's previous path.
+	Size    int64
+	ModTime time.Time
+	Data    []byte // the file's contents, if the Watcher reads them.
+}
+
+// Watcher watches a directory tree and enqueues an event for every file that
+// is created, written, removed, or renamed. The directory is polled, so it
+// works the same on every platform; changes are detected by comparing each
+// scan with the previous one:
+//
+//   - a file that is written to more than once between scans only produces
+//     one event.
+//   - a file that disappears from one path and appears at another, and
+//     os.SameFile reports they are the same file, is reported as a rename
+//     instead of a remove and a create.
+//
+// If the queue is full, the events that could not be enqueued are kept and
+// retried, in order, on the next scan; this provides backpressure to the
+// watcher without losing changes. At most one event per path is kept: a newer
+// event for a path replaces the kept one, except that a write to a file whose
+// create, or rename, is still pending updates that event instead, so a file
+// written to repeatedly while the queue is full produces one event, with its
+// latest contents. A file removed while its create is pending produces no
+// event, and one removed while its rename is pending is reported as removed
+// from its old path.
+type Watcher struct {
+	dir      string
+	q        Queue
+	files    map[string]os.FileInfo
+	pending  []FileEvent
+	Contents bool // read each created or written file's contents into the event.
+}
+
+// NewWatcher returns a Watcher for the directory tree rooted at dir. The
+// first scan reports every file already in the tree as created.
+func NewWatcher(dir string, q Queue) *Watcher {
+	return &Watcher{dir: dir, q: q, files: make(map[string]os.FileInfo)}
+}
+
+// Scan scans the directory tree once and enqueues an event for each change
+// since the previous scan. The number of events enqueued is returned.
+func (w *Watcher) Scan() (int, error) {
+	files := make(map[string]os.FileInfo, len(w.files))
+	err := filepath.WalkDir(w.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// the file may have been removed since the directory was read.
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		files[path] = info
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var created, removed []string
+	var events []FileEvent
+	for path, info := range files {
+		old, ok := w.files[path]
+		if !ok {
+			created = append(created, path)
+			continue
+		}
+		if !info.ModTime().Equal(old.ModTime()) || info.Size() != old.Size() {
+			events = append(events, w.event(FileWrite, path, "", info))
+		}
+	}
+	for path := range w.files {
+		if _, ok := files[path]; !ok {
+			removed = append(removed, path)
+		}
+	}
+	sort.Strings(created)
+	sort.Strings(removed)
+	for _, path := range created {
+		info := files[path]
+		op, oldPath := FileCreate, ""
+		for i, r := range removed {
+			if os.SameFile(w.files[r], info) {
+				op, oldPath = FileRename, r
+				removed = append(removed[:i], removed[i+1:]...)
+				break
+			}
+		}
+		events = append(events, w.event(op, path, oldPath, info))
+	}
+	for _, path := range removed {
+		events = append(events, FileEvent{Op: FileRemove, Path: path})
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Path < events[j].Path })
+	w.files = files
+	w.merge(events)
+
+	var n int
+	for n < len(w.pending) {
+		if w.q.Enqueue(w.pending[n]) != nil {
+			break
+		}
+		n++
+	}
+	w.pending = append(w.pending[:0], w.pending[n:]...)
+	return n, nil
+}
+
+// merge adds events to the pending events, keeping at most one per path.
+func (w *Watcher) merge(events []FileEvent) {
+	for _, e := range events {
+		w.mergeOne(e)
+	}
+}
+
+// mergeOne adds e to the pending events, replacing, or combining it with, the
+// pending event for its path, if there is one.
+func (w *Watcher) mergeOne(e FileEvent) {
+	for i, p := range w.pending {
+		if p.Path != e.Path {
+			continue
+		}
+		w.pending = append(w.pending[:i], w.pending[i+1:]...)
+		switch {
+		case e.Op == FileWrite && (p.Op == FileCreate || p.Op == FileRename):
+			e.Op, e.OldPath = p.Op, p.OldPath
+		case e.Op == FileRemove && p.Op == FileCreate:
+			// the consumer never heard of the file.
+			return
+		case e.Op == FileRemove && p.Op == FileRename:
+			// the consumer only knows the file by its old path.
+			w.mergeOne(FileEvent{Op: FileRemove, Path: p.OldPath})
+			return
+		}
+		w.pending = append(w.pending, e)
+		return
+	}
+	w.pending = append(w.pending, e)
+}
+
+// event returns the event for the file at path.
+func (w *Watcher) event(op FileOp, path, oldPath string, info os.FileInfo) FileEvent {
+	e := FileEvent{Op: op, Path: path, OldPath: oldPath, Size: info.Size(), ModTime: info.ModTime()}
+	if w.Contents && op != FileRename {
+		// if the file can't be read, e.g. it was removed, the next scan
+		// reports it.
+		e.Data, _ = os.ReadFile(path)
+	}
+	return e
+}
+
+// Pending returns the number of events waiting to be enqueued.
+func (w *Watcher) Pending() int {
+	return len(w.pending)
+}
+
+// Run scans the directory tree every interval until ctx is done or a scan
+// fails. The scan's error, or ctx's error, is returned.
+func (w *Watcher) Run(ctx context.Context, interval time.Duration) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		if _, err := w.Scan(); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package stream
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mohae/firkin/queue"
+)
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a")
+	b := filepath.Join(dir, "b")
+	c := filepath.Join(dir, "sub", "c")
+	if err := os.WriteFile(a, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	q := queue.NewQueue(8)
+	w := NewWatcher(dir, q)
+	w.Contents = true
+
+	type expect struct {
+		op      FileOp
+		path    string
+		oldPath string
+		data    string
+	}
+	check := func(step string, expected []expect) {
+		t.Helper()
+		if _, err := w.Scan(); err != nil {
+			t.Fatalf("%s: unexpected error: %s", step, err)
+		}
+		if q.Len() != len(expected) {
+			t.Errorf("%s: expected %d events, got %d", step, len(expected), q.Len())
+		}
+		for _, e := range expected {
+			v, ok := q.Dequeue()
+			if !ok {
+				return
+			}
+			ev := v.(FileEvent)
+			if ev.Op != e.op || ev.Path != e.path || ev.OldPath != e.oldPath || string(ev.Data) != e.data {
+				t.Errorf("%s: expected %s %q %q %q, got %s %q %q %q", step, e.op, e.path, e.oldPath, e.data, ev.Op, ev.Path, ev.OldPath, ev.Data)
+			}
+		}
+	}
+
+	check("initial", []expect{{FileCreate, a, "", "a"}})
+	check("unchanged", nil)
+
+	// multiple writes between scans are one event.
+	_ = os.WriteFile(a, []byte("aa"), 0644)
+	_ = os.WriteFile(a, []byte("aaa"), 0644)
+	_ = os.MkdirAll(filepath.Dir(c), 0755)
+	_ = os.WriteFile(c, []byte("c"), 0644)
+	check("write", []expect{{FileWrite, a, "", "aaa"}, {FileCreate, c, "", "c"}})
+
+	if err := os.Rename(a, b); err != nil {
+		t.Fatal(err)
+	}
+	check("rename", []expect{{FileRename, b, a, ""}})
+
+	_ = os.Remove(c)
+	check("remove", []expect{{FileRemove, c, "", ""}})
+}
+
+func TestWatcherBackpressure(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a", "b", "c"} {
+		_ = os.WriteFile(filepath.Join(dir, name), nil, 0644)
+	}
+	q := queue.NewCircular(2)
+	w := NewWatcher(dir, q)
+	n, err := w.Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || w.Pending() != 1 {
+		t.Errorf("expected 2 enqueued and 1 pending, got %d and %d", n, w.Pending())
+	}
+	q.Dequeue()
+	q.Dequeue()
+	n, _ = w.Scan()
+	if n != 1 || w.Pending() != 0 {
+		t.Errorf("expected 1 enqueued and 0 pending, got %d and %d", n, w.Pending())
+	}
+	v, _ := q.Dequeue()
+	if p := v.(FileEvent).Path; p != filepath.Join(dir, "c") {
+		t.Errorf("expected the pending event to be for c, got %q", p)
+	}
+}
+
+func TestWatcherPendingMerge(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a")
+	_ = os.WriteFile(a, []byte("0"), 0644)
+	q := queue.NewCircular(1)
+	_ = q.Enqueue("full")
+	w := NewWatcher(dir, q)
+	w.Contents = true
+	for i := 1; i <= 10; i++ {
+		if _, err := w.Scan(); err != nil {
+			t.Fatal(err)
+		}
+		if w.Pending() != 1 {
+			t.Fatalf("%d: expected 1 pending event, got %d", i, w.Pending())
+		}
+		// make sure the write is seen as a change.
+		mod := time.Now().Add(time.Duration(i) * time.Second)
+		_ = os.WriteFile(a, []byte(strconv.Itoa(i)), 0644)
+		_ = os.Chtimes(a, mod, mod)
+	}
+	q.Dequeue()
+	if n, _ := w.Scan(); n != 1 || w.Pending() != 0 {
+		t.Fatalf("expected 1 enqueued and 0 pending, got %d and %d", n, w.Pending())
+	}
+	v, _ := q.Dequeue()
+	e := v.(FileEvent)
+	if e.Op != FileCreate || e.Path != a || string(e.Data) != "10" {
+		t.Errorf("expected a create of %s with the latest contents, got %v %s %q", a, e.Op, e.Path, e.Data)
+	}
+}
+
+func TestWatcherPendingRemove(t *testing.T) {
+	dir := t.TempDir()
+	a, b, c := filepath.Join(dir, "a"), filepath.Join(dir, "b"), filepath.Join(dir, "c")
+	_ = os.WriteFile(a, []byte("a"), 0644)
+	q := queue.NewCircular(1)
+	w := NewWatcher(dir, q)
+	// the create of a fills the queue.
+	if n, err := w.Scan(); err != nil || n != 1 {
+		t.Fatalf("expected 1 enqueued, got %d %v", n, err)
+	}
+	// a is renamed to b, then b is removed, while the queue is full: the
+	// consumer only knows about a, so it must be told a was removed.
+	_ = os.Rename(a, b)
+	w.Scan()
+	_ = os.Remove(b)
+	w.Scan()
+	// a file created and removed while the queue is full is never reported.
+	_ = os.WriteFile(c, []byte("c"), 0644)
+	w.Scan()
+	_ = os.Remove(c)
+	w.Scan()
+	if w.Pending() != 1 {
+		t.Fatalf("expected 1 pending event, got %d", w.Pending())
+	}
+	q.Dequeue()
+	if n, _ := w.Scan(); n != 1 || w.Pending() != 0 {
+		t.Fatalf("expected 1 enqueued and 0 pending, got %d and %d", n, w.Pending())
+	}
+	v, _ := q.Dequeue()
+	if e := v.(FileEvent); e.Op != FileRemove || e.Path != a {
+		t.Errorf("expected a remove of %s, got %v %s", a, e.Op, e.Path)
+	}
+}
@@ -0,0 +1,255 @@
+package stress
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// OpKind is the kind of a queue operation.
+type OpKind int
+
+// The kinds of operations in a history.
+const (
+	OpEnqueue OpKind = iota
+	OpDequeue
+)
+
+// Op is one operation in a history: what was called, what it returned, and
+// when it was called and returned. Call and Return are logical timestamps;
+// they only need to be ordered relative to each other.
+type Op struct {
+	Kind     OpKind
+	Value    interface{} // the item enqueued or the item dequeued.
+	Priority int         // the item's priority; only used by priority models.
+	OK       bool        // whether the enqueue or dequeue succeeded.
+	Call     int64
+	Return   int64
+}
+
+// Model is the sequential specification that a history is checked against.
+// States must be treated as immutable; Step returns a new state.
+type Model interface {
+	// Init returns the initial state.
+	Init() interface{}
+	// Step returns whether op is legal in state and, if so, the state after
+	// op.
+	Step(state interface{}, op Op) (bool, interface{})
+	// Key returns a string that uniquely identifies a state.
+	Key(state interface{}) string
+}
+
+// FIFO returns the model of a FIFO queue holding at most cap items. If cap
+// is 0, the queue is unbounded and enqueues never fail.
+func FIFO(cap int) Model {
+	return fifo{cap: cap}
+}
+
+type fifo struct {
+	cap int
+}
+
+func (f fifo) Init() interface{} {
+	return []interface{}(nil)
+}
+
+func (f fifo) Step(state interface{}, op Op) (bool, interface{}) {
+	items := state.([]interface{})
+	switch op.Kind {
+	case OpEnqueue:
+		if !op.OK {
+			return f.cap > 0 && len(items) == f.cap, items
+		}
+		if f.cap > 0 && len(items) == f.cap {
+			return false, nil
+		}
+		next := make([]interface{}, len(items), len(items)+1)
+		copy(next, items)
+		return true, append(next, op.Value)
+	default:
+		if !op.OK {
+			return len(items) == 0, items
+		}
+		if len(items) == 0 || items[0] != op.Value {
+			return false, nil
+		}
+		return true, items[1:]
+	}
+}
+
+func (f fifo) Key(state interface{}) string {
+	return fmt.Sprint(state)
+}
+
+// Priority returns the model of an unbounded priority queue: a dequeue must
+// return an item with the highest priority in the queue. Items with equal
+// priorities may be dequeued in any order.
+func Priority() Model {
+	return priority{}
+}
+
+type priority struct{}
+
+type prioritized struct {
+	value    interface{}
+	priority int
+}
+
+func (p priority) Init() interface{} {
+	return []prioritized(nil)
+}
+
+func (p priority) Step(state interface{}, op Op) (bool, interface{}) {
+	items := state.([]prioritized)
+	switch op.Kind {
+	case OpEnqueue:
+		if !op.OK {
+			return false, nil
+		}
+		next := make([]prioritized, len(items), len(items)+1)
+		copy(next, items)
+		next = append(next, prioritized{op.Value, op.Priority})
+		// keep the state canonical so equal states have equal keys.
+		sort.SliceStable(next, func(i, j int) bool { return next[i].priority > next[j].priority })
+		return true, next
+	default:
+		if !op.OK {
+			return len(items) == 0, items
+		}
+		for i, it := range items {
+			if it.priority != items[0].priority {
+				break
+			}
+			if it.value == op.Value {
+				next := make([]prioritized, 0, len(items)-1)
+				next = append(next, items[:i]...)
+				return true, append(next, items[i+1:]...)
+			}
+		}
+		return false, nil
+	}
+}
+
+func (p priority) Key(state interface{}) string {
+	return fmt.Sprint(state)
+}
+
+// Linearizable returns whether the history is linearizable with respect to
+// the model: there is some sequential order of the operations, consistent with
+// their real-time order, that the model accepts.
+//
+// This is an exhaustive search, memoized on the set of linearized operations
+// and the model state. It is meant for the short histories of tests.
+func Linearizable(m Model, history []Op) bool {
+	ops := make([]Op, len(history))
+	copy(ops, history)
+	sort.Slice(ops, func(i, j int) bool { return ops[i].Call < ops[j].Call })
+	c := checker{m: m, ops: ops, done: make([]bool, len(ops)), seen: make(map[string]bool)}
+	return c.search(m.Init(), 0)
+}
+
+type checker struct {
+	m    Model
+	ops  []Op
+	done []bool
+	seen map[string]bool
+}
+
+// search tries to linearize the remaining operations starting from state.
+func (c *checker) search(state interface{}, n int) bool {
+	if n == len(c.ops) {
+		return true
+	}
+	key := c.key(state)
+	if c.seen[key] {
+		return false
+	}
+	c.seen[key] = true
+	// an operation can go next if it was called before every other remaining
+	// operation returned.
+	min := int64(-1)
+	for i, op := range c.ops {
+		if !c.done[i] && (min == -1 || op.Return < min) {
+			min = op.Return
+		}
+	}
+	for i, op := range c.ops {
+		if c.done[i] {
+			continue
+		}
+		if op.Call > min {
+			break
+		}
+		ok, next := c.m.Step(state, op)
+		if !ok {
+			continue
+		}
+		c.done[i] = true
+		if c.search(next, n+1) {
+			return true
+		}
+		c.done[i] = false
+	}
+	return false
+}
+
+func (c *checker) key(state interface{}) string {
+	var b strings.Builder
+	for _, d := range c.done {
+		if d {
+			b.WriteByte('1')
+		} else {
+			b.WriteByte('0')
+		}
+	}
+	b.WriteByte('|')
+	b.WriteString(c.m.Key(state))
+	return b.String()
+}
+
+// Recorder wraps a queue and records the history of the operations done
+// through it. It is safe for concurrent use.
+type Recorder struct {
+	q       Queue
+	clock   atomic.Int64
+	mu      sync.Mutex
+	history []Op
+}
+
+// NewRecorder returns a Recorder for q.
+func NewRecorder(q Queue) *Recorder {
+	return &Recorder{q: q}
+}
+
+// Enqueue enqueues item onto the queue and records the operation.
+func (r *Recorder) Enqueue(item interface{}) error {
+	call := r.clock.Add(1)
+	err := r.q.Enqueue(item)
+	r.record(Op{Kind: OpEnqueue, Value: item, OK: err == nil, Call: call, Return: r.clock.Add(1)})
+	return err
+}
+
+// Dequeue dequeues an item from the queue and records the operation.
+func (r *Recorder) Dequeue() (interface{}, bool) {
+	call := r.clock.Add(1)
+	v, ok := r.q.Dequeue()
+	r.record(Op{Kind: OpDequeue, Value: v, OK: ok, Call: call, Return: r.clock.Add(1)})
+	return v, ok
+}
+
+func (r *Recorder) record(op Op) {
+	r.mu.Lock()
+	r.history = append(r.history, op)
+	r.mu.Unlock()
+}
+
+// History returns the operations recorded so far.
+func (r *Recorder) History() []Op {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	h := make([]Op, len(r.history))
+	copy(h, r.history)
+	return h
+}
@@ -0,0 +1,100 @@
+package stress
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/mohae/firkin/queue"
+)
+
+func TestLinearizable(t *testing.T) {
+	tests := []struct {
+		name     string
+		m        Model
+		history  []Op
+		expected bool
+	}{
+		{"sequential fifo", FIFO(0), []Op{
+			{Kind: OpEnqueue, Value: 1, OK: true, Call: 0, Return: 1},
+			{Kind: OpEnqueue, Value: 2, OK: true, Call: 2, Return: 3},
+			{Kind: OpDequeue, Value: 1, OK: true, Call: 4, Return: 5},
+			{Kind: OpDequeue, Value: 2, OK: true, Call: 6, Return: 7},
+		}, true},
+		{"sequential reordered", FIFO(0), []Op{
+			{Kind: OpEnqueue, Value: 1, OK: true, Call: 0, Return: 1},
+			{Kind: OpEnqueue, Value: 2, OK: true, Call: 2, Return: 3},
+			{Kind: OpDequeue, Value: 2, OK: true, Call: 4, Return: 5},
+		}, false},
+		{"concurrent enqueues", FIFO(0), []Op{
+			{Kind: OpEnqueue, Value: 1, OK: true, Call: 0, Return: 3},
+			{Kind: OpEnqueue, Value: 2, OK: true, Call: 1, Return: 2},
+			{Kind: OpDequeue, Value: 2, OK: true, Call: 4, Return: 5},
+			{Kind: OpDequeue, Value: 1, OK: true, Call: 6, Return: 7},
+		}, true},
+		{"dequeue of an item never enqueued", FIFO(0), []Op{
+			{Kind: OpDequeue, Value: 1, OK: true, Call: 0, Return: 1},
+		}, false},
+		{"empty dequeue while not empty", FIFO(0), []Op{
+			{Kind: OpEnqueue, Value: 1, OK: true, Call: 0, Return: 1},
+			{Kind: OpDequeue, OK: false, Call: 2, Return: 3},
+		}, false},
+		{"full", FIFO(1), []Op{
+			{Kind: OpEnqueue, Value: 1, OK: true, Call: 0, Return: 1},
+			{Kind: OpEnqueue, Value: 2, OK: false, Call: 2, Return: 3},
+		}, true},
+		{"not full", FIFO(2), []Op{
+			{Kind: OpEnqueue, Value: 1, OK: true, Call: 0, Return: 1},
+			{Kind: OpEnqueue, Value: 2, OK: false, Call: 2, Return: 3},
+		}, false},
+		{"priority", Priority(), []Op{
+			{Kind: OpEnqueue, Value: "a", Priority: 1, OK: true, Call: 0, Return: 1},
+			{Kind: OpEnqueue, Value: "b", Priority: 3, OK: true, Call: 2, Return: 3},
+			{Kind: OpEnqueue, Value: "c", Priority: 3, OK: true, Call: 4, Return: 5},
+			{Kind: OpDequeue, Value: "c", OK: true, Call: 6, Return: 7},
+			{Kind: OpDequeue, Value: "b", OK: true, Call: 8, Return: 9},
+			{Kind: OpDequeue, Value: "a", OK: true, Call: 10, Return: 11},
+		}, true},
+		{"priority inverted", Priority(), []Op{
+			{Kind: OpEnqueue, Value: "a", Priority: 1, OK: true, Call: 0, Return: 1},
+			{Kind: OpEnqueue, Value: "b", Priority: 3, OK: true, Call: 2, Return: 3},
+			{Kind: OpDequeue, Value: "a", OK: true, Call: 4, Return: 5},
+		}, false},
+	}
+	for _, test := range tests {
+		if got := Linearizable(test.m, test.history); got != test.expected {
+			t.Errorf("%s: expected %t, got %t", test.name, test.expected, got)
+		}
+	}
+}
+
+func TestLinearizableRecorded(t *testing.T) {
+	tests := []struct {
+		name string
+		q    Queue
+		m    Model
+	}{
+		{"Queue", queue.NewQueue(4), FIFO(0)},
+		{"Circular", queue.NewCircular(4), FIFO(4)},
+	}
+	for _, test := range tests {
+		r := NewRecorder(test.q)
+		var wg sync.WaitGroup
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < 6; i++ {
+					if i%2 == 0 {
+						_ = r.Enqueue(g*100 + i)
+						continue
+					}
+					r.Dequeue()
+				}
+			}(g)
+		}
+		wg.Wait()
+		if !Linearizable(test.m, r.History()) {
+			t.Errorf("%s: expected the recorded history to be linearizable", test.name)
+		}
+	}
+}
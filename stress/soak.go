@@ -0,0 +1,309 @@
+package stress
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SoakConfig configures a soak run.
+type SoakConfig struct {
+	Duration  time.Duration // how long to run for; if 0, a minute is used.
+	Epoch     time.Duration // how often the queue is drained and audited; if 0, 10s is used.
+	Producers int           // the number of goroutines enqueueing.
+	Consumers int           // the number of goroutines dequeueing; must be 1 for MPSC queues.
+	// CheckOrder checks that each consumer sees every producer's items in the
+	// order that they were enqueued. Only set this for FIFO queues.
+	CheckOrder bool
+	// MaxHeapGrowth is how much the heap may grow, in bytes, between the end
+	// of the first epoch and the end of any later one before it is reported
+	// as a leak. If 0, 64MB is used.
+	MaxHeapGrowth uint64
+	// Logf, if not nil, is called with a summary of each epoch.
+	Logf func(format string, args ...interface{})
+}
+
+// SoakResult is the outcome of a soak run.
+type SoakResult struct {
+	Epochs     int
+	Enqueued   int
+	Dequeued   int
+	Reordered  int      // the number of items a consumer saw out of order.
+	HeapStart  uint64   // the heap in use at the end of the first epoch.
+	HeapMax    uint64   // the most heap in use at the end of an epoch.
+	Violations []string // every invariant that didn't hold, and when.
+	Elapsed    time.Duration
+}
+
+// Err returns an error describing what went wrong, if anything.
+func (r SoakResult) Err() error {
+	if len(r.Violations) == 0 && r.Reordered == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d epochs, %d enqueued, %d dequeued: %d reordered, %d violations, first: %v", r.Epochs, r.Enqueued, r.Dequeued, r.Reordered, len(r.Violations), r.Violations)
+}
+
+// batcher is implemented by queues with batch operations; the soak's
+// workload uses them, if the queue has them, as well as single item ones.
+type batcher interface {
+	EnqueueAll([]interface{}) (int, error)
+	DequeueN(int) []interface{}
+}
+
+// sizer is implemented by queues whose length and capacity can be audited.
+type sizer interface {
+	Len() int
+	Cap() int
+}
+
+// tally is the count and sum of the seqs of one producer's items; together
+// they detect lost and duplicated items without remembering every item.
+type tally struct {
+	n, sum int
+}
+
+// Soak runs a mixed workload of enqueues and dequeues against q, which must be
+// empty, for cfg.Duration, to find leaks and rare races that a short stress
+// run doesn't. While the workload runs, the queue's length is audited against
+// its capacity, if it has them. At the end of every epoch the producers stop,
+// the queue is drained, and the audit checks that every item enqueued was
+// dequeued exactly once, that the queue is empty, and that the heap hasn't
+// grown by more than cfg.MaxHeapGrowth since the first epoch.
+func Soak(q Queue, cfg SoakConfig) SoakResult {
+	if cfg.Duration == 0 {
+		cfg.Duration = time.Minute
+	}
+	if cfg.Epoch == 0 {
+		cfg.Epoch = 10 * time.Second
+	}
+	if cfg.Producers < 1 {
+		cfg.Producers = 1
+	}
+	if cfg.Consumers < 1 {
+		cfg.Consumers = 1
+	}
+	if cfg.MaxHeapGrowth == 0 {
+		cfg.MaxHeapGrowth = 64 << 20
+	}
+	s := &soak{
+		q:         q,
+		cfg:       cfg,
+		seq:       make([]int, cfg.Producers),
+		enqueued:  make([]tally, cfg.Producers),
+		last:      make([][]int, cfg.Consumers),
+		dequeued:  make([][]tally, cfg.Consumers),
+		reordered: make([]int, cfg.Consumers),
+	}
+	for c := range s.last {
+		s.last[c] = make([]int, cfg.Producers)
+		for p := range s.last[c] {
+			s.last[c][p] = -1
+		}
+		s.dequeued[c] = make([]tally, cfg.Producers)
+	}
+	start := time.Now()
+	end := start.Add(cfg.Duration)
+	for e := 0; e == 0 || time.Now().Before(end); e++ {
+		epochEnd := time.Now().Add(cfg.Epoch)
+		if epochEnd.After(end) {
+			epochEnd = end
+		}
+		s.epoch(e, epochEnd)
+		s.audit(e)
+		s.r.Epochs++
+	}
+	s.r.Elapsed = time.Since(start)
+	return s.r
+}
+
+// soak is the state of a soak run.
+type soak struct {
+	q   Queue
+	cfg SoakConfig
+	r   SoakResult
+	mu  sync.Mutex // protects r.Violations.
+	// per producer; each is only used by its producer during an epoch.
+	seq      []int
+	enqueued []tally
+	// per consumer, per producer; each is only used by its consumer.
+	last      [][]int
+	dequeued  [][]tally
+	reordered []int
+}
+
+// violation records an invariant that didn't hold.
+func (s *soak) violation(epoch int, format string, args ...interface{}) {
+	s.mu.Lock()
+	s.r.Violations = append(s.r.Violations, fmt.Sprintf("epoch %d: ", epoch)+fmt.Sprintf(format, args...))
+	s.mu.Unlock()
+}
+
+// epoch runs the workload until end, then stops the producers and drains the
+// queue.
+func (s *soak) epoch(e int, end time.Time) {
+	var stop, producersDone atomic.Bool
+	var producers, consumers sync.WaitGroup
+	for p := 0; p < s.cfg.Producers; p++ {
+		producers.Add(1)
+		go func(p int) {
+			defer producers.Done()
+			r := rand.New(rand.NewSource(int64(e*s.cfg.Producers + p)))
+			for !stop.Load() {
+				s.produce(p, r)
+			}
+		}(p)
+	}
+	for c := 0; c < s.cfg.Consumers; c++ {
+		consumers.Add(1)
+		go func(c int) {
+			defer consumers.Done()
+			r := rand.New(rand.NewSource(int64(-e*s.cfg.Consumers - c - 1)))
+			for {
+				// once the producers are done, nothing to dequeue means the
+				// queue has been drained.
+				done := producersDone.Load()
+				if !s.consume(c, r) && done {
+					return
+				}
+			}
+		}(c)
+	}
+	auditDone := make(chan struct{})
+	go s.auditLen(e, &stop, auditDone)
+	time.Sleep(time.Until(end))
+	stop.Store(true)
+	producers.Wait()
+	producersDone.Store(true)
+	consumers.Wait()
+	<-auditDone
+}
+
+// produce enqueues an item or, if the queue has batch operations, sometimes a
+// batch of them. Items that can't be enqueued, e.g. because a bounded queue is
+// full, aren't counted and their seq is reused.
+func (s *soak) produce(p int, r *rand.Rand) {
+	if b, ok := s.q.(batcher); ok && r.Intn(8) == 0 {
+		items := make([]interface{}, 1+r.Intn(16))
+		for i := range items {
+			items[i] = token{producer: p, seq: s.seq[p] + i}
+		}
+		n, _ := b.EnqueueAll(items)
+		for i := 0; i < n; i++ {
+			s.enqueued[p].n++
+			s.enqueued[p].sum += s.seq[p]
+			s.seq[p]++
+		}
+		if n < len(items) {
+			runtime.Gosched()
+		}
+		return
+	}
+	if s.q.Enqueue(token{producer: p, seq: s.seq[p]}) != nil {
+		runtime.Gosched()
+		return
+	}
+	s.enqueued[p].n++
+	s.enqueued[p].sum += s.seq[p]
+	s.seq[p]++
+}
+
+// consume dequeues an item or, if the queue has batch operations, sometimes a
+// batch of them. It returns false if nothing was dequeued.
+func (s *soak) consume(c int, r *rand.Rand) bool {
+	if b, ok := s.q.(batcher); ok && r.Intn(8) == 0 {
+		items := b.DequeueN(1 + r.Intn(16))
+		for _, v := range items {
+			s.record(c, v)
+		}
+		if len(items) == 0 {
+			runtime.Gosched()
+		}
+		return len(items) > 0
+	}
+	v, ok := s.q.Dequeue()
+	if !ok {
+		runtime.Gosched()
+		return false
+	}
+	s.record(c, v)
+	return true
+}
+
+// record records that consumer c dequeued v.
+func (s *soak) record(c int, v interface{}) {
+	tok := v.(token)
+	if tok.seq < s.last[c][tok.producer] {
+		s.reordered[c]++
+	}
+	s.last[c][tok.producer] = tok.seq
+	s.dequeued[c][tok.producer].n++
+	s.dequeued[c][tok.producer].sum += tok.seq
+}
+
+// auditLen checks, while the workload runs, that the queue's length is
+// within its capacity.
+func (s *soak) auditLen(e int, stop *atomic.Bool, done chan<- struct{}) {
+	defer close(done)
+	sz, ok := s.q.(sizer)
+	if !ok {
+		return
+	}
+	for !stop.Load() {
+		l, cp := sz.Len(), sz.Cap()
+		if l < 0 || l > cp {
+			s.violation(e, "len %d is outside of [0, cap %d]", l, cp)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// audit checks the invariants that hold once the queue has been drained.
+func (s *soak) audit(e int) {
+	var enq, deq int
+	for p := 0; p < s.cfg.Producers; p++ {
+		var got tally
+		for c := range s.dequeued {
+			got.n += s.dequeued[c][p].n
+			got.sum += s.dequeued[c][p].sum
+		}
+		want := s.enqueued[p]
+		switch {
+		case got.n < want.n:
+			s.violation(e, "producer %d: %d items lost", p, want.n-got.n)
+		case got.n > want.n:
+			s.violation(e, "producer %d: %d items duplicated", p, got.n-want.n)
+		case got.sum != want.sum:
+			s.violation(e, "producer %d: items lost and duplicated", p)
+		}
+		enq += want.n
+		deq += got.n
+	}
+	s.r.Enqueued, s.r.Dequeued = enq, deq
+	if s.cfg.CheckOrder {
+		s.r.Reordered = 0
+		for _, n := range s.reordered {
+			s.r.Reordered += n
+		}
+	}
+	if sz, ok := s.q.(sizer); ok && sz.Len() != 0 {
+		s.violation(e, "len is %d after draining", sz.Len())
+	}
+	runtime.GC()
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	if e == 0 {
+		s.r.HeapStart = ms.HeapAlloc
+	}
+	if ms.HeapAlloc > s.r.HeapMax {
+		s.r.HeapMax = ms.HeapAlloc
+	}
+	if ms.HeapAlloc > s.r.HeapStart+s.cfg.MaxHeapGrowth {
+		s.violation(e, "heap grew from %d to %d bytes", s.r.HeapStart, ms.HeapAlloc)
+	}
+	if s.cfg.Logf != nil {
+		s.cfg.Logf("epoch %d: %d enqueued, %d dequeued, %d reordered, heap %d bytes", e, enq, deq, s.r.Reordered, ms.HeapAlloc)
+	}
+}
@@ -0,0 +1,71 @@
+package stress
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/mohae/firkin/queue"
+)
+
+var soakFor = flag.Duration("soak", 0, "run the soak tests for this long, e.g. 4h")
+
+func TestSoak(t *testing.T) {
+	cfg := SoakConfig{Duration: 200 * time.Millisecond, Epoch: 50 * time.Millisecond, Producers: 4}
+	if *soakFor > 0 {
+		cfg.Duration, cfg.Epoch, cfg.Logf = *soakFor, 0, t.Logf
+	}
+	tests := []struct {
+		name       string
+		q          func() Queue
+		consumers  int
+		checkOrder bool
+	}{
+		{"Queue", func() Queue { return queue.NewQueue(64) }, 4, true},
+		{"Circular", func() Queue { return queue.NewCircular(64) }, 4, true},
+		{"Sharded", func() Queue { return queue.NewSharded(4, 16) }, 4, false},
+		{"MPMC", func() Queue { return queue.NewMPMC(64) }, 4, true},
+		{"LinkedMPSC", func() Queue { return queue.NewLinkedMPSC(0) }, 1, true},
+	}
+	for _, test := range tests {
+		cfg := cfg
+		cfg.Consumers, cfg.CheckOrder = test.consumers, test.checkOrder
+		r := Soak(test.q(), cfg)
+		if err := r.Err(); err != nil {
+			t.Errorf("%s: %s", test.name, err)
+		}
+		if r.Epochs < 1 || r.Enqueued == 0 || r.Dequeued != r.Enqueued {
+			t.Errorf("%s: expected every item enqueued over >= 1 epoch to be dequeued, got %+v", test.name, r)
+		}
+	}
+}
+
+// duplicator enqueues every 10th item twice.
+type duplicator struct {
+	queue.Queuer
+	n int
+}
+
+func (d *duplicator) Enqueue(item interface{}) error {
+	d.n++
+	if d.n%10 == 0 {
+		d.Queuer.Enqueue(item)
+	}
+	return d.Queuer.Enqueue(item)
+}
+
+func TestSoakDetects(t *testing.T) {
+	cfg := SoakConfig{Duration: 20 * time.Millisecond, Epoch: 10 * time.Millisecond}
+	tests := []struct {
+		name string
+		q    Queue
+	}{
+		{"loss", &lossy{Queuer: queue.NewQueue(64)}},
+		{"duplication", &duplicator{Queuer: queue.NewQueue(64)}},
+	}
+	for _, test := range tests {
+		if r := Soak(test.q, cfg); len(r.Violations) == 0 {
+			t.Errorf("%s: expected a violation, got %+v", test.name, r)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// Package stress provides a harness that hammers a queue with concurrent
+// producers and consumers and checks that no items were lost, duplicated, or
+// reordered.
+//
+// The harness is most useful for the lock-free queues and is meant to be run
+// on weak memory model architectures, e.g. ARM, as well as amd64. The
+// package's tests run a short version by default; use the -long flag to run
+// the long version:
+//
+//	go test ./stress -long
+//
+// Soak runs a mixed workload for hours, auditing the queue's invariants and
+// the heap's growth as it goes; use the -soak flag to set how long for:
+//
+//	go test ./stress -run Soak -soak 4h -timeout 0
+package stress
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Queue is the interface of the queues that can be tested.
+type Queue interface {
+	Enqueue(interface{}) error
+	Dequeue() (interface{}, bool)
+}
+
+// Config configures a stress run.
+type Config struct {
+	Producers int // the number of goroutines enqueueing.
+	Consumers int // the number of goroutines dequeueing; must be 1 for MPSC queues.
+	Items     int // the number of items each producer enqueues.
+	// CheckOrder checks that each consumer sees every producer's items in the
+	// order that they were enqueued. Only set this for FIFO queues.
+	CheckOrder bool
+	// Timeout is how long the consumers wait for all of the items before
+	// giving up. If 0, a minute is used.
+	Timeout time.Duration
+}
+
+// Result is the outcome of a stress run.
+type Result struct {
+	Enqueued   int // the number of items enqueued.
+	Dequeued   int // the number of items dequeued.
+	Lost       int // the number of items enqueued but never dequeued.
+	Duplicated int // the number of items dequeued more than once.
+	Reordered  int // the number of items a consumer saw out of order.
+	Elapsed    time.Duration
+}
+
+// Err returns an error describing what went wrong, if anything.
+func (r Result) Err() error {
+	if r.Lost == 0 && r.Duplicated == 0 && r.Reordered == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d enqueued, %d dequeued: %d lost, %d duplicated, %d reordered", r.Enqueued, r.Dequeued, r.Lost, r.Duplicated, r.Reordered)
+}
+
+// token is the item enqueued by the producers.
+type token struct {
+	producer int
+	seq      int
+}
+
+// consumer is the record of what one consumer dequeued.
+type consumer struct {
+	seen      [][]int // per producer, per seq, the number of times seen.
+	last      []int   // per producer, the last seq seen.
+	dequeued  int
+	reordered int
+}
+
+// Run runs the stress test described by cfg against q, which must be empty.
+func Run(q Queue, cfg Config) Result {
+	if cfg.Producers < 1 {
+		cfg.Producers = 1
+	}
+	if cfg.Consumers < 1 {
+		cfg.Consumers = 1
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = time.Minute
+	}
+	total := cfg.Producers * cfg.Items
+	var dequeued sync.WaitGroup
+	var mu sync.Mutex
+	var n int // total dequeued across the consumers; protected by mu.
+	deadline := time.Now().Add(cfg.Timeout)
+	start := time.Now()
+
+	consumers := make([]*consumer, cfg.Consumers)
+	for i := range consumers {
+		c := &consumer{seen: make([][]int, cfg.Producers), last: make([]int, cfg.Producers)}
+		for p := range c.seen {
+			c.seen[p] = make([]int, cfg.Items)
+			c.last[p] = -1
+		}
+		consumers[i] = c
+		dequeued.Add(1)
+		go func() {
+			defer dequeued.Done()
+			for {
+				mu.Lock()
+				done := n >= total
+				mu.Unlock()
+				if done || time.Now().After(deadline) {
+					return
+				}
+				v, ok := q.Dequeue()
+				if !ok {
+					runtime.Gosched()
+					continue
+				}
+				tok := v.(token)
+				c.seen[tok.producer][tok.seq]++
+				if tok.seq < c.last[tok.producer] {
+					c.reordered++
+				}
+				c.last[tok.producer] = tok.seq
+				c.dequeued++
+				mu.Lock()
+				n++
+				mu.Unlock()
+			}
+		}()
+	}
+
+	var enqueued sync.WaitGroup
+	for p := 0; p < cfg.Producers; p++ {
+		enqueued.Add(1)
+		go func(p int) {
+			defer enqueued.Done()
+			for i := 0; i < cfg.Items; i++ {
+				// a bounded queue may be full; keep trying until the consumers
+				// make room.
+				for q.Enqueue(token{producer: p, seq: i}) != nil {
+					if time.Now().After(deadline) {
+						return
+					}
+					runtime.Gosched()
+				}
+			}
+		}(p)
+	}
+	enqueued.Wait()
+	dequeued.Wait()
+
+	r := Result{Enqueued: total, Elapsed: time.Since(start)}
+	for p := 0; p < cfg.Producers; p++ {
+		for i := 0; i < cfg.Items; i++ {
+			var cnt int
+			for _, c := range consumers {
+				cnt += c.seen[p][i]
+			}
+			switch {
+			case cnt == 0:
+				r.Lost++
+			case cnt > 1:
+				r.Duplicated += cnt - 1
+			}
+		}
+	}
+	for _, c := range consumers {
+		r.Dequeued += c.dequeued
+		if cfg.CheckOrder {
+			r.Reordered += c.reordered
+		}
+	}
+	return r
+}
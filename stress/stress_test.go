@@ -0,0 +1,66 @@
+package stress
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/mohae/firkin/queue"
+)
+
+var long = flag.Bool("long", false, "run the long version of the stress tests")
+
+// items returns the number of items each producer should enqueue.
+func items() int {
+	if *long {
+		return 1000000
+	}
+	return 2000
+}
+
+func TestStress(t *testing.T) {
+	tests := []struct {
+		name       string
+		q          func() Queue
+		consumers  int
+		checkOrder bool
+	}{
+		{"Queue", func() Queue { return queue.NewQueue(64) }, 4, true},
+		{"Circular", func() Queue { return queue.NewCircular(64) }, 4, true},
+		{"Sharded", func() Queue { return queue.NewSharded(4, 16) }, 4, false},
+		{"LinkedMPSC", func() Queue { return queue.NewLinkedMPSC(0) }, 1, true},
+	}
+	for _, test := range tests {
+		r := Run(test.q(), Config{Producers: 8, Consumers: test.consumers, Items: items(), CheckOrder: test.checkOrder})
+		if err := r.Err(); err != nil {
+			t.Errorf("%s: %s", test.name, err)
+		}
+		if r.Dequeued != r.Enqueued {
+			t.Errorf("%s: expected %d items to be dequeued, got %d", test.name, r.Enqueued, r.Dequeued)
+		}
+	}
+}
+
+// lossy drops every 10th item.
+type lossy struct {
+	queue.Queuer
+	n int
+}
+
+func (l *lossy) Enqueue(item interface{}) error {
+	l.n++
+	if l.n%10 == 0 {
+		return nil
+	}
+	return l.Queuer.Enqueue(item)
+}
+
+func TestStressDetectsLoss(t *testing.T) {
+	r := Run(&lossy{Queuer: queue.NewQueue(64)}, Config{Producers: 1, Items: 100, Timeout: 100 * time.Millisecond})
+	if r.Lost != 10 {
+		t.Errorf("expected 10 lost items, got %d", r.Lost)
+	}
+	if r.Err() == nil {
+		t.Error("expected an error, got nil")
+	}
+}
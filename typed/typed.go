@@ -0,0 +1,144 @@
+// Package typed provides type-safe, generic versions of the queues in package
+// queue: a bounded, circular, queue and an unbounded queue. The items are
+// stored as T, not interface{}, so enqueueing a value doesn't allocate and
+// dequeued items don't need a type assertion.
+package typed
+
+import (
+	"sync"
+)
+
+// Circular is a bounded queue of T implemented as a circular queue.
+type Circular[T any] struct {
+	mu sync.Mutex
+	u  UnsyncCircular[T]
+}
+
+// NewCircular returns an empty circular queue that holds up to size items. If
+// size < 1, the queue holds 1 item.
+func NewCircular[T any](size int) *Circular[T] {
+	return &Circular[T]{u: *NewUnsyncCircular[T](size)}
+}
+
+// Enqueue adds an item to the queue. An error is returned if the queue is
+// full.
+func (c *Circular[T]) Enqueue(item T) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.u.Enqueue(item)
+}
+
+// Dequeue removes the next item from the queue and returns it. If the queue
+// is empty, a false will be returned.
+func (c *Circular[T]) Dequeue() (T, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.u.Dequeue()
+}
+
+// Peek returns the next item in the queue without removing it. If the queue
+// is empty, a false will be returned.
+func (c *Circular[T]) Peek() (T, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.u.Peek()
+}
+
+// IsEmpty returns whether or not the queue is empty.
+func (c *Circular[T]) IsEmpty() bool {
+	return c.Len() == 0
+}
+
+// IsFull returns whether or not the queue is full.
+func (c *Circular[T]) IsFull() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.u.IsFull()
+}
+
+// Len returns the number of items in the queue.
+func (c *Circular[T]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.u.Len()
+}
+
+// Cap returns the number of items the queue can hold.
+func (c *Circular[T]) Cap() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.u.Cap()
+}
+
+// Reset empties the queue.
+func (c *Circular[T]) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.u.Reset()
+}
+
+// Queue is an unbounded queue of T; it grows as needed.
+type Queue[T any] struct {
+	mu sync.Mutex
+	u  UnsyncQueue[T]
+}
+
+// NewQueue returns an empty queue with an initial capacity of size.
+func NewQueue[T any](size int) *Queue[T] {
+	return &Queue[T]{u: *NewUnsyncQueue[T](size)}
+}
+
+// Enqueue adds an item to the queue. This never fails; the error is for
+// consistency with Circular.
+func (q *Queue[T]) Enqueue(item T) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.u.Enqueue(item)
+}
+
+// Dequeue removes the next item from the queue and returns it. If the queue
+// is empty, a false will be returned.
+func (q *Queue[T]) Dequeue() (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.u.Dequeue()
+}
+
+// Peek returns the next item in the queue without removing it. If the queue
+// is empty, a false will be returned.
+func (q *Queue[T]) Peek() (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.u.Peek()
+}
+
+// IsEmpty returns whether or not the queue is empty.
+func (q *Queue[T]) IsEmpty() bool {
+	return q.Len() == 0
+}
+
+// IsFull returns false; an unbounded queue is never full.
+func (q *Queue[T]) IsFull() bool {
+	return false
+}
+
+// Len returns the number of items in the queue.
+func (q *Queue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.u.Len()
+}
+
+// Cap returns the queue's current capacity.
+func (q *Queue[T]) Cap() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.u.Cap()
+}
+
+// Reset empties the queue; its capacity is kept.
+func (q *Queue[T]) Reset() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.u.Reset()
+}
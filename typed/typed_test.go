@@ -0,0 +1,112 @@
+package typed
+
+import (
+	"testing"
+)
+
+// fifo is the method set shared by the typed queues.
+type fifo[T any] interface {
+	Enqueue(T) error
+	Dequeue() (T, bool)
+	Peek() (T, bool)
+	IsEmpty() bool
+	IsFull() bool
+	Len() int
+	Cap() int
+	Reset()
+}
+
+func TestFIFO(t *testing.T) {
+	tests := []struct {
+		name string
+		q    fifo[int]
+	}{
+		{"circular", NewCircular[int](3)},
+		{"queue", NewQueue[int](2)},
+		{"unsync circular", NewUnsyncCircular[int](3)},
+		{"unsync queue", NewUnsyncQueue[int](2)},
+	}
+	for _, test := range tests {
+		q := test.q
+		if _, ok := q.Dequeue(); ok {
+			t.Errorf("%s: expected dequeue of an empty queue to return false", test.name)
+		}
+		// wrap around more than once.
+		var next, expected int
+		for round := 0; round < 4; round++ {
+			for i := 0; i < 3; i++ {
+				if err := q.Enqueue(next); err != nil {
+					t.Fatalf("%s: unexpected error: %s", test.name, err)
+				}
+				next++
+			}
+			if v, ok := q.Peek(); !ok || v != expected {
+				t.Errorf("%s: expected peek to return %d true, got %d %t", test.name, expected, v, ok)
+			}
+			for i := 0; i < 2; i++ {
+				v, ok := q.Dequeue()
+				if !ok || v != expected {
+					t.Errorf("%s: expected %d true, got %d %t", test.name, expected, v, ok)
+				}
+				expected++
+			}
+			for q.Len() > 0 {
+				v, _ := q.Dequeue()
+				if v != expected {
+					t.Errorf("%s: expected %d, got %d", test.name, expected, v)
+				}
+				expected++
+			}
+		}
+		_ = q.Enqueue(1)
+		q.Reset()
+		if !q.IsEmpty() {
+			t.Errorf("%s: expected the queue to be empty after reset", test.name)
+		}
+	}
+}
+
+func TestCircularFull(t *testing.T) {
+	c := NewCircular[string](2)
+	_ = c.Enqueue("a")
+	_ = c.Enqueue("b")
+	if !c.IsFull() {
+		t.Error("expected the queue to be full")
+	}
+	err := c.Enqueue("c")
+	if err == nil || err.Error() != "queue full: cannot enqueue c" {
+		t.Errorf("expected queue full error, got %v", err)
+	}
+	if c.Cap() != 2 {
+		t.Errorf("expected cap to be 2, got %d", c.Cap())
+	}
+}
+
+func TestQueueReleases(t *testing.T) {
+	q := NewQueue[*int](2)
+	n := 1
+	_ = q.Enqueue(&n)
+	_ = q.Enqueue(&n)
+	q.Dequeue()
+	if q.u.items[0] != nil {
+		t.Error("expected the dequeued slot to be cleared")
+	}
+}
+
+func BenchmarkCircular(b *testing.B) {
+	c := NewCircular[int](1024)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = c.Enqueue(i)
+		c.Dequeue()
+	}
+}
+
+func BenchmarkUnsyncCircular(b *testing.B) {
+	c := NewUnsyncCircular[int](1024)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = c.Enqueue(i)
+		c.Dequeue()
+	}
+}
@@ -0,0 +1,166 @@
+package typed
+
+import (
+	"fmt"
+)
+
+// UnsyncCircular is Circular without the lock: it is not safe for concurrent
+// use. It is for callers that already serialize access to the queue, e.g. a
+// queue owned by one goroutine, where the lock is pure overhead.
+type UnsyncCircular[T any] struct {
+	items []T
+	head  int
+	len   int
+}
+
+// NewUnsyncCircular returns an empty, unsynchronized, circular queue that
+// holds up to size items. If size < 1, the queue holds 1 item.
+func NewUnsyncCircular[T any](size int) *UnsyncCircular[T] {
+	if size < 1 {
+		size = 1
+	}
+	return &UnsyncCircular[T]{items: make([]T, size)}
+}
+
+// Enqueue adds an item to the queue. An error is returned if the queue is
+// full.
+func (c *UnsyncCircular[T]) Enqueue(item T) error {
+	if c.len == len(c.items) {
+		return fmt.Errorf("queue full: cannot enqueue %v", item)
+	}
+	c.items[(c.head+c.len)%len(c.items)] = item
+	c.len++
+	return nil
+}
+
+// Dequeue removes the next item from the queue and returns it. If the queue
+// is empty, a false will be returned.
+func (c *UnsyncCircular[T]) Dequeue() (T, bool) {
+	var zero T
+	if c.len == 0 {
+		return zero, false
+	}
+	item := c.items[c.head]
+	c.items[c.head] = zero // don't hold a reference to a dequeued item.
+	c.head = (c.head + 1) % len(c.items)
+	c.len--
+	return item, true
+}
+
+// Peek returns the next item in the queue without removing it. If the queue
+// is empty, a false will be returned.
+func (c *UnsyncCircular[T]) Peek() (T, bool) {
+	if c.len == 0 {
+		var zero T
+		return zero, false
+	}
+	return c.items[c.head], true
+}
+
+// IsEmpty returns whether or not the queue is empty.
+func (c *UnsyncCircular[T]) IsEmpty() bool {
+	return c.len == 0
+}
+
+// IsFull returns whether or not the queue is full.
+func (c *UnsyncCircular[T]) IsFull() bool {
+	return c.len == len(c.items)
+}
+
+// Len returns the number of items in the queue.
+func (c *UnsyncCircular[T]) Len() int {
+	return c.len
+}
+
+// Cap returns the number of items the queue can hold.
+func (c *UnsyncCircular[T]) Cap() int {
+	return len(c.items)
+}
+
+// Reset empties the queue.
+func (c *UnsyncCircular[T]) Reset() {
+	clear(c.items)
+	c.head = 0
+	c.len = 0
+}
+
+// UnsyncQueue is Queue without the lock: it is not safe for concurrent use;
+// see UnsyncCircular.
+type UnsyncQueue[T any] struct {
+	items []T
+	head  int
+}
+
+// NewUnsyncQueue returns an empty, unsynchronized, queue with an initial
+// capacity of size.
+func NewUnsyncQueue[T any](size int) *UnsyncQueue[T] {
+	return &UnsyncQueue[T]{items: make([]T, 0, size)}
+}
+
+// Enqueue adds an item to the queue. This never fails; the error is for
+// consistency with UnsyncCircular.
+func (q *UnsyncQueue[T]) Enqueue(item T) error {
+	// reuse the space at the front before growing.
+	if len(q.items) == cap(q.items) && q.head >= len(q.items)/2 {
+		n := copy(q.items, q.items[q.head:])
+		clear(q.items[n:])
+		q.items = q.items[:n]
+		q.head = 0
+	}
+	q.items = append(q.items, item)
+	return nil
+}
+
+// Dequeue removes the next item from the queue and returns it. If the queue
+// is empty, a false will be returned.
+func (q *UnsyncQueue[T]) Dequeue() (T, bool) {
+	var zero T
+	if q.head == len(q.items) {
+		return zero, false
+	}
+	item := q.items[q.head]
+	q.items[q.head] = zero
+	q.head++
+	if q.head == len(q.items) {
+		q.head = 0
+		q.items = q.items[:0]
+	}
+	return item, true
+}
+
+// Peek returns the next item in the queue without removing it. If the queue
+// is empty, a false will be returned.
+func (q *UnsyncQueue[T]) Peek() (T, bool) {
+	if q.head == len(q.items) {
+		var zero T
+		return zero, false
+	}
+	return q.items[q.head], true
+}
+
+// IsEmpty returns whether or not the queue is empty.
+func (q *UnsyncQueue[T]) IsEmpty() bool {
+	return q.head == len(q.items)
+}
+
+// IsFull returns false; an unbounded queue is never full.
+func (q *UnsyncQueue[T]) IsFull() bool {
+	return false
+}
+
+// Len returns the number of items in the queue.
+func (q *UnsyncQueue[T]) Len() int {
+	return len(q.items) - q.head
+}
+
+// Cap returns the queue's current capacity.
+func (q *UnsyncQueue[T]) Cap() int {
+	return cap(q.items)
+}
+
+// Reset empties the queue; its capacity is kept.
+func (q *UnsyncQueue[T]) Reset() {
+	clear(q.items)
+	q.items = q.items[:0]
+	q.head = 0
+}